@@ -0,0 +1,245 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/leg100/pug/internal/app"
+	"github.com/leg100/pug/internal/logging"
+	"github.com/leg100/pug/internal/task"
+	"github.com/leg100/pug/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPI(t *testing.T) {
+	srv := setup(t, "")
+
+	var modules []Module
+	get(t, srv.URL+"/api/modules", http.StatusOK, &modules)
+	require.Len(t, modules, 2)
+	assert.Equal(t, "a", modules[0].Path)
+	assert.Equal(t, "b", modules[1].Path)
+
+	t.Run("get module", func(t *testing.T) {
+		var got Module
+		get(t, fmt.Sprintf("%s/api/modules/%d", srv.URL, modules[0].ID), http.StatusOK, &got)
+		assert.Equal(t, modules[0], got)
+	})
+
+	t.Run("get missing module", func(t *testing.T) {
+		var got Error
+		get(t, srv.URL+"/api/modules/999999", http.StatusNotFound, &got)
+		assert.Contains(t, got.Error, "resource not found")
+	})
+
+	t.Run("get invalid module ID", func(t *testing.T) {
+		var got Error
+		get(t, srv.URL+"/api/modules/foo", http.StatusBadRequest, &got)
+		assert.Equal(t, "invalid mod ID: foo", got.Error)
+	})
+
+	t.Run("create task", func(t *testing.T) {
+		var created CreateTasksResponse
+		post(t, srv.URL+"/api/tasks", CreateTasksRequest{
+			Operation: ValidateOperation,
+			Modules:   []uint{modules[0].ID},
+		}, http.StatusCreated, &created)
+		assert.Nil(t, created.GroupID)
+		require.Len(t, created.Tasks, 1)
+		assert.Equal(t, "validate", created.Tasks[0].Command)
+
+		got := waitForTask(t, srv.URL, created.Tasks[0].ID)
+		assert.Equal(t, task.Exited, got.Status)
+
+		resp, err := http.Get(fmt.Sprintf("%s/api/tasks/%d/output", srv.URL, got.ID))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		output, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Contains(t, string(output), "Success! module a is valid")
+	})
+
+	t.Run("create task group", func(t *testing.T) {
+		var created CreateTasksResponse
+		post(t, srv.URL+"/api/tasks", CreateTasksRequest{
+			Operation: ValidateOperation,
+			Modules:   []uint{modules[0].ID, modules[1].ID},
+		}, http.StatusCreated, &created)
+		require.NotNil(t, created.GroupID)
+		require.Len(t, created.Tasks, 2)
+
+		var group Group
+		get(t, fmt.Sprintf("%s/api/groups/%d", srv.URL, *created.GroupID), http.StatusOK, &group)
+		assert.Len(t, group.Tasks, 2)
+	})
+
+	t.Run("create task with invalid operation", func(t *testing.T) {
+		var got Error
+		post(t, srv.URL+"/api/tasks", CreateTasksRequest{Operation: "destroy-everything"}, http.StatusBadRequest, &got)
+		assert.Equal(t, `invalid operation: "destroy-everything"`, got.Error)
+	})
+
+	t.Run("create task without modules", func(t *testing.T) {
+		var got Error
+		post(t, srv.URL+"/api/tasks", CreateTasksRequest{Operation: InitOperation}, http.StatusBadRequest, &got)
+		assert.Equal(t, "no modules specified", got.Error)
+	})
+
+	t.Run("events", func(t *testing.T) {
+		resp, err := http.Get(srv.URL + "/api/events")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+		scanner := bufio.NewScanner(resp.Body)
+		// Wait for subscription before creating task.
+		require.True(t, scanner.Scan())
+		require.Equal(t, ": subscribed", scanner.Text())
+
+		post(t, srv.URL+"/api/tasks", CreateTasksRequest{
+			Operation: ValidateOperation,
+			Modules:   []uint{modules[1].ID},
+		}, http.StatusCreated, nil)
+
+		for scanner.Scan() {
+			if scanner.Text() != "event: task.created" {
+				continue
+			}
+			require.True(t, scanner.Scan())
+			data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+			require.True(t, ok)
+			var got Task
+			require.NoError(t, json.Unmarshal([]byte(data), &got))
+			assert.Equal(t, "validate", got.Command)
+			assert.Equal(t, modules[1].ID, *got.ModuleID)
+			return
+		}
+		t.Fatal("task created event not received")
+	})
+}
+
+func TestAuthenticate(t *testing.T) {
+	srv := setup(t, "secret")
+
+	tests := []struct {
+		name          string
+		authorization string
+		want          int
+	}{
+		{"valid token", "Bearer secret", http.StatusOK},
+		{"invalid token", "Bearer guess", http.StatusUnauthorized},
+		{"missing token", "", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", srv.URL+"/api/modules", nil)
+			require.NoError(t, err)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			resp.Body.Close()
+			assert.Equal(t, tt.want, resp.StatusCode)
+		})
+	}
+}
+
+func TestStart_UnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pug.sock")
+	stop, err := Start(Options{
+		App:    &app.App{},
+		Listen: "unix:" + path,
+		Logger: logging.Discard,
+	})
+	require.NoError(t, err)
+	t.Cleanup(stop)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+}
+
+func TestStart_GenerateToken(t *testing.T) {
+	dataDir := t.TempDir()
+	stop, err := Start(Options{
+		App:     &app.App{},
+		Listen:  "127.0.0.1:0",
+		DataDir: dataDir,
+		Logger:  logging.Discard,
+	})
+	require.NoError(t, err)
+	t.Cleanup(stop)
+
+	info, err := os.Stat(filepath.Join(dataDir, tokenFilename))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+}
+
+func setup(t *testing.T, token string) *httptest.Server {
+	program, workdir := testutils.Fixture(t)
+
+	app, err := app.New(app.Config{
+		Program:  program,
+		MaxTasks: 2,
+		Workdir:  workdir,
+		DataDir:  t.TempDir(),
+		Logging:  logging.Options{Level: "error"},
+	})
+	require.NoError(t, err)
+	t.Cleanup(app.Cleanup)
+
+	_, _, err = app.Modules.Reload()
+	require.NoError(t, err)
+
+	srv := httptest.NewServer(newHandler(app, token))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func get(t *testing.T, url string, wantStatus int, v any) {
+	t.Helper()
+
+	resp, err := http.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, wantStatus, resp.StatusCode)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(v))
+}
+
+func post(t *testing.T, url string, body any, wantStatus int, v any) {
+	t.Helper()
+
+	b, err := json.Marshal(body)
+	require.NoError(t, err)
+	resp, err := http.Post(url, "application/json", bytes.NewReader(b))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, wantStatus, resp.StatusCode)
+	if v != nil {
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(v))
+	}
+}
+
+// waitForTask polls the task until it finishes.
+func waitForTask(t *testing.T, url string, id uint) Task {
+	t.Helper()
+
+	var got Task
+	require.Eventually(t, func() bool {
+		get(t, fmt.Sprintf("%s/api/tasks/%d", url, id), http.StatusOK, &got)
+		return got.Status.IsFinal()
+	}, 10*time.Second, 50*time.Millisecond)
+	return got
+}
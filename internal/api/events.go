@@ -0,0 +1,81 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/leg100/pug/internal/resource"
+)
+
+// events streams events as server-sent events until the client disconnects.
+// Each event is named after the kind of resource and the type of event, e.g.
+// task.updated, and its data is the JSON representation of the resource.
+func (h *handler) events(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, errors.New("streaming unsupported"))
+		return
+	}
+	var (
+		ctx        = r.Context()
+		modules    = h.Modules.Subscribe(ctx)
+		workspaces = h.Workspaces.Subscribe(ctx)
+		states     = h.States.Subscribe(ctx)
+		tasks      = h.Tasks.TaskBroker.Subscribe(ctx)
+		groups     = h.Tasks.GroupBroker.Subscribe(ctx)
+	)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	// Let the client know it is subscribed.
+	fmt.Fprint(w, ": subscribed\n\n")
+	flusher.Flush()
+
+	for {
+		var (
+			kind      string
+			eventType resource.EventType
+			data      any
+		)
+		select {
+		case event, ok := <-modules:
+			if !ok {
+				return
+			}
+			kind, eventType, data = "module", event.Type, newModule(event.Payload)
+		case event, ok := <-workspaces:
+			if !ok {
+				return
+			}
+			kind, eventType, data = "workspace", event.Type, h.newWorkspace(event.Payload)
+		case event, ok := <-states:
+			if !ok {
+				return
+			}
+			kind, eventType, data = "state", event.Type, newState(event.Payload)
+		case event, ok := <-tasks:
+			if !ok {
+				return
+			}
+			kind, eventType, data = "task", event.Type, newTask(event.Payload)
+		case event, ok := <-groups:
+			if !ok {
+				return
+			}
+			kind, eventType, data = "group", event.Type, newGroup(event.Payload)
+		case <-ctx.Done():
+			return
+		}
+		b, err := json.Marshal(data)
+		if err != nil {
+			h.Logger.Error("encoding API event", "error", err)
+			continue
+		}
+		if _, err := fmt.Fprintf(w, "event: %s.%s\ndata: %s\n\n", kind, eventType, b); err != nil {
+			return
+		}
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,251 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/leg100/pug/internal/app"
+	"github.com/leg100/pug/internal/module"
+	"github.com/leg100/pug/internal/resource"
+	"github.com/leg100/pug/internal/task"
+	"github.com/leg100/pug/internal/workspace"
+)
+
+// handler handles API requests.
+type handler struct {
+	*app.App
+}
+
+// newHandler constructs the API's routes, authenticating requests with the
+// token, unless it is empty.
+func newHandler(app *app.App, token string) http.Handler {
+	h := &handler{App: app}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/modules", h.listModules)
+	mux.HandleFunc("GET /api/modules/{id}", h.getModule)
+	mux.HandleFunc("GET /api/workspaces", h.listWorkspaces)
+	mux.HandleFunc("GET /api/workspaces/{id}", h.getWorkspace)
+	mux.HandleFunc("GET /api/workspaces/{id}/state", h.getState)
+	mux.HandleFunc("GET /api/tasks", h.listTasks)
+	mux.HandleFunc("POST /api/tasks", h.createTasks)
+	mux.HandleFunc("GET /api/tasks/{id}", h.getTask)
+	mux.HandleFunc("GET /api/tasks/{id}/output", h.getTaskOutput)
+	mux.HandleFunc("GET /api/groups", h.listGroups)
+	mux.HandleFunc("GET /api/groups/{id}", h.getGroup)
+	mux.HandleFunc("GET /api/events", h.events)
+	return authenticate(token, mux)
+}
+
+func (h *handler) listModules(w http.ResponseWriter, r *http.Request) {
+	modules := h.Modules.List()
+	slices.SortFunc(modules, func(a, b *module.Module) int { return strings.Compare(a.Path, b.Path) })
+	resp := make([]Module, len(modules))
+	for i, mod := range modules {
+		resp[i] = newModule(mod)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *handler) getModule(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, resource.Module)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	mod, err := h.Modules.Get(id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newModule(mod))
+}
+
+func (h *handler) listWorkspaces(w http.ResponseWriter, r *http.Request) {
+	var opts workspace.ListOptions
+	if v := r.URL.Query().Get("module_id"); v != "" {
+		id, err := parseID(v, resource.Module)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		opts.ModuleID = id
+	}
+	workspaces := h.Workspaces.List(opts)
+	slices.SortFunc(workspaces, func(a, b *workspace.Workspace) int {
+		if c := strings.Compare(a.ModulePath, b.ModulePath); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	resp := make([]Workspace, len(workspaces))
+	for i, ws := range workspaces {
+		resp[i] = h.newWorkspace(ws)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *handler) getWorkspace(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, resource.Workspace)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	ws, err := h.Workspaces.Get(id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, h.newWorkspace(ws))
+}
+
+func (h *handler) getState(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, resource.Workspace)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	state, err := h.States.Get(id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newState(state))
+}
+
+func (h *handler) listTasks(w http.ResponseWriter, r *http.Request) {
+	var opts task.ListOptions
+	for _, status := range r.URL.Query()["status"] {
+		opts.Status = append(opts.Status, task.Status(status))
+	}
+	tasks := h.Tasks.List(opts)
+	resp := make([]Task, len(tasks))
+	for i, t := range tasks {
+		resp[i] = newTask(t)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *handler) getTask(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, resource.Task)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	t, err := h.Tasks.Get(id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newTask(t))
+}
+
+// getTaskOutput writes the task's combined stdout and stderr. If the follow
+// parameter is true then output is streamed until the task finishes.
+func (h *handler) getTaskOutput(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, resource.Task)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	t, err := h.Tasks.Get(id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if follow, _ := strconv.ParseBool(r.URL.Query().Get("follow")); !follow {
+		_, _ = io.Copy(w, t.NewReader(true))
+		return
+	}
+	flusher, _ := w.(http.Flusher)
+	output := t.NewStreamer()
+	for {
+		select {
+		case chunk, ok := <-output:
+			if !ok {
+				return
+			}
+			if _, err := w.Write(chunk); err != nil {
+				go drain(output)
+				return
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		case <-r.Context().Done():
+			go drain(output)
+			return
+		}
+	}
+}
+
+func (h *handler) listGroups(w http.ResponseWriter, r *http.Request) {
+	groups := h.Tasks.ListGroups()
+	resp := make([]Group, len(groups))
+	for i, g := range groups {
+		resp[i] = newGroup(g)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *handler) getGroup(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, resource.TaskGroup)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	g, err := h.Tasks.GetGroup(id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newGroup(g))
+}
+
+// drain discards what remains of a task's output stream, which otherwise
+// blocks until its output is received.
+func drain(output <-chan []byte) {
+	for range output {
+	}
+}
+
+// pathID parses the ID in the request path.
+func pathID(r *http.Request, kind resource.Kind) (resource.ID, error) {
+	return parseID(r.PathValue("id"), kind)
+}
+
+// parseID parses the serial number of a resource ID, optionally prefixed with
+// a #.
+func parseID(s string, kind resource.Kind) (resource.ID, error) {
+	serial, err := strconv.ParseUint(strings.TrimPrefix(s, "#"), 10, 0)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s ID: %s", kind, s)
+	}
+	return resource.MonotonicID{Serial: uint(serial), Kind: kind}, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeServiceError writes an error returned by a service, mapping a missing
+// resource to a not found status.
+func writeServiceError(w http.ResponseWriter, err error) {
+	if errors.Is(err, resource.ErrNotFound) {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	writeError(w, http.StatusInternalServerError, err)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, Error{Error: err.Error()})
+}
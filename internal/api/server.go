@@ -0,0 +1,154 @@
+// Package api provides an opt-in local HTTP API, with which other tools can
+// list resources, create tasks and subscribe to events, via the same services
+// used by the TUI.
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/leg100/pug/internal"
+	"github.com/leg100/pug/internal/app"
+	"github.com/leg100/pug/internal/logging"
+)
+
+// unixPrefix prefixes a listen address to indicate a unix socket path.
+const unixPrefix = "unix:"
+
+// tokenFilename is the name of the file in the data directory to which a
+// generated token is written.
+const tokenFilename = "api-token"
+
+// Options for starting the API server.
+type Options struct {
+	App *app.App
+	// Listen is either a unix socket, in the form unix:PATH, or a loopback
+	// address and port.
+	Listen string
+	// Token authenticates requests. Required when listening on a port, in
+	// which case, if empty, one is generated and written to the data
+	// directory.
+	Token   string
+	DataDir string
+	Logger  logging.Interface
+}
+
+// Start starts the API server in the background. The returned function shuts
+// down the server.
+func Start(opts Options) (func(), error) {
+	ln, token, err := listen(opts)
+	if err != nil {
+		return nil, err
+	}
+	srv := &http.Server{
+		Handler:           newHandler(opts.App, token),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			opts.Logger.Error("serving API", "error", err)
+		}
+	}()
+	opts.Logger.Info("started API server", "address", ln.Addr())
+
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		// Event streams never finish of their own accord, so close any
+		// remaining connections once the timeout expires.
+		if err := srv.Shutdown(ctx); err != nil {
+			_ = srv.Close()
+		}
+		if path, ok := strings.CutPrefix(opts.Listen, unixPrefix); ok {
+			_ = os.Remove(path)
+		}
+	}, nil
+}
+
+// IsUnixSocket is true if the listen address is a unix socket.
+func IsUnixSocket(listen string) bool {
+	return strings.HasPrefix(listen, unixPrefix)
+}
+
+// listen listens on the configured address, returning the listener along with
+// the token with which to authenticate requests, which is empty if
+// authentication is not required.
+func listen(opts Options) (net.Listener, string, error) {
+	if path, ok := strings.CutPrefix(opts.Listen, unixPrefix); ok {
+		// Remove socket left behind by a previous instance.
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, "", fmt.Errorf("removing existing API socket: %w", err)
+		}
+		// Only the current user may connect to the socket. Create it with a
+		// restrictive umask so that there is no window, between creating the
+		// socket and setting its permissions, in which others may connect.
+		restore := restrictUmask()
+		ln, err := net.Listen("unix", path)
+		restore()
+		if err != nil {
+			return nil, "", fmt.Errorf("listening on API socket: %w", err)
+		}
+		if err := os.Chmod(path, 0o600); err != nil {
+			ln.Close()
+			return nil, "", fmt.Errorf("setting API socket permissions: %w", err)
+		}
+		return ln, opts.Token, nil
+	}
+	if err := internal.CheckLoopback(opts.Listen); err != nil {
+		return nil, "", fmt.Errorf("listening on API address: %w", err)
+	}
+	token := opts.Token
+	if token == "" {
+		var err error
+		token, err = GenerateToken(opts.DataDir)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+	ln, err := net.Listen("tcp", opts.Listen)
+	if err != nil {
+		return nil, "", fmt.Errorf("listening on API address: %w", err)
+	}
+	return ln, token, nil
+}
+
+// GenerateToken generates a random token and writes it to a file in the data
+// directory, readable only by the current user.
+func GenerateToken(dataDir string) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generating API token: %w", err)
+	}
+	token := hex.EncodeToString(b)
+	path := filepath.Join(dataDir, tokenFilename)
+	if err := os.WriteFile(path, []byte(token), 0o600); err != nil {
+		return "", fmt.Errorf("writing API token: %w", err)
+	}
+	return token, nil
+}
+
+// authenticate rejects requests lacking a bearer token matching the token.
+func authenticate(token string, next http.Handler) http.Handler {
+	if token == "" {
+		return next
+	}
+	want := []byte("Bearer " + token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got := []byte(r.Header.Get("Authorization"))
+		if subtle.ConstantTimeCompare(got, want) != 1 {
+			writeError(w, http.StatusUnauthorized, errors.New("invalid or missing token"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
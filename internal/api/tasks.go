@@ -0,0 +1,177 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/leg100/pug/internal/plan"
+	"github.com/leg100/pug/internal/resource"
+	"github.com/leg100/pug/internal/state"
+	"github.com/leg100/pug/internal/task"
+)
+
+// Operations for which tasks can be created.
+const (
+	InitOperation     = "init"
+	FormatOperation   = "fmt"
+	ValidateOperation = "validate"
+	PlanOperation     = "plan"
+	ApplyOperation    = "apply"
+	CostOperation     = "cost"
+	StateRmOperation  = "state-rm"
+	StateMvOperation  = "state-mv"
+)
+
+// CreateTasksRequest is the body of a request to create tasks. Module
+// operations (init, fmt, validate) require modules, and the remaining
+// operations require workspaces. A task is created for each module or
+// workspace, and if more than one task is created then they belong to a task
+// group. The exception is cost, for which a single task is created for all the
+// workspaces.
+type CreateTasksRequest struct {
+	Operation  string `json:"operation"`
+	Modules    []uint `json:"modules"`
+	Workspaces []uint `json:"workspaces"`
+	// Upgrade upgrades providers and modules (init).
+	Upgrade bool `json:"upgrade"`
+	// Destroy plans the destruction of all resources (plan, apply).
+	Destroy bool `json:"destroy"`
+	// Targets are resource addresses to target (plan, apply).
+	Targets []string `json:"targets"`
+	// Addresses are the resource addresses to remove (state-rm).
+	Addresses []string `json:"addresses"`
+	// From and To are the source and destination addresses of the resource
+	// to move (state-mv).
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// CreateTasksResponse is the body of a response to a request to create tasks.
+type CreateTasksResponse struct {
+	// GroupID is set if a task group was created.
+	GroupID *uint  `json:"group_id"`
+	Tasks   []Task `json:"tasks"`
+}
+
+func (h *handler) createTasks(w http.ResponseWriter, r *http.Request) {
+	var req CreateTasksRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("decoding request: %w", err))
+		return
+	}
+	specs, err := h.specs(req)
+	if errors.Is(err, resource.ErrNotFound) {
+		writeError(w, http.StatusNotFound, err)
+		return
+	} else if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	var resp CreateTasksResponse
+	if len(specs) == 1 {
+		t, err := h.Tasks.Create(specs[0])
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, fmt.Errorf("creating task: %w", err))
+			return
+		}
+		resp.Tasks = []Task{newTask(t)}
+	} else {
+		g, err := h.Tasks.CreateGroup(specs...)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, fmt.Errorf("creating task group: %w", err))
+			return
+		}
+		resp.GroupID = &g.ID.Serial
+		resp.Tasks = make([]Task, len(g.Tasks))
+		for i, t := range g.Tasks {
+			resp.Tasks[i] = newTask(t)
+		}
+	}
+	writeJSON(w, http.StatusCreated, resp)
+}
+
+// specs constructs a task spec for each module or workspace in the request.
+func (h *handler) specs(req CreateTasksRequest) ([]task.Spec, error) {
+	var (
+		fn   task.SpecFunc
+		kind = resource.Workspace
+		ids  = req.Workspaces
+	)
+	switch req.Operation {
+	case InitOperation:
+		fn = func(id resource.ID) (task.Spec, error) {
+			return h.Modules.Init(id, req.Upgrade)
+		}
+	case FormatOperation:
+		fn = h.Modules.Format
+	case ValidateOperation:
+		fn = h.Modules.Validate
+	case PlanOperation, ApplyOperation:
+		opts := plan.CreateOptions{Destroy: req.Destroy}
+		for _, addr := range req.Targets {
+			opts.TargetAddrs = append(opts.TargetAddrs, state.ResourceAddress(addr))
+		}
+		fn = func(id resource.ID) (task.Spec, error) {
+			if req.Operation == ApplyOperation {
+				return h.Plans.Apply(id, opts)
+			}
+			return h.Plans.Plan(id, opts)
+		}
+	case CostOperation:
+		if len(ids) == 0 {
+			return nil, errors.New("no workspaces specified")
+		}
+		workspaceIDs := make([]resource.ID, len(ids))
+		for i, serial := range ids {
+			workspaceIDs[i] = resource.MonotonicID{Serial: serial, Kind: kind}
+		}
+		spec, err := h.Workspaces.Cost(workspaceIDs...)
+		if err != nil {
+			return nil, err
+		}
+		return []task.Spec{spec}, nil
+	case StateRmOperation:
+		if len(req.Addresses) == 0 {
+			return nil, errors.New("no resource addresses specified")
+		}
+		addrs := make([]state.ResourceAddress, len(req.Addresses))
+		for i, addr := range req.Addresses {
+			addrs[i] = state.ResourceAddress(addr)
+		}
+		fn = func(id resource.ID) (task.Spec, error) {
+			return h.States.Delete(id, addrs...)
+		}
+	case StateMvOperation:
+		if req.From == "" || req.To == "" {
+			return nil, errors.New("source and destination addresses are required")
+		}
+		fn = func(id resource.ID) (task.Spec, error) {
+			return h.States.Move(id, state.ResourceAddress(req.From), state.ResourceAddress(req.To))
+		}
+	default:
+		return nil, fmt.Errorf("invalid operation: %q", req.Operation)
+	}
+	switch req.Operation {
+	case InitOperation, FormatOperation, ValidateOperation:
+		kind = resource.Module
+		ids = req.Modules
+		if len(ids) == 0 {
+			return nil, errors.New("no modules specified")
+		}
+	default:
+		if len(ids) == 0 {
+			return nil, errors.New("no workspaces specified")
+		}
+	}
+	specs := make([]task.Spec, len(ids))
+	for i, serial := range ids {
+		spec, err := fn(resource.MonotonicID{Serial: serial, Kind: kind})
+		if err != nil {
+			return nil, err
+		}
+		specs[i] = spec
+	}
+	return specs, nil
+}
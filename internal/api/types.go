@@ -0,0 +1,174 @@
+package api
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/leg100/pug/internal/module"
+	"github.com/leg100/pug/internal/resource"
+	"github.com/leg100/pug/internal/state"
+	"github.com/leg100/pug/internal/task"
+	"github.com/leg100/pug/internal/workspace"
+)
+
+// The following are the JSON representations of resources. Resources are
+// identified by their serial number.
+
+type Module struct {
+	ID                 uint   `json:"id"`
+	Path               string `json:"path"`
+	Backend            string `json:"backend,omitempty"`
+	CurrentWorkspaceID *uint  `json:"current_workspace_id"`
+	Dependencies       []uint `json:"dependencies"`
+}
+
+type Workspace struct {
+	ID         uint     `json:"id"`
+	Name       string   `json:"name"`
+	ModuleID   uint     `json:"module_id"`
+	ModulePath string   `json:"module_path"`
+	Current    bool     `json:"current"`
+	Cost       *float64 `json:"cost"`
+}
+
+type State struct {
+	WorkspaceID      *uint           `json:"workspace_id"`
+	Serial           int64           `json:"serial"`
+	TerraformVersion string          `json:"terraform_version,omitempty"`
+	Lineage          string          `json:"lineage,omitempty"`
+	Resources        []StateResource `json:"resources"`
+}
+
+type StateResource struct {
+	Address string `json:"address"`
+	Tainted bool   `json:"tainted"`
+}
+
+type Task struct {
+	ID          uint        `json:"id"`
+	GroupID     *uint       `json:"group_id"`
+	ModuleID    *uint       `json:"module_id"`
+	WorkspaceID *uint       `json:"workspace_id"`
+	Command     string      `json:"command"`
+	Program     string      `json:"program"`
+	Args        []string    `json:"args"`
+	Path        string      `json:"path"`
+	Status      task.Status `json:"status"`
+	Summary     string      `json:"summary,omitempty"`
+	Error       string      `json:"error,omitempty"`
+	Created     time.Time   `json:"created"`
+	Updated     time.Time   `json:"updated"`
+}
+
+type Group struct {
+	ID      uint      `json:"id"`
+	Command string    `json:"command"`
+	Tasks   []uint    `json:"tasks"`
+	Errors  []string  `json:"errors,omitempty"`
+	Created time.Time `json:"created"`
+}
+
+// Error is the body of a response to a failed request.
+type Error struct {
+	Error string `json:"error"`
+}
+
+func newModule(mod *module.Module) Module {
+	m := Module{
+		ID:                 mod.ID.Serial,
+		Path:               mod.Path,
+		Backend:            mod.Backend,
+		CurrentWorkspaceID: serial(mod.CurrentWorkspaceID),
+		Dependencies:       []uint{},
+	}
+	for _, id := range mod.Dependencies() {
+		if s := serial(id); s != nil {
+			m.Dependencies = append(m.Dependencies, *s)
+		}
+	}
+	return m
+}
+
+func (h *handler) newWorkspace(ws *workspace.Workspace) Workspace {
+	w := Workspace{
+		ID:         ws.ID.Serial,
+		Name:       ws.Name,
+		ModuleID:   ws.ModuleID.Serial,
+		ModulePath: ws.ModulePath,
+		Cost:       ws.Cost,
+	}
+	if mod, err := h.Modules.Get(ws.ModuleID); err == nil {
+		w.Current = mod.CurrentWorkspaceID == ws.ID
+	}
+	return w
+}
+
+func newState(s *state.State) State {
+	js := State{
+		WorkspaceID:      serial(s.WorkspaceID),
+		Serial:           s.Serial,
+		TerraformVersion: s.TerraformVersion,
+		Lineage:          s.Lineage,
+		Resources:        make([]StateResource, 0, len(s.Resources)),
+	}
+	for addr, res := range s.Resources {
+		js.Resources = append(js.Resources, StateResource{
+			Address: string(addr),
+			Tainted: res.Tainted,
+		})
+	}
+	slices.SortFunc(js.Resources, func(a, b StateResource) int {
+		return strings.Compare(a.Address, b.Address)
+	})
+	return js
+}
+
+func newTask(t *task.Task) Task {
+	state, summary, err := t.Result()
+	jt := Task{
+		ID:          t.ID.Serial,
+		GroupID:     serial(t.TaskGroupID),
+		ModuleID:    serial(t.ModuleID),
+		WorkspaceID: serial(t.WorkspaceID),
+		Command:     t.String(),
+		Program:     t.Program,
+		Args:        append([]string{}, t.Args...),
+		Path:        t.Path,
+		Status:      state,
+		Created:     t.Created,
+		Updated:     t.LastUpdated(),
+	}
+	if summary != nil {
+		jt.Summary = summary.String()
+	}
+	if err != nil {
+		jt.Error = err.Error()
+	}
+	return jt
+}
+
+func newGroup(g *task.Group) Group {
+	jg := Group{
+		ID:      g.ID.Serial,
+		Command: g.Command,
+		Tasks:   make([]uint, len(g.Tasks)),
+		Created: g.Created,
+	}
+	for i, t := range g.Tasks {
+		jg.Tasks[i] = t.ID.Serial
+	}
+	for _, err := range g.CreateErrors {
+		jg.Errors = append(jg.Errors, err.Error())
+	}
+	return jg
+}
+
+// serial returns the serial number of the ID, or nil if the ID is nil.
+func serial(id resource.ID) *uint {
+	mid, ok := id.(resource.MonotonicID)
+	if !ok {
+		return nil
+	}
+	return &mid.Serial
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package api
+
+// restrictUmask does nothing on platforms without a umask.
+func restrictUmask() func() {
+	return func() {}
+}
@@ -0,0 +1,12 @@
+//go:build unix
+
+package api
+
+import "syscall"
+
+// restrictUmask sets the umask so that files are created accessible only to
+// the current user, returning a function that restores the previous umask.
+func restrictUmask() func() {
+	old := syscall.Umask(0o077)
+	return func() { syscall.Umask(old) }
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/leg100/pug/internal/logging"
 	"github.com/leg100/pug/internal/module"
 	"github.com/leg100/pug/internal/plan"
+	"github.com/leg100/pug/internal/pubsub"
 	"github.com/leg100/pug/internal/state"
 	"github.com/leg100/pug/internal/task"
 	"github.com/leg100/pug/internal/workspace"
@@ -24,6 +25,7 @@ type App struct {
 	Plans      *plan.Service
 	States     *state.Service
 	Tasks      *task.Service
+	History    *task.History
 }
 
 // New starts the application, constructing services, starting daemons and
@@ -41,23 +43,42 @@ func New(cfg Config) (*App, error) {
 		"program", cfg.Program,
 		"work_dir", cfg.Workdir,
 		"data_dir", cfg.DataDir,
+		"max_output_memory", cfg.MaxOutputMemory,
+		"state_snapshots", cfg.StateSnapshots,
+		"state_history", cfg.StateHistory,
+		"max_finished_tasks", cfg.TaskRetention.MaxFinished,
+		"max_task_age", cfg.TaskRetention.MaxAge,
+		"tasks_per_workspace", cfg.TaskRetention.KeepPerWorkspace,
+		"max_event_queue", cfg.EventQueue,
+		"event_overflow", cfg.EventOverflow,
 	)
 
+	// Configure the brokers with which each service publishes events.
+	events := []pubsub.Option{
+		pubsub.WithMaxQueue(cfg.EventQueue),
+		pubsub.WithOverflowPolicy(pubsub.OverflowPolicies[cfg.EventOverflow]),
+	}
+
 	// Instantiate services
 	tasks := task.NewService(task.ServiceOptions{
-		Program:    cfg.Program,
-		Logger:     logger,
-		Workdir:    cfg.Workdir,
-		UserEnvs:   cfg.Envs,
-		UserArgs:   cfg.Args,
-		Terragrunt: cfg.Terragrunt,
+		Program:         cfg.Program,
+		Logger:          logger,
+		Workdir:         cfg.Workdir,
+		UserEnvs:        cfg.Envs,
+		UserArgs:        cfg.Args,
+		Terragrunt:      cfg.Terragrunt,
+		DataDir:         cfg.DataDir,
+		MaxOutputMemory: cfg.MaxOutputMemory,
+		Events:          events,
 	})
 	modules := module.NewService(module.ServiceOptions{
-		Tasks:       tasks,
-		Workdir:     cfg.Workdir,
-		PluginCache: cfg.PluginCache,
-		Logger:      logger,
-		Terragrunt:  cfg.Terragrunt,
+		Tasks:        tasks,
+		Workdir:      cfg.Workdir,
+		PluginCache:  cfg.PluginCache,
+		Logger:       logger,
+		Terragrunt:   cfg.Terragrunt,
+		Dependencies: cfg.ModuleDependencies,
+		Events:       events,
 	})
 	workspaces := workspace.NewService(workspace.ServiceOptions{
 		Tasks:   tasks,
@@ -65,12 +86,19 @@ func New(cfg Config) (*App, error) {
 		Logger:  logger,
 		DataDir: cfg.DataDir,
 		Workdir: cfg.Workdir,
+		Events:  events,
 	})
 	states := state.NewService(state.ServiceOptions{
-		Modules:    modules,
-		Workspaces: workspaces,
-		Tasks:      tasks,
-		Logger:     logger,
+		Modules:       modules,
+		Workspaces:    workspaces,
+		Tasks:         tasks,
+		Logger:        logger,
+		DataDir:       cfg.DataDir,
+		MaxSnapshots:  cfg.StateSnapshots,
+		MaxHistory:    cfg.StateHistory,
+		Workdir:       cfg.Workdir,
+		CompareIgnore: cfg.CompareIgnore,
+		Events:        events,
 	})
 	plans := plan.NewService(plan.ServiceOptions{
 		Tasks:      tasks,
@@ -81,6 +109,14 @@ func New(cfg Config) (*App, error) {
 		Workdir:    cfg.Workdir,
 		Logger:     logger,
 		Terragrunt: cfg.Terragrunt,
+		Events:     events,
+	})
+
+	history := task.NewHistory(task.HistoryOptions{
+		DataDir:  cfg.DataDir,
+		Logger:   logger,
+		MaxTasks: cfg.MaxTasks,
+		Key:      historyKey(modules, workspaces),
 	})
 
 	ctx, cancel := context.WithCancel(context.Background())
@@ -88,12 +124,28 @@ func New(cfg Config) (*App, error) {
 	// Start daemons
 	task.StartEnqueuer(tasks)
 	waitTasks := task.StartRunner(ctx, logger, tasks, cfg.MaxTasks)
+	task.StartPruner(ctx, logger, tasks, cfg.TaskRetention)
+	waitHistory := task.StartHistory(ctx, tasks, history)
+	task.StartUsageSampler(ctx, logger, tasks)
+
+	// Whenever a task is deleted, e.g. when pruned, delete its plan as well.
+	go plans.DeleteWithTask(tasks.TaskBroker.Subscribe(ctx))
 
 	// cleanup function to be invoked when app is terminated.
 	cleanup := func() {
 		// Cancel context
 		cancel()
 
+		logEventMetrics(logger, map[string]metered{
+			"tasks":      tasks.TaskBroker,
+			"groups":     tasks.GroupBroker,
+			"modules":    modules,
+			"workspaces": workspaces,
+			"plans":      plans,
+			"states":     states,
+			"snapshots":  states.SnapshotBroker,
+		})
+
 		// Close subscriptions
 		logger.Shutdown()
 		tasks.TaskBroker.Shutdown()
@@ -102,16 +154,21 @@ func New(cfg Config) (*App, error) {
 		workspaces.Shutdown()
 		plans.Shutdown()
 		states.Shutdown()
+		states.SnapshotBroker.Shutdown()
 
 		// Wait for running tasks to terminate. Canceling the context (above)
 		// sends each task a termination signal so each task's process should
 		// shut itself down.
 		waitTasks()
+		// Wait for task durations to be saved.
+		waitHistory()
 
 		// Remove all run artefacts (plan files etc,...)
 		for _, plan := range plans.List() {
 			_ = os.RemoveAll(plan.ArtefactsPath)
 		}
+		// Remove task output spilled to disk
+		_ = tasks.Cleanup()
 	}
 
 	return &App{
@@ -120,7 +177,63 @@ func New(cfg Config) (*App, error) {
 		Plans:      plans,
 		Tasks:      tasks,
 		States:     states,
+		History:    history,
 		Cleanup:    cleanup,
 		Logger:     logger,
 	}, nil
 }
+
+// metered is a broker that counts the events it handles.
+type metered interface {
+	Metrics() pubsub.Metrics
+}
+
+// logEventMetrics logs counts of the events handled by each broker, warning
+// of any events dropped because a subscriber fell too far behind.
+func logEventMetrics(logger logging.Interface, brokers map[string]metered) {
+	for name, broker := range brokers {
+		m := broker.Metrics()
+		args := []any{
+			"broker", name,
+			"published", m.Published,
+			"delivered", m.Delivered,
+			"coalesced", m.Coalesced,
+			"dropped", m.Dropped,
+		}
+		if m.Dropped > 0 {
+			logger.Warn("dropped events", args...)
+		} else {
+			logger.Debug("event metrics", args...)
+		}
+	}
+}
+
+// historyKey returns a func that identifies comparable tasks by their module
+// path, workspace name, and either their identifier or, if they lack an
+// identifier, their description. Tasks without a module are not identified.
+func historyKey(modules *module.Service, workspaces *workspace.Service) func(*task.Task) (task.HistoryKey, bool) {
+	return func(t *task.Task) (task.HistoryKey, bool) {
+		key := task.HistoryKey{Identifier: t.Identifier}
+		if key.Identifier == "" {
+			key.Identifier = task.Identifier(t.String())
+		}
+		switch {
+		case t.WorkspaceID != nil:
+			ws, err := workspaces.Get(t.WorkspaceID)
+			if err != nil {
+				return task.HistoryKey{}, false
+			}
+			key.ModulePath = ws.ModulePath
+			key.WorkspaceName = ws.Name
+		case t.ModuleID != nil:
+			mod, err := modules.Get(t.ModuleID)
+			if err != nil {
+				return task.HistoryKey{}, false
+			}
+			key.ModulePath = mod.Path
+		default:
+			return task.HistoryKey{}, false
+		}
+		return key, true
+	}
+}
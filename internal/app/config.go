@@ -1,16 +1,22 @@
 package app
 
 import (
+	"cmp"
 	"fmt"
 	"io"
+	"maps"
 	"os"
+	"path"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strings"
 
 	"github.com/leg100/pug/internal"
 	"github.com/leg100/pug/internal/cliconfig"
 	"github.com/leg100/pug/internal/logging"
+	"github.com/leg100/pug/internal/pubsub"
+	"github.com/leg100/pug/internal/task"
 	"github.com/peterbourgon/ff/v4"
 	"github.com/peterbourgon/ff/v4/ffhelp"
 	"github.com/peterbourgon/ff/v4/ffyaml"
@@ -22,16 +28,96 @@ type Config struct {
 	PluginCache             bool
 	Debug                   bool
 	DisableReloadAfterApply bool
+	StateSnapshots          int
+	StateHistory            int
+	CompareIgnore           []string
+	TaskRetention           task.RetentionPolicy
+	ModuleDependencies      map[string][]string
 	Workdir                 internal.Workdir
 	DataDir                 string
+	MaxOutputMemory         int
 	Envs                    []string
 	Args                    []string
 	Terragrunt              bool
 	Logging                 logging.Options
 
+	// Run configures a headless run of a command. Nil unless the run
+	// subcommand is used.
+	Run *RunConfig
+	// API configures the local API server.
+	API APIConfig
+	// WebListen is the loopback address on which the web dashboard listens.
+	// If empty then the dashboard is disabled.
+	WebListen string
+	// Keys remaps actions to keys, keyed by the name of the action, e.g.
+	// global.autoscroll. An action remapped to no keys is disabled.
+	Keys map[string][]string
+	// Theme is the name of the theme with which the TUI is rendered, one of
+	// Themes.
+	Theme string
+	// Colors overrides colors of the theme, keyed by the name of the color,
+	// e.g. current-background.
+	Colors map[string]string
+	// NoColor disables colors, and is set via the NO_COLOR environment
+	// variable.
+	NoColor bool
+	// EventQueue is the maximum number of events queued for each subscriber
+	// before EventOverflow is applied.
+	EventQueue int
+	// EventOverflow is the name of the policy applied when a subscriber's
+	// queue of events is full, one of EventOverflowPolicies.
+	EventOverflow string
+	// Mouse enables mouse support. Disabled by default, leaving the terminal
+	// to handle the mouse, e.g. to select text.
+	Mouse bool
+
 	Version bool
 }
 
+// RunConfig configures a headless run of a command, i.e. `pug run COMMAND`.
+type RunConfig struct {
+	// Command is the command to run, one of RunCommands.
+	Command string
+	// Modules are patterns matching the paths of the modules on which to run
+	// the command. If empty, the command is run on all modules.
+	Modules []string
+	// Workspaces are patterns matching the names of the workspaces on which
+	// to run the command. If empty, the command is run on the current
+	// workspace of each module.
+	Workspaces []string
+	// DetailedExitCode exits with a status of 2 if any plan has changes.
+	DetailedExitCode bool
+	// JSON emits newline-delimited JSON events instead of text.
+	JSON bool
+}
+
+// APIConfig configures the local API server.
+type APIConfig struct {
+	// Listen is the address on which the API listens, either a unix socket,
+	// in the form unix:PATH, or a loopback address and port. If empty then
+	// the API is disabled.
+	Listen string
+	// Token authenticates requests to an API listening on a port. If empty
+	// then a token is generated.
+	Token string
+}
+
+// Themes are the names of the themes with which the TUI can be rendered.
+var Themes = []string{"auto", "dark", "light", "high-contrast"}
+
+// EventOverflowPolicies are the names of the policies that can be applied when
+// a subscriber's queue of events is full, the default first.
+var EventOverflowPolicies = func() []string {
+	names := slices.Collect(maps.Keys(pubsub.OverflowPolicies))
+	slices.SortFunc(names, func(a, b string) int {
+		return cmp.Compare(pubsub.OverflowPolicies[a], pubsub.OverflowPolicies[b])
+	})
+	return names
+}()
+
+// RunCommands are the commands that can be run headlessly.
+var RunCommands = []string{"init", "fmt", "validate", "plan", "apply", "destroy", "cost"}
+
 // set config in order of precedence:
 // 1. flags > 2. env vars > 3. config file
 func Parse(stderr io.Writer, args []string) (Config, error) {
@@ -49,6 +135,7 @@ func Parse(stderr io.Writer, args []string) (Config, error) {
 	workdir := fs.String('w', "workdir", ".", "The working directory containing modules.")
 	fs.IntVar(&cfg.MaxTasks, 't', "max-tasks", 2*runtime.NumCPU(), "The maximum number of parallel tasks.")
 	fs.StringVar(&cfg.DataDir, 0, "data-dir", defaultDataDir, "Directory in which to store plan files.")
+	fs.IntVar(&cfg.MaxOutputMemory, 0, "max-output-memory", task.DefaultMaxOutputMemory, "Maximum bytes of each task's output to hold in memory before spilling it to the data directory.")
 	fs.StringListVar(&cfg.Envs, 'e', "env", "Environment variable to pass to terraform process. Can set more than once.")
 	fs.StringListVar(&cfg.Args, 'a', "arg", "CLI arg to pass to terraform process. Can set more than once.")
 	fs.BoolVar(&cfg.Debug, 'd', "debug", "Log bubbletea messages to messages.log")
@@ -56,28 +143,108 @@ func Parse(stderr io.Writer, args []string) (Config, error) {
 	_ = fs.String('c', "config", defaultConfigFile, "Path to config file.")
 
 	fs.BoolVar(&cfg.DisableReloadAfterApply, 0, "disable-reload-after-apply", "Disable automatic reload of state following an apply.")
+	fs.BoolVar(&cfg.Mouse, 0, "mouse", "Enable mouse support. Prevents selecting text with the terminal.")
+	fs.IntVar(&cfg.StateSnapshots, 0, "state-snapshots", 10, "Number of state snapshots to retain per workspace. Set to 0 to disable snapshots.")
+	fs.IntVar(&cfg.StateHistory, 0, "state-history", 10, "Number of states to retain in history per workspace.")
+	fs.IntVar(&cfg.TaskRetention.MaxFinished, 0, "max-finished-tasks", 1000, "Maximum number of finished tasks to retain. Set to 0 to disable.")
+	fs.DurationVar(&cfg.TaskRetention.MaxAge, 0, "max-task-age", 0, "Maximum length of time to retain a finished task. Set to 0 to disable.")
+	fs.IntVar(&cfg.TaskRetention.KeepPerWorkspace, 0, "tasks-per-workspace", 0, "Number of finished tasks of each type to retain per workspace. Set to 0 to disable.")
+	moduleDependencies := fs.StringList(0, "module-dependency", "Declare a module's dependencies, in the form MODULE=DEPENDENCY[,DEPENDENCY...]. Can set more than once.")
+	fs.StringListVar(&cfg.CompareIgnore, 0, "compare-ignore", "Pattern matching resource attributes to ignore when comparing workspaces. Can set more than once. (default: id,arn)")
+	fs.StringVar(&cfg.API.Listen, 0, "api-listen", "", "Serve local API on unix:PATH or a loopback address, e.g. localhost:7300.")
+	fs.StringVar(&cfg.API.Token, 0, "api-token", "", "Token for local API listening on a port, and for web dashboard. Generated if unset.")
+	fs.StringVar(&cfg.WebListen, 0, "web-listen", "", "Serve read-only web dashboard on a loopback address, e.g. localhost:7301.")
+	fs.IntVar(&cfg.EventQueue, 0, "max-event-queue", pubsub.DefaultMaxQueue, "Maximum number of events queued for each subscriber, e.g. a TUI pane.")
 
+	{
+		usage := fmt.Sprintf("Color theme (valid: %s).", strings.Join(Themes, ","))
+		fs.StringEnumVar(&cfg.Theme, 0, "theme", usage, Themes...)
+	}
+	{
+		usage := fmt.Sprintf("Policy when a subscriber's queue of events is full (valid: %s).", strings.Join(EventOverflowPolicies, ","))
+		fs.StringEnumVar(&cfg.EventOverflow, 0, "event-overflow", usage, EventOverflowPolicies...)
+	}
 	{
 		usage := fmt.Sprintf("Logging level (valid: %s).", strings.Join(logging.ValidLevels(), ","))
 		fs.StringEnumVar(&cfg.Logging.Level, 'l', "log-level", usage, logging.ValidLevels()...)
 	}
 
+	// The run subcommand runs a command headlessly, i.e. without the TUI,
+	// and is parsed with its own flags in addition to the flags above.
+	parseFS, usage := fs, []string(nil)
+	if len(args) > 0 && args[0] == "run" {
+		cfg.Run = &RunConfig{}
+		parseFS = ff.NewFlagSet("pug run").SetParent(fs)
+		parseFS.StringListVar(&cfg.Run.Modules, 0, "module", "Pattern matching paths of modules on which to run command. Can set more than once. (default: all)")
+		parseFS.StringListVar(&cfg.Run.Workspaces, 0, "workspace", "Pattern matching names of workspaces on which to run command. Can set more than once. (default: current)")
+		parseFS.BoolVar(&cfg.Run.DetailedExitCode, 0, "detailed-exitcode", "Exit with status 2 if any plan has changes.")
+		parseFS.BoolVar(&cfg.Run.JSON, 0, "json", "Emit newline-delimited JSON events instead of text.")
+		usage = []string{fmt.Sprintf("pug run {%s} [FLAGS]", strings.Join(RunCommands, "|"))}
+
+		if len(args) < 2 || strings.HasPrefix(args[1], "-") {
+			args = args[1:]
+		} else {
+			cfg.Run.Command = args[1]
+			args = args[2:]
+		}
+	}
+
+	// Colors are disabled not via pug flags but via the NO_COLOR convention:
+	// https://no-color.org
+	cfg.NoColor = os.Getenv("NO_COLOR") != ""
+
 	// Plugin cache is enabled not via pug flags but via terraform config
 	tfcfg, _ := cliconfig.LoadConfig()
 	cfg.PluginCache = (tfcfg.PluginCacheDir != "")
 
-	err = ff.Parse(fs, args,
+	err = ff.Parse(parseFS, args,
 		ff.WithEnvVarPrefix("PUG"),
 		ff.WithConfigFileFlag("config"),
-		ff.WithConfigFileParser(ffyaml.Parse),
+		ff.WithConfigFileParser(func(r io.Reader, set func(name, value string) error) error {
+			// The keys section of the config file maps action names to keys,
+			// and the colors section maps color names to colors. Neither
+			// are flags, so collect them separately rather than have them
+			// rejected as unknown flags.
+			return ffyaml.Parse(r, func(name, value string) error {
+				if action, ok := strings.CutPrefix(name, "keys."); ok {
+					if cfg.Keys == nil {
+						cfg.Keys = make(map[string][]string)
+					}
+					cfg.Keys[action] = append(cfg.Keys[action], value)
+					return nil
+				}
+				if color, ok := strings.CutPrefix(name, "colors."); ok {
+					if cfg.Colors == nil {
+						cfg.Colors = make(map[string]string)
+					}
+					cfg.Colors[color] = value
+					return nil
+				}
+				return set(name, value)
+			})
+		}),
 		ff.WithConfigAllowMissingFile(),
 	)
 	if err != nil {
 		// ff.Parse returns an error if there is an error or if -h/--help is
 		// passed; in either case print flag usage in addition to error message.
-		fmt.Fprintln(stderr, ffhelp.Flags(fs))
+		fmt.Fprintln(stderr, ffhelp.Flags(parseFS, usage...))
 		return Config{}, err
 	}
+	if cfg.Run != nil {
+		if !slices.Contains(RunCommands, cfg.Run.Command) {
+			fmt.Fprintln(stderr, ffhelp.Flags(parseFS, usage...))
+			return Config{}, fmt.Errorf("invalid command %q: must be one of %s", cfg.Run.Command, strings.Join(RunCommands, ", "))
+		}
+		if extra := parseFS.GetArgs(); len(extra) > 0 {
+			return Config{}, fmt.Errorf("unexpected arguments: %s", strings.Join(extra, " "))
+		}
+		for _, pattern := range append(cfg.Run.Modules, cfg.Run.Workspaces...) {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return Config{}, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+			}
+		}
+	}
 
 	// If user has specified terragrunt as the program executable then enable
 	// terragrunt mode.
@@ -85,6 +252,22 @@ func Parse(stderr io.Writer, args []string) (Config, error) {
 		cfg.Terragrunt = true
 	}
 
+	// Ignore IDs and ARNs when comparing workspaces unless the user has
+	// specified their own rules.
+	if len(cfg.CompareIgnore) == 0 {
+		cfg.CompareIgnore = []string{"id", "arn"}
+	}
+	for _, pattern := range cfg.CompareIgnore {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return Config{}, fmt.Errorf("invalid compare-ignore pattern %q: %w", pattern, err)
+		}
+	}
+
+	cfg.ModuleDependencies, err = parseModuleDependencies(*moduleDependencies)
+	if err != nil {
+		return Config{}, err
+	}
+
 	// Perform any conversions from the flag parsed primitive types to pug
 	// defined types.
 	cfg.Workdir, err = internal.NewWorkdir(*workdir)
@@ -94,3 +277,32 @@ func Parse(stderr io.Writer, args []string) (Config, error) {
 
 	return cfg, nil
 }
+
+// parseModuleDependencies parses module dependency declarations of the form
+// MODULE=DEPENDENCY[,DEPENDENCY...], returning a map of module paths to the
+// paths of the modules they depend upon.
+func parseModuleDependencies(declarations []string) (map[string][]string, error) {
+	if len(declarations) == 0 {
+		return nil, nil
+	}
+	dependencies := make(map[string][]string)
+	for _, decl := range declarations {
+		mod, deps, ok := strings.Cut(decl, "=")
+		mod = filepath.Clean(strings.TrimSpace(mod))
+		if !ok || mod == "." || strings.TrimSpace(deps) == "" {
+			return nil, fmt.Errorf("invalid module dependency %q: must be of the form MODULE=DEPENDENCY[,DEPENDENCY...]", decl)
+		}
+		for _, dep := range strings.Split(deps, ",") {
+			dep = strings.TrimSpace(dep)
+			if dep == "" {
+				return nil, fmt.Errorf("invalid module dependency %q: empty dependency", decl)
+			}
+			dep = filepath.Clean(dep)
+			if dep == mod {
+				return nil, fmt.Errorf("invalid module dependency %q: module cannot depend on itself", decl)
+			}
+			dependencies[mod] = append(dependencies[mod], dep)
+		}
+	}
+	return dependencies, nil
+}
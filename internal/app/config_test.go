@@ -8,9 +8,12 @@ import (
 	"runtime"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/leg100/pug/internal"
 	"github.com/leg100/pug/internal/logging"
+	"github.com/leg100/pug/internal/pubsub"
+	"github.com/leg100/pug/internal/task"
 	"github.com/leg100/pug/internal/testutils"
 	"github.com/peterbourgon/ff/v4"
 	"github.com/stretchr/testify/assert"
@@ -41,10 +44,18 @@ func TestConfig(t *testing.T) {
 				require.NoError(t, err)
 
 				want := Config{
-					Program:  "terraform",
-					MaxTasks: 2 * runtime.NumCPU(),
-					Workdir:  wd,
-					DataDir:  filepath.Join(os.Getenv("HOME"), ".pug"),
+					Program:         "terraform",
+					MaxTasks:        2 * runtime.NumCPU(),
+					StateSnapshots:  10,
+					StateHistory:    10,
+					CompareIgnore:   []string{"id", "arn"},
+					TaskRetention:   task.RetentionPolicy{MaxFinished: 1000},
+					Workdir:         wd,
+					DataDir:         filepath.Join(os.Getenv("HOME"), ".pug"),
+					MaxOutputMemory: task.DefaultMaxOutputMemory,
+					Theme:           "auto",
+					EventQueue:      pubsub.DefaultMaxQueue,
+					EventOverflow:   "drop-oldest",
 					Logging: logging.Options{
 						Level: "info",
 					},
@@ -115,6 +126,90 @@ func TestConfig(t *testing.T) {
 				assert.Equal(t, got.Program, "tofu")
 			},
 		},
+		{
+			"override compare ignore rules",
+			"",
+			[]string{"--compare-ignore", "*_id", "--compare-ignore", "arn"},
+			nil,
+			func(t *testing.T, got Config) {
+				assert.Equal(t, []string{"*_id", "arn"}, got.CompareIgnore)
+			},
+		},
+		{
+			"set task retention policy",
+			"max-task-age: 1h\n",
+			[]string{"--tasks-per-workspace", "5"},
+			[]string{"PUG_MAX_FINISHED_TASKS=100"},
+			func(t *testing.T, got Config) {
+				want := task.RetentionPolicy{
+					MaxFinished:      100,
+					MaxAge:           time.Hour,
+					KeepPerWorkspace: 5,
+				}
+				assert.Equal(t, want, got.TaskRetention)
+			},
+		},
+		{
+			"declare module dependencies",
+			"module-dependency:\n  - apps=cluster,network\n  - cluster=network\n",
+			nil,
+			nil,
+			func(t *testing.T, got Config) {
+				want := map[string][]string{
+					"apps":    {"cluster", "network"},
+					"cluster": {"network"},
+				}
+				assert.Equal(t, want, got.ModuleDependencies)
+			},
+		},
+		{
+			"remap keys",
+			"keys:\n  global.autoscroll: ctrl+y\n  explorer:\n    reload-workspaces: [W, ctrl+e]\n  common.destroy: none\n",
+			nil,
+			nil,
+			func(t *testing.T, got Config) {
+				want := map[string][]string{
+					"global.autoscroll":          {"ctrl+y"},
+					"explorer.reload-workspaces": {"W", "ctrl+e"},
+					"common.destroy":             {"none"},
+				}
+				assert.Equal(t, want, got.Keys)
+			},
+		},
+		{
+			"set theme and override colors",
+			"theme: light\ncolors:\n  current-background: \"#005f87\"\n  red: 124\n",
+			nil,
+			[]string{"NO_COLOR=1"},
+			func(t *testing.T, got Config) {
+				assert.Equal(t, "light", got.Theme)
+				want := map[string]string{
+					"current-background": "#005f87",
+					"red":                "124",
+				}
+				assert.Equal(t, want, got.Colors)
+				assert.True(t, got.NoColor)
+			},
+		},
+		{
+			"configure event queue",
+			"",
+			[]string{"--max-event-queue", "1000", "--event-overflow", "drop-newest"},
+			nil,
+			func(t *testing.T, got Config) {
+				assert.Equal(t, 1000, got.EventQueue)
+				assert.Equal(t, "drop-newest", got.EventOverflow)
+			},
+		},
+		{
+			"enable mouse",
+			"",
+			[]string{"--mouse"},
+			nil,
+			func(t *testing.T, got Config) {
+				assert.True(t, got.Mouse)
+			},
+		},
 		{
 			"enable plugin cache via env var",
 			"",
@@ -145,6 +240,22 @@ func TestConfig(t *testing.T) {
 				assert.Contains(t, got.Envs, "TF_PLUGIN_CACHE_DIR=/tmp")
 			},
 		},
+		{
+			"run command headlessly",
+			"",
+			[]string{"run", "plan", "--module", "envs/*/network", "--workspace", "prod", "--detailed-exitcode", "--program", "tofu"},
+			nil,
+			func(t *testing.T, got Config) {
+				want := &RunConfig{
+					Command:          "plan",
+					Modules:          []string{"envs/*/network"},
+					Workspaces:       []string{"prod"},
+					DetailedExitCode: true,
+				}
+				assert.Equal(t, want, got.Run)
+				assert.Equal(t, "tofu", got.Program)
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -184,3 +295,29 @@ func TestHelpFlag(t *testing.T) {
 		assert.Contains(t, got.String(), want)
 	}
 }
+
+func TestInvalidRunCommand(t *testing.T) {
+	testutils.ResetEnv(t)
+	t.Setenv("HOME", t.TempDir())
+
+	for _, args := range [][]string{
+		{"run"},
+		{"run", "--module", "a"},
+		{"run", "frobnicate"},
+		{"run", "plan", "extra"},
+		{"run", "plan", "--module", "[a"},
+	} {
+		_, err := Parse(io.Discard, args)
+		assert.Error(t, err, args)
+	}
+}
+
+func TestInvalidModuleDependency(t *testing.T) {
+	testutils.ResetEnv(t)
+	t.Setenv("HOME", t.TempDir())
+
+	for _, decl := range []string{"apps", "apps=", "=cluster", "apps=cluster,", "apps=,cluster", "apps=apps", "apps=cluster,./apps"} {
+		_, err := Parse(io.Discard, []string{"--module-dependency", decl})
+		assert.ErrorContains(t, err, "invalid module dependency", decl)
+	}
+}
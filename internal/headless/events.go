@@ -0,0 +1,347 @@
+package headless
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/leg100/pug/internal/plan"
+	"github.com/leg100/pug/internal/resource"
+	"github.com/leg100/pug/internal/state"
+	"github.com/leg100/pug/internal/task"
+	"github.com/leg100/pug/internal/workspace"
+)
+
+// SchemaVersion is the version of the schema of JSON events. It is
+// incremented whenever a change is made that is incompatible with consumers
+// of earlier versions.
+const SchemaVersion = 1
+
+// EventType is the type of a JSON event.
+type EventType string
+
+const (
+	TaskCreatedEvent   EventType = "task_created"
+	TaskStatusEvent    EventType = "task_status"
+	TaskOutputEvent    EventType = "task_output"
+	TaskSummaryEvent   EventType = "task_summary"
+	GroupCreatedEvent  EventType = "group_created"
+	GroupFinishedEvent EventType = "group_finished"
+	ErrorEvent         EventType = "error"
+)
+
+// Event is a JSON event, emitted on a single line.
+type Event struct {
+	Version int       `json:"version"`
+	Type    EventType `json:"type"`
+	Time    time.Time `json:"time"`
+	// Task is set for task events.
+	Task *Task `json:"task,omitempty"`
+	// Output is a chunk of a task's output, and is set for task output
+	// events.
+	Output string `json:"output,omitempty"`
+	// Summary is set for task summary events.
+	Summary *Summary `json:"summary,omitempty"`
+	// Group is set for group events.
+	Group *Group `json:"group,omitempty"`
+	// Error is set for error events.
+	Error string `json:"error,omitempty"`
+}
+
+// Task is the JSON representation of a task.
+type Task struct {
+	ID        string      `json:"id"`
+	GroupID   string      `json:"group_id,omitempty"`
+	Command   string      `json:"command"`
+	Module    string      `json:"module,omitempty"`
+	Workspace string      `json:"workspace,omitempty"`
+	Status    task.Status `json:"status"`
+	Error     string      `json:"error,omitempty"`
+}
+
+// Group is the JSON representation of a task group.
+type Group struct {
+	ID      string   `json:"id"`
+	Command string   `json:"command"`
+	Tasks   []string `json:"tasks"`
+	// Counts of tasks in each status, set once the group has finished.
+	Exited   int `json:"exited"`
+	Errored  int `json:"errored"`
+	Canceled int `json:"canceled"`
+}
+
+// Summary is the JSON representation of a task's summary. Type determines
+// which of the other fields, besides Text, is set.
+type Summary struct {
+	// Type is one of plan, state_reload, workspace_reload, cost or other.
+	Type string `json:"type"`
+	// Text is a human-readable summary.
+	Text            string                  `json:"text"`
+	Plan            *plan.Report            `json:"plan,omitempty"`
+	StateReload     string                  `json:"state_reload,omitempty"`
+	WorkspaceReload *WorkspaceReloadSummary `json:"workspace_reload,omitempty"`
+	Cost            *float64                `json:"cost,omitempty"`
+}
+
+// WorkspaceReloadSummary lists the workspaces added and removed by a reload.
+type WorkspaceReloadSummary struct {
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+}
+
+// newSummary converts a task summary into its JSON representation.
+func newSummary(s task.Summary) *Summary {
+	summary := &Summary{Text: s.String()}
+	switch s := s.(type) {
+	case plan.Report:
+		summary.Type = "plan"
+		summary.Plan = &s
+	case state.ReloadSummary:
+		summary.Type = "state_reload"
+		summary.StateReload = s.String()
+	case workspace.ReloadSummary:
+		summary.Type = "workspace_reload"
+		summary.WorkspaceReload = &WorkspaceReloadSummary{
+			Added:   append([]string{}, s.Added...),
+			Removed: append([]string{}, s.Removed...),
+		}
+	case workspace.CostSummary:
+		summary.Type = "cost"
+		cost := float64(s)
+		summary.Cost = &cost
+	default:
+		summary.Type = "other"
+	}
+	return summary
+}
+
+// emitter emits JSON events for tasks and task groups, converting events
+// published by the task and group brokers.
+type emitter struct {
+	r   *runner
+	enc *json.Encoder
+	// mu serializes writes to the encoder.
+	mu sync.Mutex
+	// now returns the current time, and is overridden in tests.
+	now func() time.Time
+
+	// The remaining fields are only accessed by the event loop.
+
+	// statuses are the last statuses emitted for each task.
+	statuses map[resource.ID]task.Status
+	// deferred are tasks whose status is to be emitted once their output has
+	// been emitted in full: a task's final status follows its output.
+	deferred map[resource.ID]*task.Task
+	// streamed is true for each task whose output has been emitted in full.
+	streamed map[resource.ID]bool
+	// groups are the groups yet to finish.
+	groups map[resource.ID]*task.Group
+	// finished is closed for each group once it has finished.
+	finished map[resource.ID]chan struct{}
+	// finishedMu guards finished, which is accessed by callers waiting for
+	// groups to finish.
+	finishedMu sync.Mutex
+	// waited receives tasks that callers have waited upon to finish, for the
+	// event loop to emit their final status should it not have done so
+	// already.
+	waited chan *task.Task
+}
+
+func newEmitter(r *runner, w io.Writer) *emitter {
+	return &emitter{
+		r:        r,
+		enc:      json.NewEncoder(w),
+		now:      time.Now,
+		statuses: make(map[resource.ID]task.Status),
+		deferred: make(map[resource.ID]*task.Task),
+		streamed: make(map[resource.ID]bool),
+		groups:   make(map[resource.ID]*task.Group),
+		finished: make(map[resource.ID]chan struct{}),
+		waited:   make(chan *task.Task),
+	}
+}
+
+// start subscribes to task and group events and emits JSON events until the
+// context is canceled. Subscribe before creating tasks to ensure every event is
+// emitted.
+func (e *emitter) start(ctx context.Context) {
+	var (
+		tasks    = e.r.Tasks.TaskBroker.Subscribe(ctx)
+		groups   = e.r.Tasks.GroupBroker.Subscribe(ctx)
+		streamed = make(chan resource.ID)
+	)
+	go func() {
+		for {
+			select {
+			case event, ok := <-tasks:
+				if !ok {
+					return
+				}
+				e.handleTask(ctx, event, streamed)
+			case event, ok := <-groups:
+				if !ok {
+					return
+				}
+				if event.Type == resource.CreatedEvent {
+					e.emit(Event{Type: GroupCreatedEvent, Group: e.group(event.Payload)})
+					e.groups[event.Payload.ID] = event.Payload
+					e.checkGroups()
+				}
+			case id := <-streamed:
+				e.streamed[id] = true
+				if t, ok := e.deferred[id]; ok {
+					delete(e.deferred, id)
+					e.updateStatus(t)
+				}
+				e.checkGroups()
+			case t := <-e.waited:
+				e.updateStatus(t)
+			}
+		}
+	}()
+}
+
+func (e *emitter) handleTask(ctx context.Context, event resource.Event[*task.Task], streamed chan<- resource.ID) {
+	t := event.Payload
+	switch event.Type {
+	case resource.CreatedEvent:
+		// Tasks are created pending, although the task may well have since
+		// moved on, in which case the new status is emitted upon the next
+		// update.
+		e.statuses[t.ID] = task.Pending
+		e.emit(Event{Type: TaskCreatedEvent, Task: e.task(t)})
+		// Stream task output until the task finishes.
+		go func() {
+			for chunk := range t.NewStreamer() {
+				e.emit(Event{Type: TaskOutputEvent, Task: e.task(t), Output: string(chunk)})
+			}
+			select {
+			case streamed <- t.ID:
+			case <-ctx.Done():
+			}
+		}()
+	case resource.UpdatedEvent:
+		e.updateStatus(t)
+	}
+}
+
+// updateStatus emits the status of a task if it has changed since it was last
+// emitted, along with its summary if it has exited. A final status is
+// deferred until the task's output has been emitted in full.
+func (e *emitter) updateStatus(t *task.Task) {
+	jt := e.task(t)
+	if jt.Status.IsFinal() && !e.streamed[t.ID] {
+		e.deferred[t.ID] = t
+		return
+	}
+	// Tasks are updated for reasons other than a change in status, which are
+	// ignored.
+	if e.statuses[t.ID] == jt.Status {
+		return
+	}
+	e.statuses[t.ID] = jt.Status
+	e.emit(Event{Type: TaskStatusEvent, Task: jt})
+	if _, summary, _ := t.Result(); jt.Status == task.Exited && summary != nil {
+		e.emit(Event{Type: TaskSummaryEvent, Task: jt, Summary: newSummary(summary)})
+	}
+	e.checkGroups()
+}
+
+// checkGroups emits an event for each group whose tasks have all finished and
+// whose output has been emitted in full.
+func (e *emitter) checkGroups() {
+	for id, g := range e.groups {
+		finished := true
+		for _, t := range g.Tasks {
+			if !e.statuses[t.ID].IsFinal() || !e.streamed[t.ID] {
+				finished = false
+				break
+			}
+		}
+		if !finished {
+			continue
+		}
+		e.emit(Event{Type: GroupFinishedEvent, Group: e.group(g)})
+		delete(e.groups, id)
+		close(e.finishedCh(id))
+	}
+}
+
+// wait blocks until a group has finished. Rather than relying solely upon
+// task events, which may have been dropped, it waits for each of the group's
+// tasks to finish, ensuring their final status is emitted.
+func (e *emitter) wait(group *task.Group) {
+	for _, t := range group.Tasks {
+		_ = t.Wait()
+		e.waited <- t
+	}
+	<-e.finishedCh(group.ID)
+}
+
+func (e *emitter) finishedCh(groupID resource.ID) chan struct{} {
+	e.finishedMu.Lock()
+	defer e.finishedMu.Unlock()
+
+	ch, ok := e.finished[groupID]
+	if !ok {
+		ch = make(chan struct{})
+		e.finished[groupID] = ch
+	}
+	return ch
+}
+
+// error emits an error event.
+func (e *emitter) error(msg string) {
+	e.emit(Event{Type: ErrorEvent, Error: msg})
+}
+
+func (e *emitter) emit(event Event) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	event.Version = SchemaVersion
+	event.Time = e.now()
+	// Errors writing to stdout are not recoverable.
+	_ = e.enc.Encode(event)
+}
+
+func (e *emitter) task(t *task.Task) *Task {
+	state, _, err := t.Result()
+	jt := &Task{
+		ID:        t.ID.String(),
+		Command:   t.String(),
+		Module:    e.r.taskModulePath(t),
+		Workspace: e.r.taskWorkspaceName(t),
+		Status:    state,
+	}
+	if t.TaskGroupID != nil {
+		jt.GroupID = fmt.Sprint(t.TaskGroupID)
+	}
+	if err != nil {
+		jt.Error = err.Error()
+	}
+	return jt
+}
+
+func (e *emitter) group(g *task.Group) *Group {
+	jg := &Group{
+		ID:      g.ID.String(),
+		Command: g.Command,
+		Tasks:   make([]string, len(g.Tasks)),
+	}
+	for i, t := range g.Tasks {
+		jg.Tasks[i] = t.ID.String()
+		switch e.statuses[t.ID] {
+		case task.Exited:
+			jg.Exited++
+		case task.Errored:
+			jg.Errored++
+		case task.Canceled:
+			jg.Canceled++
+		}
+	}
+	return jg
+}
@@ -0,0 +1,74 @@
+package headless
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/leg100/pug/internal/plan"
+	"github.com/leg100/pug/internal/state"
+	"github.com/leg100/pug/internal/workspace"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmitter_Schema(t *testing.T) {
+	var got bytes.Buffer
+	e := newEmitter(nil, &got)
+	e.now = func() time.Time { return time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC) }
+
+	e.emit(Event{
+		Type:    TaskSummaryEvent,
+		Task:    &Task{ID: "#2", GroupID: "#1", Command: "plan", Module: "a", Workspace: "prod", Status: "exited"},
+		Summary: newSummary(plan.Report{Additions: 1, Changes: 2, Destructions: 3}),
+	})
+	e.error("something went wrong")
+
+	want := `{"version":1,"type":"task_summary","time":"2024-01-02T03:04:05Z","task":{"id":"#2","group_id":"#1","command":"plan","module":"a","workspace":"prod","status":"exited"},"summary":{"type":"plan","text":"+1/~2/−3","plan":{"additions":1,"changes":2,"destructions":3}}}
+{"version":1,"type":"error","time":"2024-01-02T03:04:05Z","error":"something went wrong"}
+`
+	assert.Equal(t, want, got.String())
+}
+
+func TestNewSummary(t *testing.T) {
+	cost := 12.5
+	tests := []struct {
+		name    string
+		summary interface{ String() string }
+		want    *Summary
+	}{
+		{
+			"plan",
+			plan.Report{Additions: 1},
+			&Summary{Type: "plan", Text: "+1/~0/−0", Plan: &plan.Report{Additions: 1}},
+		},
+		{
+			"state reload",
+			state.Updated,
+			&Summary{Type: "state_reload", Text: "updated", StateReload: "updated"},
+		},
+		{
+			"workspace reload",
+			workspace.ReloadSummary{Added: []string{"dev", "prod"}},
+			&Summary{Type: "workspace_reload", Text: "+2-0", WorkspaceReload: &WorkspaceReloadSummary{Added: []string{"dev", "prod"}, Removed: []string{}}},
+		},
+		{
+			"cost",
+			workspace.CostSummary(12.5),
+			&Summary{Type: "cost", Text: "$12.50", Cost: &cost},
+		},
+		{
+			"other",
+			otherSummary{},
+			&Summary{Type: "other", Text: "other"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, newSummary(tt.summary))
+		})
+	}
+}
+
+type otherSummary struct{}
+
+func (otherSummary) String() string { return "other" }
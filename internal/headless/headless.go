@@ -0,0 +1,420 @@
+// package headless runs commands without the TUI, e.g. in CI pipelines,
+// streaming the output of tasks and summarising their outcome.
+package headless
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/leg100/pug/internal/app"
+	"github.com/leg100/pug/internal/module"
+	"github.com/leg100/pug/internal/plan"
+	"github.com/leg100/pug/internal/resource"
+	"github.com/leg100/pug/internal/task"
+	"github.com/leg100/pug/internal/workspace"
+)
+
+// ErrPlanChanges is returned when a plan has changes and the user has asked
+// for a detailed exit code.
+var ErrPlanChanges = errors.New("one or more plans have changes")
+
+// prefixColors are the colors with which task output prefixes are rendered,
+// each task taking the next color in turn.
+var prefixColors = []lipgloss.Color{"6", "3", "2", "5", "4", "1"}
+
+// Run runs a command headlessly, constructing the same services as the TUI and
+// scheduling tasks in the same way. Each task's output is written to stdout,
+// prefixed with its module and workspace, followed by a table summarising each
+// task; or, if configured, newline-delimited JSON events are written to stdout
+// instead. An error is returned if any task failed.
+func Run(cfg app.Config, stdout io.Writer) error {
+	app, err := app.New(cfg)
+	if err != nil {
+		return err
+	}
+	defer app.Cleanup()
+
+	r := &runner{
+		App:    app,
+		cfg:    *cfg.Run,
+		stdout: stdout,
+	}
+	if r.cfg.JSON {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		r.events = newEmitter(r, stdout)
+		r.events.start(ctx)
+	}
+	return r.run()
+}
+
+type runner struct {
+	*app.App
+
+	cfg    app.RunConfig
+	stdout io.Writer
+	// mu serializes writes to stdout.
+	mu sync.Mutex
+	// events emits JSON events, and is nil unless JSON is configured.
+	events *emitter
+}
+
+func (r *runner) run() error {
+	if _, _, err := r.Modules.Reload(); err != nil {
+		return fmt.Errorf("loading modules: %w", err)
+	}
+	modules := matchModules(r.Modules.List(), r.cfg.Modules)
+	slices.SortFunc(modules, func(i, j *module.Module) int {
+		return strings.Compare(i.Path, j.Path)
+	})
+	if len(modules) == 0 {
+		return errors.New("no modules found")
+	}
+	specs, err := r.specs(modules)
+	if err != nil {
+		return err
+	}
+	if len(specs) == 0 {
+		return errors.New("no tasks to run")
+	}
+	group, err := r.Tasks.CreateGroup(specs...)
+	if err != nil {
+		return fmt.Errorf("creating tasks: %w", err)
+	}
+	for _, err := range group.CreateErrors {
+		r.error("error creating task: %s", err)
+	}
+	if r.events != nil {
+		r.events.wait(group)
+	} else {
+		r.stream(group.Tasks)
+		r.summarize(group.Tasks)
+	}
+	return result(group, r.cfg.DetailedExitCode)
+}
+
+// specs creates a task spec for each module or workspace on which the command
+// is run.
+func (r *runner) specs(modules []*module.Module) ([]task.Spec, error) {
+	var fn task.SpecFunc
+	switch r.cfg.Command {
+	case "init":
+		fn = func(moduleID resource.ID) (task.Spec, error) {
+			return r.Modules.Init(moduleID, false)
+		}
+	case "fmt":
+		fn = r.Modules.Format
+	case "validate":
+		fn = r.Modules.Validate
+	}
+	if fn != nil {
+		ids := make([]resource.ID, len(modules))
+		for i, mod := range modules {
+			ids[i] = mod.ID
+		}
+		return r.createSpecs(fn, ids...), nil
+	}
+
+	// The remaining commands run on workspaces.
+	workspaces, err := r.loadWorkspaces(modules)
+	if err != nil {
+		return nil, err
+	}
+	if len(workspaces) == 0 {
+		return nil, errors.New("no workspaces found")
+	}
+	ids := make([]resource.ID, len(workspaces))
+	for i, ws := range workspaces {
+		ids[i] = ws.ID
+	}
+	switch r.cfg.Command {
+	case "plan":
+		fn = func(workspaceID resource.ID) (task.Spec, error) {
+			return r.Plans.Plan(workspaceID, plan.CreateOptions{})
+		}
+	case "apply":
+		fn = func(workspaceID resource.ID) (task.Spec, error) {
+			return r.Plans.Apply(workspaceID, plan.CreateOptions{})
+		}
+	case "destroy":
+		fn = func(workspaceID resource.ID) (task.Spec, error) {
+			return r.Plans.Apply(workspaceID, plan.CreateOptions{Destroy: true})
+		}
+	case "cost":
+		// A single task calculates the cost of all workspaces.
+		spec, err := r.Workspaces.Cost(ids...)
+		if err != nil {
+			return nil, err
+		}
+		return []task.Spec{spec}, nil
+	default:
+		return nil, fmt.Errorf("unknown command: %s", r.cfg.Command)
+	}
+	return r.createSpecs(fn, ids...), nil
+}
+
+// createSpecs invokes fn with each id in ids, reporting and skipping any that
+// fail.
+func (r *runner) createSpecs(fn task.SpecFunc, ids ...resource.ID) []task.Spec {
+	specs := make([]task.Spec, 0, len(ids))
+	for _, id := range ids {
+		spec, err := fn(id)
+		if err != nil {
+			r.error("error creating task: %s", err)
+			continue
+		}
+		specs = append(specs, spec)
+	}
+	return specs
+}
+
+// loadWorkspaces loads the workspaces of the modules, returning those matching
+// the workspace patterns or, if there are no patterns, the current workspace
+// of each module. Modules whose workspaces fail to load, e.g. because they
+// have not been initialized, are reported and skipped.
+func (r *runner) loadWorkspaces(modules []*module.Module) ([]*workspace.Workspace, error) {
+	tasks := make([]*task.Task, 0, len(modules))
+	for _, mod := range modules {
+		spec, err := r.Workspaces.Reload(mod.ID)
+		if err != nil {
+			return nil, fmt.Errorf("loading workspaces: %w", err)
+		}
+		t, err := r.Tasks.Create(spec)
+		if err != nil {
+			return nil, fmt.Errorf("loading workspaces: %w", err)
+		}
+		tasks = append(tasks, t)
+	}
+	var workspaces []*workspace.Workspace
+	for i, t := range tasks {
+		if err := t.Wait(); err != nil {
+			r.error("error loading workspaces for module %s: %s", modules[i].Path, err)
+			continue
+		}
+		// Retrieve module again, now that its current workspace is known.
+		mod, err := r.Modules.Get(modules[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		moduleWorkspaces := r.Workspaces.List(workspace.ListOptions{ModuleID: mod.ID})
+		slices.SortFunc(moduleWorkspaces, func(i, j *workspace.Workspace) int {
+			return strings.Compare(i.Name, j.Name)
+		})
+		for _, ws := range moduleWorkspaces {
+			if matchWorkspace(ws, mod, r.cfg.Workspaces) {
+				workspaces = append(workspaces, ws)
+			}
+		}
+	}
+	return workspaces, nil
+}
+
+// stream writes the output of each task to stdout, line by line, prefixing
+// each line with the task's module, workspace and command. It blocks until
+// every task has finished.
+func (r *runner) stream(tasks []*task.Task) {
+	prefixes := make([]string, len(tasks))
+	var width int
+	for i, t := range tasks {
+		prefixes[i] = r.label(t)
+		width = max(width, len(prefixes[i]))
+	}
+	var wg sync.WaitGroup
+	for i, t := range tasks {
+		style := lipgloss.NewStyle().Foreground(prefixColors[i%len(prefixColors)])
+		prefix := style.Render(fmt.Sprintf("%-*s |", width, prefixes[i]))
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			// Write output a line at a time, holding back any partial line
+			// until it is completed.
+			var pending []byte
+			for chunk := range t.NewStreamer() {
+				pending = append(pending, chunk...)
+				for {
+					i := bytes.IndexByte(pending, '\n')
+					if i < 0 {
+						break
+					}
+					r.printf("%s %s\n", prefix, pending[:i])
+					pending = pending[i+1:]
+				}
+			}
+			if len(pending) > 0 {
+				r.printf("%s %s\n", prefix, pending)
+			}
+			// Output is closed once the task finishes, but wait nonetheless
+			// for its summary to be set.
+			_ = t.Wait()
+		}()
+	}
+	wg.Wait()
+}
+
+// summarize writes a table summarising the outcome of each task.
+func (r *runner) summarize(tasks []*task.Task) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	fmt.Fprintln(r.stdout)
+	tw := tabwriter.NewWriter(r.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "MODULE\tWORKSPACE\tCOMMAND\tSTATUS\tSUMMARY\tDURATION")
+	for _, t := range tasks {
+		modulePath, workspaceName := r.taskModulePath(t), r.taskWorkspaceName(t)
+		state, _, _ := t.Result()
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			valueOrDash(modulePath),
+			valueOrDash(workspaceName),
+			t.String(),
+			state,
+			valueOrDash(summary(t)),
+			t.Elapsed(task.Running).Round(time.Second),
+		)
+	}
+	_ = tw.Flush()
+}
+
+// result returns an error if any task failed to be created or to finish
+// successfully or, if detailed is true, ErrPlanChanges if any plan has
+// changes.
+func result(group *task.Group, detailed bool) error {
+	var (
+		failed  = len(group.CreateErrors)
+		changes bool
+	)
+	for _, t := range group.Tasks {
+		state, summary, _ := t.Result()
+		if state != task.Exited {
+			failed++
+			continue
+		}
+		if report, ok := summary.(plan.Report); ok && t.Identifier == plan.PlanTask && report.HasChanges() {
+			changes = true
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d tasks failed", failed, len(group.Tasks)+len(group.CreateErrors))
+	}
+	if detailed && changes {
+		return ErrPlanChanges
+	}
+	return nil
+}
+
+// label labels a task with its module path, workspace name and command.
+func (r *runner) label(t *task.Task) string {
+	var parts []string
+	if path := r.taskModulePath(t); path != "" {
+		parts = append(parts, path)
+	}
+	if name := r.taskWorkspaceName(t); name != "" {
+		parts = append(parts, name)
+	}
+	return strings.Join(append(parts, t.String()), " ")
+}
+
+func (r *runner) taskModulePath(t *task.Task) string {
+	if t.ModuleID == nil {
+		return ""
+	}
+	mod, err := r.Modules.Get(t.ModuleID)
+	if err != nil {
+		return ""
+	}
+	return mod.Path
+}
+
+func (r *runner) taskWorkspaceName(t *task.Task) string {
+	if t.WorkspaceID == nil {
+		return ""
+	}
+	ws, err := r.Workspaces.Get(t.WorkspaceID)
+	if err != nil {
+		return ""
+	}
+	return ws.Name
+}
+
+// error reports an error that is not fatal to the run.
+func (r *runner) error(format string, args ...any) {
+	if r.events != nil {
+		r.events.error(fmt.Sprintf(format, args...))
+		return
+	}
+	r.printf(format+"\n", args...)
+}
+
+func (r *runner) printf(format string, args ...any) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	fmt.Fprintf(r.stdout, format, args...)
+}
+
+// summary summarises a task's outcome: its summary if it succeeded, or its
+// error if it failed.
+func summary(t *task.Task) string {
+	_, summary, err := t.Result()
+	switch {
+	case err != nil:
+		return err.Error()
+	case summary != nil:
+		return summary.String()
+	default:
+		return ""
+	}
+}
+
+func valueOrDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// matchModules returns the modules with paths matching any of the patterns.
+// If there are no patterns then all modules are returned.
+func matchModules(modules []*module.Module, patterns []string) []*module.Module {
+	if len(patterns) == 0 {
+		return modules
+	}
+	var matched []*module.Module
+	for _, mod := range modules {
+		if matchAny(mod.Path, patterns) {
+			matched = append(matched, mod)
+		}
+	}
+	return matched
+}
+
+// matchWorkspace determines whether a workspace matches any of the patterns
+// or, if there are no patterns, whether it is its module's current workspace.
+func matchWorkspace(ws *workspace.Workspace, mod *module.Module, patterns []string) bool {
+	if len(patterns) == 0 {
+		return mod.CurrentWorkspaceID == resource.ID(ws.ID)
+	}
+	return matchAny(ws.Name, patterns)
+}
+
+func matchAny(name string, patterns []string) bool {
+	for _, pattern := range patterns {
+		// Patterns are validated when parsing config, so ignore errors.
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
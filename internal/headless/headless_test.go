@@ -0,0 +1,138 @@
+package headless
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/leg100/pug/internal/app"
+	"github.com/leg100/pug/internal/logging"
+	"github.com/leg100/pug/internal/module"
+	"github.com/leg100/pug/internal/plan"
+	"github.com/leg100/pug/internal/task"
+	"github.com/leg100/pug/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	program, workdir := testutils.Fixture(t)
+
+	run := func(t *testing.T, cfg app.RunConfig) (string, error) {
+		var got bytes.Buffer
+		err := Run(app.Config{
+			Program:  program,
+			MaxTasks: 2,
+			Workdir:  workdir,
+			DataDir:  t.TempDir(),
+			Logging:  logging.Options{Level: "error"},
+			Run:      &cfg,
+		}, &got)
+		return got.String(), err
+	}
+
+	t.Run("validate", func(t *testing.T) {
+		got, err := run(t, app.RunConfig{Command: "validate"})
+		assert.EqualError(t, err, "1 of 2 tasks failed")
+
+		assert.Regexp(t, `a validate \| Success! module a is valid`, got)
+		assert.Regexp(t, `b validate \| Error: invalid module b`, got)
+		assert.Regexp(t, `a\s+-\s+validate\s+exited`, got)
+		assert.Regexp(t, `b\s+-\s+validate\s+errored\s+task failed: exit status 1`, got)
+	})
+
+	t.Run("validate matching module", func(t *testing.T) {
+		got, err := run(t, app.RunConfig{Command: "validate", Modules: []string{"a"}})
+		assert.NoError(t, err)
+		assert.NotContains(t, got, "invalid module b")
+	})
+
+	t.Run("plan current workspace", func(t *testing.T) {
+		got, err := run(t, app.RunConfig{Command: "plan", DetailedExitCode: true})
+		assert.NoError(t, err)
+		assert.Regexp(t, `a\s+default\s+plan\s+exited\s+\+0/~0/−0`, got)
+		assert.Regexp(t, `b\s+default\s+plan\s+exited\s+\+0/~0/−0`, got)
+	})
+
+	t.Run("plan matching workspace with changes", func(t *testing.T) {
+		got, err := run(t, app.RunConfig{
+			Command:          "plan",
+			Modules:          []string{"a"},
+			Workspaces:       []string{"p*"},
+			DetailedExitCode: true,
+		})
+		assert.ErrorIs(t, err, ErrPlanChanges)
+		assert.Regexp(t, `a prod plan \| Plan: 1 to add`, got)
+		assert.Regexp(t, `a\s+prod\s+plan\s+exited\s+\+1/~0/−0`, got)
+		assert.NotContains(t, got, "default")
+	})
+
+	t.Run("json", func(t *testing.T) {
+		got, err := run(t, app.RunConfig{Command: "plan", Modules: []string{"a"}, Workspaces: []string{"prod"}, JSON: true})
+		require.NoError(t, err)
+
+		var events []Event
+		for _, line := range strings.Split(strings.TrimSpace(got), "\n") {
+			var event Event
+			require.NoError(t, json.Unmarshal([]byte(line), &event), line)
+			assert.Equal(t, SchemaVersion, event.Version)
+			events = append(events, event)
+		}
+		// Find events for the plan task, ignoring events for the task that
+		// loads workspaces.
+		var (
+			types    []EventType
+			statuses []task.Status
+			output   string
+			summary  *Summary
+		)
+		for _, event := range events {
+			if event.Task == nil || event.Task.Command != "plan" {
+				continue
+			}
+			assert.Equal(t, "a", event.Task.Module)
+			assert.Equal(t, "prod", event.Task.Workspace)
+			types = append(types, event.Type)
+			switch event.Type {
+			case TaskStatusEvent:
+				statuses = append(statuses, event.Task.Status)
+			case TaskOutputEvent:
+				output += event.Output
+			case TaskSummaryEvent:
+				summary = event.Summary
+			}
+		}
+		require.NotEmpty(t, types)
+		assert.Equal(t, TaskCreatedEvent, types[0])
+		// The summary follows the final status.
+		assert.Equal(t, []EventType{TaskStatusEvent, TaskSummaryEvent}, types[len(types)-2:])
+		assert.Equal(t, task.Exited, statuses[len(statuses)-1])
+		assert.Contains(t, output, "Plan: 1 to add")
+		assert.Equal(t, &Summary{Type: "plan", Text: "+1/~0/−0", Plan: &plan.Report{Additions: 1}}, summary)
+
+		// The last event is the group finishing.
+		last := events[len(events)-1]
+		assert.Equal(t, GroupFinishedEvent, last.Type)
+		require.NotNil(t, last.Group)
+		assert.Equal(t, 1, last.Group.Exited)
+	})
+
+	t.Run("no matching modules", func(t *testing.T) {
+		_, err := run(t, app.RunConfig{Command: "validate", Modules: []string{"c"}})
+		assert.EqualError(t, err, "no modules found")
+	})
+}
+
+func TestMatchModules(t *testing.T) {
+	var (
+		network = &module.Module{Path: "envs/prod/network"}
+		compute = &module.Module{Path: "envs/prod/compute"}
+		dev     = &module.Module{Path: "envs/dev/network"}
+		modules = []*module.Module{network, compute, dev}
+	)
+	assert.Equal(t, modules, matchModules(modules, nil))
+	assert.Equal(t, []*module.Module{network, dev}, matchModules(modules, []string{"envs/*/network"}))
+	assert.Equal(t, []*module.Module{network, compute}, matchModules(modules, []string{"envs/prod/*"}))
+	assert.Empty(t, matchModules(modules, []string{"envs"}))
+}
@@ -282,7 +282,7 @@ func TestExplorer_MultipleApplies(t *testing.T) {
 
 	// Give approval
 	waitFor(t, tm, func(s string) bool {
-		return strings.Contains(s, "Auto-apply 3 workspaces? (y/N):")
+		return matchPattern(t, `preview.*apply`, s) && strings.Contains(s, "Stage 1")
 	})
 	tm.Type("y")
 
@@ -402,7 +402,7 @@ func TestExplorer_MultipleDestroys(t *testing.T) {
 
 	// Give approval
 	waitFor(t, tm, func(s string) bool {
-		return strings.Contains(s, "Destroy resources of 3 workspaces? (y/N):")
+		return matchPattern(t, `preview.*apply \(destroy\)`, s) && strings.Contains(s, "Stage 1")
 	})
 	tm.Type("y")
 
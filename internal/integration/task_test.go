@@ -46,7 +46,7 @@ func TestTask_LongRunningTasks(t *testing.T) {
 	// Auto-apply all modules
 	tm.Type("a")
 	waitFor(t, tm, func(s string) bool {
-		return strings.Contains(s, "Auto-apply 3 workspaces? (y/N):")
+		return matchPattern(t, `preview.*apply`, s) && strings.Contains(s, "Stage 1")
 	})
 	tm.Type("y")
 
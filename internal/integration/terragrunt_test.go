@@ -67,7 +67,7 @@ func TestTerragrunt_Dependencies(t *testing.T) {
 
 	// Give approval
 	waitFor(t, tm, func(s string) bool {
-		return strings.Contains(s, "Auto-apply 6 workspaces? (y/N):")
+		return matchPattern(t, `preview.*apply`, s) && strings.Contains(s, "Stage 1")
 	})
 	tm.Type("y")
 
@@ -98,7 +98,7 @@ func TestTerragrunt_Dependencies(t *testing.T) {
 
 	// Give approval
 	waitFor(t, tm, func(s string) bool {
-		return strings.Contains(s, "Destroy resources of 6 workspaces? (y/N):")
+		return matchPattern(t, `preview.*apply \(destroy\)`, s) && strings.Contains(s, "Stage 1")
 	})
 	tm.Type("y")
 
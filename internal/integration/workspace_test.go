@@ -106,7 +106,7 @@ func TestWorkspace_MultipleApplies(t *testing.T) {
 
 	// Give approval
 	waitFor(t, tm, func(s string) bool {
-		return strings.Contains(s, "Auto-apply 4 workspaces? (y/N):")
+		return matchPattern(t, `preview.*apply`, s) && strings.Contains(s, "Stage 1")
 	})
 	tm.Type("y")
 
@@ -194,7 +194,7 @@ func TestWorkspace_MultipleDestroy(t *testing.T) {
 
 	// Give approval
 	waitFor(t, tm, func(s string) bool {
-		return strings.Contains(s, "Destroy resources of 3 workspaces? (y/N):")
+		return matchPattern(t, `preview.*apply \(destroy\)`, s) && strings.Contains(s, "Stage 1")
 	})
 	tm.Type("y")
 
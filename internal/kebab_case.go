@@ -0,0 +1,28 @@
+package internal
+
+import (
+	"strings"
+	"unicode"
+)
+
+// KebabCase converts a Go identifier, e.g. ReloadWorkspaces or JSONKey, into
+// kebab case, e.g. reload-workspaces or json-key.
+func KebabCase(s string) string {
+	var (
+		b     strings.Builder
+		runes = []rune(s)
+	)
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 {
+			// Start a new word after a lowercase letter, or at the last
+			// capital of an acronym that is followed by a lowercase letter.
+			prevLower := unicode.IsLower(runes[i-1])
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if prevLower || (unicode.IsUpper(runes[i-1]) && nextLower) {
+				b.WriteRune('-')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
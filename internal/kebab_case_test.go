@@ -0,0 +1,19 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKebabCase(t *testing.T) {
+	tests := map[string]string{
+		"Quit":             "quit",
+		"ReloadWorkspaces": "reload-workspaces",
+		"JSONKey":          "json-key",
+		"ActiveBorder":     "active-border",
+	}
+	for in, want := range tests {
+		assert.Equal(t, want, KebabCase(in))
+	}
+}
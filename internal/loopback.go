@@ -0,0 +1,22 @@
+package internal
+
+import (
+	"fmt"
+	"net"
+)
+
+// CheckLoopback checks the address, in the form host:port, is a loopback
+// address, to prevent a server from being exposed to the network.
+func CheckLoopback(addr string) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("invalid address: %w", err)
+	}
+	if host == "localhost" {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return nil
+	}
+	return fmt.Errorf("invalid address: %s: must be a loopback address", addr)
+}
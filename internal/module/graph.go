@@ -0,0 +1,195 @@
+package module
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/leg100/pug/internal/resource"
+)
+
+// Graph is the dependency graph of modules.
+type Graph struct {
+	// Nodes are the modules in the graph, ordered by layer, and then by path.
+	Nodes []*GraphNode
+	// Cycles are groups of modules that depend upon one another, each ordered
+	// by path.
+	Cycles [][]*Module
+
+	nodes map[resource.ID]*GraphNode
+}
+
+// GraphNode is a module in the dependency graph.
+type GraphNode struct {
+	Module *Module
+	// Layer is the length of the longest chain of dependencies beneath the
+	// module. Modules without dependencies are in layer zero. Modules in a
+	// cycle share the same layer.
+	Layer int
+	// Dependencies are the modules this module depends upon.
+	Dependencies []*Module
+	// Dependents are the modules that depend upon this module.
+	Dependents []*Module
+	// InCycle is true if the module is part of a dependency cycle.
+	InCycle bool
+}
+
+// NewGraph constructs a dependency graph from modules. Dependencies upon
+// modules that are not provided are ignored.
+func NewGraph(modules []*Module) *Graph {
+	g := &Graph{nodes: make(map[resource.ID]*GraphNode, len(modules))}
+	for _, mod := range modules {
+		node := &GraphNode{Module: mod}
+		g.nodes[mod.ID] = node
+		g.Nodes = append(g.Nodes, node)
+	}
+	for _, node := range g.Nodes {
+		for _, id := range node.Module.Dependencies() {
+			dep, ok := g.nodes[id]
+			if !ok || dep == node {
+				continue
+			}
+			node.Dependencies = append(node.Dependencies, dep.Module)
+			dep.Dependents = append(dep.Dependents, node.Module)
+		}
+	}
+	g.layer(g.components())
+	slices.SortFunc(g.Nodes, func(a, b *GraphNode) int {
+		if n := cmp.Compare(a.Layer, b.Layer); n != 0 {
+			return n
+		}
+		return cmp.Compare(a.Module.Path, b.Module.Path)
+	})
+	return g
+}
+
+// components finds the strongly connected components of the graph using
+// Tarjan's algorithm, recording those with more than one module as cycles.
+// Components are returned in reverse topological order, i.e. a component
+// appears after the components it depends upon.
+func (g *Graph) components() [][]*GraphNode {
+	var (
+		index      = make(map[*GraphNode]int)
+		lowlink    = make(map[*GraphNode]int)
+		onStack    = make(map[*GraphNode]bool)
+		stack      []*GraphNode
+		components [][]*GraphNode
+		visit      func(*GraphNode)
+	)
+	visit = func(n *GraphNode) {
+		index[n] = len(index)
+		lowlink[n] = index[n]
+		stack = append(stack, n)
+		onStack[n] = true
+
+		for _, dep := range n.Dependencies {
+			w := g.nodes[dep.ID]
+			if _, ok := index[w]; !ok {
+				visit(w)
+				lowlink[n] = min(lowlink[n], lowlink[w])
+			} else if onStack[w] {
+				lowlink[n] = min(lowlink[n], index[w])
+			}
+		}
+		if lowlink[n] != index[n] {
+			return
+		}
+		var component []*GraphNode
+		for {
+			w := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[w] = false
+			component = append(component, w)
+			if w == n {
+				break
+			}
+		}
+		components = append(components, component)
+	}
+	// Visit in order of path for a deterministic result.
+	sorted := slices.Clone(g.Nodes)
+	slices.SortFunc(sorted, func(a, b *GraphNode) int {
+		return cmp.Compare(a.Module.Path, b.Module.Path)
+	})
+	for _, n := range sorted {
+		if _, ok := index[n]; !ok {
+			visit(n)
+		}
+	}
+	for _, component := range components {
+		if len(component) < 2 {
+			continue
+		}
+		cycle := make([]*Module, len(component))
+		for i, n := range component {
+			n.InCycle = true
+			cycle[i] = n.Module
+		}
+		slices.SortFunc(cycle, ByPath)
+		g.Cycles = append(g.Cycles, cycle)
+	}
+	return components
+}
+
+// layer assigns a layer to each node, visiting components such that each
+// component is visited after the components it depends upon.
+func (g *Graph) layer(components [][]*GraphNode) {
+	for _, component := range components {
+		var layer int
+		for _, n := range component {
+			for _, dep := range n.Dependencies {
+				w := g.nodes[dep.ID]
+				if slices.Contains(component, w) {
+					continue
+				}
+				layer = max(layer, w.Layer+1)
+			}
+		}
+		for _, n := range component {
+			n.Layer = layer
+		}
+	}
+}
+
+// Node retrieves the node for the module with the given ID.
+func (g *Graph) Node(id resource.ID) (*GraphNode, bool) {
+	node, ok := g.nodes[id]
+	return node, ok
+}
+
+// Upstream returns the IDs of the modules that the given module depends upon,
+// directly or indirectly.
+func (g *Graph) Upstream(id resource.ID) []resource.ID {
+	return g.walk(id, func(n *GraphNode) []*Module { return n.Dependencies })
+}
+
+// Downstream returns the IDs of the modules that depend upon the given module,
+// directly or indirectly.
+func (g *Graph) Downstream(id resource.ID) []resource.ID {
+	return g.walk(id, func(n *GraphNode) []*Module { return n.Dependents })
+}
+
+func (g *Graph) walk(id resource.ID, next func(*GraphNode) []*Module) []resource.ID {
+	start, ok := g.nodes[id]
+	if !ok {
+		return nil
+	}
+	var (
+		visited = map[*GraphNode]bool{start: true}
+		queue   = []*GraphNode{start}
+		ids     []resource.ID
+	)
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		for _, mod := range next(n) {
+			w := g.nodes[mod.ID]
+			if visited[w] {
+				continue
+			}
+			visited[w] = true
+			queue = append(queue, w)
+			ids = append(ids, w.Module.ID)
+		}
+	}
+	return ids
+}
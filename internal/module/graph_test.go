@@ -0,0 +1,67 @@
+package module
+
+import (
+	"testing"
+
+	"github.com/leg100/pug/internal/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGraph(t *testing.T) {
+	network := New(Options{Path: "network"})
+	cluster := New(Options{Path: "cluster"})
+	apps := New(Options{Path: "apps"})
+	monitoring := New(Options{Path: "monitoring"})
+	cluster.dependencies = []resource.ID{network.ID}
+	apps.dependencies = []resource.ID{cluster.ID, network.ID}
+	monitoring.dependencies = []resource.ID{apps.ID}
+
+	g := NewGraph([]*Module{apps, monitoring, cluster, network})
+
+	// Nodes are ordered by layer
+	var got []string
+	for _, node := range g.Nodes {
+		got = append(got, node.Module.Path)
+	}
+	assert.Equal(t, []string{"network", "cluster", "apps", "monitoring"}, got)
+
+	node, ok := g.Node(apps.ID)
+	require.True(t, ok)
+	assert.Equal(t, 2, node.Layer)
+	assert.Equal(t, []*Module{cluster, network}, node.Dependencies)
+	assert.Equal(t, []*Module{monitoring}, node.Dependents)
+
+	assert.ElementsMatch(t, []resource.ID{cluster.ID, network.ID}, g.Upstream(apps.ID))
+	assert.ElementsMatch(t, []resource.ID{apps.ID, monitoring.ID}, g.Downstream(cluster.ID))
+	assert.Empty(t, g.Upstream(network.ID))
+	assert.Empty(t, g.Cycles)
+}
+
+func TestGraph_Cycle(t *testing.T) {
+	a := New(Options{Path: "a"})
+	b := New(Options{Path: "b"})
+	c := New(Options{Path: "c"})
+	d := New(Options{Path: "d"})
+	// a <- b <-> c <- d
+	b.dependencies = []resource.ID{a.ID, c.ID}
+	c.dependencies = []resource.ID{b.ID}
+	d.dependencies = []resource.ID{c.ID}
+
+	g := NewGraph([]*Module{d, c, b, a})
+
+	if assert.Len(t, g.Cycles, 1) {
+		assert.Equal(t, []*Module{b, c}, g.Cycles[0])
+	}
+	for _, mod := range []*Module{b, c} {
+		node, _ := g.Node(mod.ID)
+		assert.True(t, node.InCycle)
+		assert.Equal(t, 1, node.Layer)
+	}
+	node, _ := g.Node(d.ID)
+	assert.False(t, node.InCycle)
+	assert.Equal(t, 2, node.Layer)
+
+	assert.ElementsMatch(t, []resource.ID{a.ID, b.ID, c.ID}, g.Upstream(d.ID))
+	assert.ElementsMatch(t, []resource.ID{c.ID, d.ID}, g.Downstream(b.ID))
+}
@@ -0,0 +1,200 @@
+package module
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/leg100/pug/internal"
+	"github.com/leg100/pug/internal/resource"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// stateIdentity lists, for each supported backend type, the backend
+// configuration attributes that together identify the location of a module's
+// state.
+var stateIdentity = map[string][]string{
+	"local":   {"path"},
+	"s3":      {"bucket", "key"},
+	"gcs":     {"bucket", "prefix"},
+	"azurerm": {"storage_account_name", "container_name", "key"},
+	"consul":  {"path"},
+	"http":    {"address"},
+}
+
+// stateLocation identifies the location of a module's state.
+type stateLocation struct {
+	backend string
+	id      string
+}
+
+// moduleStates are the state locations parsed from a module's configuration.
+type moduleStates struct {
+	// backend is the location of the module's own state, or nil if it could
+	// not be determined.
+	backend *stateLocation
+	// remote are the locations of state referenced by the module's
+	// terraform_remote_state data sources.
+	remote []stateLocation
+}
+
+// inferDependencies infers dependencies between modules from their
+// terraform_remote_state data sources: a module that reads the state of
+// another module depends upon that module. Only backend attributes set to
+// literal values are considered. The returned map is keyed by the ID of the
+// dependent module.
+func inferDependencies(workdir internal.Workdir, modules []*Module) map[resource.ID][]resource.ID {
+	var (
+		owners = make(map[stateLocation]resource.ID)
+		states = make(map[resource.ID]moduleStates, len(modules))
+	)
+	for _, mod := range modules {
+		ms := parseModuleStates(workdir, mod.Path)
+		if ms.backend != nil {
+			owners[*ms.backend] = mod.ID
+		}
+		states[mod.ID] = ms
+	}
+	dependencies := make(map[resource.ID][]resource.ID)
+	for _, mod := range modules {
+		for _, loc := range states[mod.ID].remote {
+			owner, ok := owners[loc]
+			if !ok || owner == mod.ID {
+				continue
+			}
+			if !slices.Contains(dependencies[mod.ID], owner) {
+				dependencies[mod.ID] = append(dependencies[mod.ID], owner)
+			}
+		}
+	}
+	return dependencies
+}
+
+// parseModuleStates parses the terraform configuration files in a module's
+// directory for its backend and any terraform_remote_state data sources.
+// Files that fail to parse are skipped.
+func parseModuleStates(workdir internal.Workdir, path string) moduleStates {
+	var ms moduleStates
+
+	files, _ := filepath.Glob(filepath.Join(workdir.Join(path), "*.tf"))
+	for _, fname := range files {
+		f, diags := hclparse.NewParser().ParseHCLFile(fname)
+		if diags.HasErrors() {
+			continue
+		}
+		content, _, _ := f.Body.PartialContent(&hcl.BodySchema{
+			Blocks: []hcl.BlockHeaderSchema{
+				{Type: "terraform"},
+				{Type: "data", LabelNames: []string{"type", "name"}},
+			},
+		})
+		for _, block := range content.Blocks {
+			switch block.Type {
+			case "terraform":
+				if loc, ok := parseBackendBlock(path, block.Body); ok {
+					ms.backend = &loc
+				}
+			case "data":
+				if block.Labels[0] != "terraform_remote_state" {
+					continue
+				}
+				if loc, ok := parseRemoteStateBlock(path, block.Body); ok {
+					ms.remote = append(ms.remote, loc)
+				}
+			}
+		}
+	}
+	return ms
+}
+
+func parseBackendBlock(path string, body hcl.Body) (stateLocation, bool) {
+	content, _, _ := body.PartialContent(&hcl.BodySchema{
+		Blocks: []hcl.BlockHeaderSchema{
+			{Type: "backend", LabelNames: []string{"type"}},
+		},
+	})
+	for _, block := range content.Blocks {
+		attrs, _ := block.Body.JustAttributes()
+		config := make(map[string]string, len(attrs))
+		for name, attr := range attrs {
+			if v, ok := evalString(attr.Expr); ok {
+				config[name] = v
+			}
+		}
+		return newStateLocation(path, block.Labels[0], config)
+	}
+	return stateLocation{}, false
+}
+
+func parseRemoteStateBlock(path string, body hcl.Body) (stateLocation, bool) {
+	content, _, _ := body.PartialContent(&hcl.BodySchema{
+		Attributes: []hcl.AttributeSchema{
+			{Name: "backend", Required: true},
+			{Name: "config"},
+		},
+	})
+	attr, ok := content.Attributes["backend"]
+	if !ok {
+		return stateLocation{}, false
+	}
+	backend, ok := evalString(attr.Expr)
+	if !ok {
+		return stateLocation{}, false
+	}
+	config := make(map[string]string)
+	if attr, ok := content.Attributes["config"]; ok {
+		v, diags := attr.Expr.Value(nil)
+		if diags.HasErrors() || !v.Type().IsObjectType() {
+			return stateLocation{}, false
+		}
+		for name, v := range v.AsValueMap() {
+			if v.IsKnown() && !v.IsNull() && v.Type() == cty.String {
+				config[name] = v.AsString()
+			}
+		}
+	}
+	return newStateLocation(path, backend, config)
+}
+
+// newStateLocation constructs the location of state from a backend type and
+// its configuration, relative to the module at the given path. False is
+// returned if the backend is unsupported or its configuration lacks an
+// identifying attribute.
+func newStateLocation(path, backend string, config map[string]string) (stateLocation, bool) {
+	keys, ok := stateIdentity[backend]
+	if !ok {
+		return stateLocation{}, false
+	}
+	if backend == "local" {
+		// The local backend defaults to a file in the module directory, and
+		// relative paths are relative to the module directory.
+		statePath := config["path"]
+		if statePath == "" {
+			statePath = "terraform.tfstate"
+		}
+		if !filepath.IsAbs(statePath) {
+			statePath = filepath.Join(path, statePath)
+		}
+		return stateLocation{backend: backend, id: filepath.Clean(statePath)}, true
+	}
+	values := make([]string, len(keys))
+	for i, key := range keys {
+		v, ok := config[key]
+		if !ok {
+			return stateLocation{}, false
+		}
+		values[i] = v
+	}
+	return stateLocation{backend: backend, id: strings.Join(values, "\x00")}, true
+}
+
+// evalString evaluates an expression that is a literal string.
+func evalString(expr hcl.Expression) (string, bool) {
+	v, diags := expr.Value(nil)
+	if diags.HasErrors() || !v.IsKnown() || v.IsNull() || v.Type() != cty.String {
+		return "", false
+	}
+	return v.AsString(), true
+}
@@ -22,6 +22,9 @@ type Service struct {
 	pluginCache bool
 	logger      logging.Interface
 	terragrunt  bool
+	// declared maps module paths to the paths of modules they depend upon, as
+	// declared by the user.
+	declared map[string][]string
 
 	*pubsub.Broker[*Module]
 }
@@ -32,6 +35,12 @@ type ServiceOptions struct {
 	PluginCache bool
 	Logger      logging.Interface
 	Terragrunt  bool
+	// Dependencies maps module paths to the paths of modules they depend upon.
+	// Ignored in terragrunt mode, in which dependencies are determined by
+	// terragrunt.
+	Dependencies map[string][]string
+	// Events configures the brokers that publish the service's events.
+	Events []pubsub.Option
 }
 
 type taskCreator interface {
@@ -43,12 +52,21 @@ type moduleTable interface {
 	Update(id resource.ID, updater func(existing *Module) error) (*Module, error)
 	Delete(id resource.ID)
 	Get(id resource.ID) (*Module, error)
+	GetBy(index string, key any) (*Module, error)
 	List() []*Module
 }
 
+// pathIndex indexes modules by their path.
+const pathIndex = "path"
+
 func NewService(opts ServiceOptions) *Service {
-	broker := pubsub.NewBroker[*Module](opts.Logger)
-	table := resource.NewTable(broker)
+	broker := pubsub.NewBroker[*Module](opts.Logger, opts.Events...)
+	table := resource.NewTable(broker,
+		resource.WithIndex(pathIndex, func(mod *Module) []any {
+			return []any{mod.Path}
+		}),
+		resource.WithOrder(ByPath),
+	)
 
 	opts.Logger.AddArgsUpdater(&logging.ReferenceUpdater[*Module]{
 		Getter: table,
@@ -64,6 +82,7 @@ func NewService(opts ServiceOptions) *Service {
 		pluginCache: opts.PluginCache,
 		logger:      opts.Logger,
 		terragrunt:  opts.Terragrunt,
+		declared:    opts.Dependencies,
 	}
 }
 
@@ -121,10 +140,49 @@ func (s *Service) Reload() (added []string, removed []string, err error) {
 		if err := s.loadTerragruntDependencies(); err != nil {
 			s.logger.Error("loading terragrunt dependencies: %w", err)
 		}
+	} else {
+		s.loadDependencies()
 	}
 	return
 }
 
+// loadDependencies loads the dependencies of vanilla terraform modules, both
+// those declared by the user and those inferred from terraform_remote_state
+// data sources.
+func (s *Service) loadDependencies() {
+	modules := s.table.List()
+	dependencies := inferDependencies(s.workdir, modules)
+	for path, depPaths := range s.declared {
+		mod, err := s.GetByPath(path)
+		if err != nil {
+			s.logger.Warn("loading declared module dependencies", "module", path, "error", err)
+			continue
+		}
+		for _, depPath := range depPaths {
+			dep, err := s.GetByPath(depPath)
+			if err != nil {
+				s.logger.Warn("loading declared module dependency", "module", path, "dependency", depPath, "error", err)
+				continue
+			}
+			if dep.ID != mod.ID && !slices.Contains(dependencies[mod.ID], resource.ID(dep.ID)) {
+				dependencies[mod.ID] = append(dependencies[mod.ID], dep.ID)
+			}
+		}
+	}
+	for _, mod := range modules {
+		// Only update modules whose dependencies have changed, to avoid
+		// publishing needless events.
+		if slices.Equal(mod.dependencies, dependencies[mod.ID]) {
+			continue
+		}
+		s.table.Update(mod.ID, func(existing *Module) error {
+			existing.dependencies = dependencies[mod.ID]
+			return nil
+		})
+	}
+	s.logger.Debug("loaded module dependencies", "modules_with_dependencies", len(dependencies))
+}
+
 func (s *Service) loadTerragruntDependencies() error {
 	task, err := s.tasks.Create(task.Spec{
 		Execution: task.Execution{
@@ -272,13 +330,13 @@ func (s *Service) Get(id resource.ID) (*Module, error) {
 	return s.table.Get(id)
 }
 
+// Graph returns the dependency graph of modules.
+func (s *Service) Graph() *Graph {
+	return NewGraph(s.table.List())
+}
+
 func (s *Service) GetByPath(path string) (*Module, error) {
-	for _, mod := range s.table.List() {
-		if path == mod.Path {
-			return mod, nil
-		}
-	}
-	return nil, fmt.Errorf("%s: %w", path, resource.ErrNotFound)
+	return s.table.GetBy(pathIndex, path)
 }
 
 // SetCurrent sets the current workspace for the module.
@@ -101,6 +101,15 @@ func (f *fakeModuleTable) List() []*Module {
 	return f.modules
 }
 
+func (f *fakeModuleTable) GetBy(index string, key any) (*Module, error) {
+	for _, mod := range f.modules {
+		if index == pathIndex && mod.Path == key {
+			return mod, nil
+		}
+	}
+	return nil, resource.ErrNotFound
+}
+
 func (f *fakeModuleTable) Update(id resource.ID, updater func(*Module) error) (*Module, error) {
 	for _, mod := range f.modules {
 		if mod.ID == id {
@@ -112,3 +121,32 @@ func (f *fakeModuleTable) Update(id resource.ID, updater func(*Module) error) (*
 	}
 	return nil, resource.ErrNotFound
 }
+
+func TestLoadDependencies(t *testing.T) {
+	workdir, err := internal.NewWorkdir("./testdata/remote_state")
+	require.NoError(t, err)
+	network := New(Options{Path: "network"})
+	cluster := New(Options{Path: "cluster"})
+	apps := New(Options{Path: "apps"})
+	unrelated := New(Options{Path: "unrelated"})
+	svc := &Service{
+		table:   &fakeModuleTable{modules: []*Module{network, cluster, apps, unrelated}},
+		workdir: workdir,
+		logger:  logging.Discard,
+		declared: map[string][]string{
+			"unrelated": {"cluster", "does-not-exist"},
+			// Dependency already inferred from remote state.
+			"apps": {"network"},
+		},
+	}
+
+	svc.loadDependencies()
+
+	assert.Len(t, network.Dependencies(), 0)
+	assert.Equal(t, []resource.ID{network.ID}, cluster.Dependencies())
+	if assert.Len(t, apps.Dependencies(), 2) {
+		assert.Contains(t, apps.Dependencies(), cluster.ID)
+		assert.Contains(t, apps.Dependencies(), network.ID)
+	}
+	assert.Equal(t, []resource.ID{cluster.ID}, unrelated.Dependencies())
+}
@@ -0,0 +1,22 @@
+data "terraform_remote_state" "cluster" {
+  backend = "local"
+  config = {
+    path = "../cluster/terraform.tfstate"
+  }
+}
+
+data "terraform_remote_state" "network" {
+  backend = "s3"
+  config = {
+    bucket = "infra"
+    key    = "network/terraform.tfstate"
+  }
+}
+
+data "terraform_remote_state" "elsewhere" {
+  backend = "s3"
+  config = {
+    bucket = "infra"
+    key    = "elsewhere/terraform.tfstate"
+  }
+}
@@ -0,0 +1,12 @@
+terraform {
+  backend "local" {}
+}
+
+data "terraform_remote_state" "network" {
+  backend = "s3"
+  config = {
+    bucket = "infra"
+    key    = "network/terraform.tfstate"
+    region = "eu-west-2"
+  }
+}
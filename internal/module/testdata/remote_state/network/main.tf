@@ -0,0 +1,7 @@
+terraform {
+  backend "s3" {
+    bucket = "infra"
+    key    = "network/terraform.tfstate"
+    region = "eu-west-2"
+  }
+}
@@ -0,0 +1,14 @@
+terraform {
+  backend "s3" {
+    bucket = "infra"
+    key    = var.key
+  }
+}
+
+data "terraform_remote_state" "unknown" {
+  backend = "s3"
+  config = {
+    bucket = "infra"
+    key    = var.key
+  }
+}
@@ -34,6 +34,8 @@ type plan struct {
 	// taskID is the ID of the plan task, and is only set once the task is
 	// created.
 	taskID resource.ID
+	// applied is true once the plan file has been applied.
+	applied bool
 }
 
 type CreateOptions struct {
@@ -92,6 +94,11 @@ func (f *factory) newPlan(workspaceID resource.ID, opts CreateOptions) (*plan, e
 	return plan, nil
 }
 
+// unapplied is true if the plan has changes that are yet to be applied.
+func (r *plan) unapplied() bool {
+	return r.planFile && r.HasChanges && !r.applied
+}
+
 func (r *plan) planPath() string {
 	return filepath.Join(r.ArtefactsPath, "plan")
 }
@@ -115,9 +122,6 @@ func (r *plan) planTaskSpec() task.Spec {
 		// TODO: explain why plan is blocking (?)
 		Blocking:    true,
 		Description: "plan",
-		AfterCreate: func(t *task.Task) {
-			r.taskID = t.ID
-		},
 		BeforeExited: func(t *task.Task) (task.Summary, error) {
 			out, err := io.ReadAll(t.NewReader(false))
 			if err != nil {
@@ -170,6 +174,7 @@ func (r *plan) applyTaskSpec() (task.Spec, error) {
 			if r.planFile {
 				// Plan file can now be safely removed
 				_ = os.RemoveAll(r.ArtefactsPath)
+				r.applied = true
 			}
 			report, err := parseApplyReport(string(out))
 			if err != nil {
@@ -178,8 +183,9 @@ func (r *plan) applyTaskSpec() (task.Spec, error) {
 			return report, nil
 		},
 	}
-	// If terragrunt is in use then respect module dependencies.
-	if r.terragrunt {
+	// Respect module dependencies, whether determined by terragrunt or, for
+	// vanilla terraform, declared by the user or inferred from remote state.
+	if r.terragrunt || len(r.moduleDependencies) > 0 {
 		spec.Dependencies = &task.Dependencies{
 			ModuleIDs: r.moduleDependencies,
 			// Module dependencies are reversed for a destroy.
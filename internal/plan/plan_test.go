@@ -8,6 +8,7 @@ import (
 	"github.com/leg100/pug/internal"
 	"github.com/leg100/pug/internal/module"
 	"github.com/leg100/pug/internal/resource"
+	"github.com/leg100/pug/internal/task"
 	"github.com/leg100/pug/internal/testutils"
 	"github.com/leg100/pug/internal/workspace"
 	"github.com/stretchr/testify/assert"
@@ -41,6 +42,41 @@ func TestPlan_MakeArtefactsPath(t *testing.T) {
 	assert.DirExists(t, run.ArtefactsPath)
 }
 
+func TestPlan_ApplyDependencies(t *testing.T) {
+	f, _, ws := setupTest(t)
+
+	t.Run("module without dependencies", func(t *testing.T) {
+		run, err := f.newPlan(ws.ID, CreateOptions{})
+		require.NoError(t, err)
+
+		spec, err := run.applyTaskSpec()
+		require.NoError(t, err)
+		assert.Nil(t, spec.Dependencies)
+	})
+
+	t.Run("module with dependencies", func(t *testing.T) {
+		run, err := f.newPlan(ws.ID, CreateOptions{Destroy: true})
+		require.NoError(t, err)
+		dep := resource.NewMonotonicID(resource.Module)
+		run.moduleDependencies = []resource.ID{dep}
+
+		spec, err := run.applyTaskSpec()
+		require.NoError(t, err)
+		want := &task.Dependencies{ModuleIDs: []resource.ID{dep}, InverseDependencyOrder: true}
+		assert.Equal(t, want, spec.Dependencies)
+	})
+
+	t.Run("terragrunt", func(t *testing.T) {
+		run, err := f.newPlan(ws.ID, CreateOptions{})
+		require.NoError(t, err)
+		run.terragrunt = true
+
+		spec, err := run.applyTaskSpec()
+		require.NoError(t, err)
+		assert.Equal(t, &task.Dependencies{}, spec.Dependencies)
+	})
+}
+
 func setupTest(t *testing.T) (*factory, *module.Module, *workspace.Workspace) {
 	workdir := internal.NewTestWorkdir(t)
 	testutils.ChTempDir(t, workdir.String())
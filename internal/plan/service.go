@@ -3,6 +3,7 @@ package plan
 import (
 	"errors"
 	"fmt"
+	"os"
 
 	"github.com/leg100/pug/internal"
 	"github.com/leg100/pug/internal/logging"
@@ -36,8 +37,13 @@ type ServiceOptions struct {
 	Workdir    internal.Workdir
 	Logger     logging.Interface
 	Terragrunt bool
+	// Events configures the brokers that publish the service's events.
+	Events []pubsub.Option
 }
 
+// taskIndex indexes plans by the ID of their plan task.
+const taskIndex = "task"
+
 type moduleGetter interface {
 	Get(moduleID resource.ID) (*module.Module, error)
 }
@@ -47,9 +53,16 @@ type workspaceGetter interface {
 }
 
 func NewService(opts ServiceOptions) *Service {
-	broker := pubsub.NewBroker[*plan](opts.Logger)
-	return &Service{
-		table:      resource.NewTable(broker),
+	broker := pubsub.NewBroker[*plan](opts.Logger, opts.Events...)
+	svc := &Service{
+		table: resource.NewTable(broker,
+			resource.WithIndex(taskIndex, func(plan *plan) []any {
+				if plan.taskID == nil {
+					return nil
+				}
+				return []any{plan.taskID}
+			}),
+		),
 		Broker:     broker,
 		tasks:      opts.Tasks,
 		modules:    opts.Modules,
@@ -65,6 +78,43 @@ func NewService(opts ServiceOptions) *Service {
 			terragrunt: opts.Terragrunt,
 		},
 	}
+	// Don't let a plan task be pruned while its plan is yet to be applied.
+	opts.Tasks.Protect(svc.isUnapplied)
+	return svc
+}
+
+// isUnapplied is true if the task is a plan task with changes yet to be
+// applied.
+func (s *Service) isUnapplied(t *task.Task) bool {
+	if t.Identifier != PlanTask || t.State != task.Exited {
+		return false
+	}
+	plan, err := s.getByTaskID(t.ID)
+	if err != nil {
+		return false
+	}
+	return plan.unapplied()
+}
+
+// DeleteWithTask deletes a plan along with its artefacts whenever its plan task
+// is deleted.
+func (s *Service) DeleteWithTask(sub <-chan resource.Event[*task.Task]) {
+	for event := range sub {
+		if event.Type != resource.DeletedEvent || event.Payload == nil {
+			continue
+		}
+		plan, err := s.getByTaskID(event.Payload.ID)
+		if err != nil {
+			continue
+		}
+		s.table.Delete(plan.ID)
+		if plan.ArtefactsPath != "" {
+			if err := os.RemoveAll(plan.ArtefactsPath); err != nil {
+				s.logger.Error("removing plan artefacts", "error", err, "path", plan.ArtefactsPath)
+			}
+		}
+		s.logger.Debug("deleted plan along with its task", "plan", plan.ID)
+	}
 }
 
 // ReloadAfterApply creates a state reload task whenever an apply task
@@ -101,19 +151,25 @@ func (s *Service) Plan(workspaceID resource.ID, opts CreateOptions) (task.Spec,
 		s.logger.Error("creating plan spec", "error", err)
 		return task.Spec{}, err
 	}
-	s.table.Add(plan.ID, plan)
 
-	return plan.planTaskSpec(), nil
+	spec := plan.planTaskSpec()
+	// Only record the plan once its task is created, so that a spec that is
+	// discarded, e.g. as part of an aborted preview, leaves no plan behind.
+	spec.AfterCreate = func(t *task.Task) {
+		plan.taskID = t.ID
+		s.table.Add(plan.ID, plan)
+	}
+	return spec, nil
 }
 
-// Apply creates a task spec to auto-apply a plan, i.e. `terraform apply`. To
-// apply an existing plan, see ApplyPlan.
+// Apply creates a task spec to auto-apply a plan, i.e. `terraform apply`. No
+// plan is recorded. To apply an existing plan, see ApplyPlan.
 func (s *Service) Apply(workspaceID resource.ID, opts CreateOptions) (task.Spec, error) {
 	plan, err := s.newPlan(workspaceID, opts)
 	if err != nil {
 		return task.Spec{}, err
 	}
-	return plan.applyTaskSpec()
+	return s.withSnapshot(plan.applyTaskSpec())
 }
 
 // ApplyPlan creates a task spec to apply an existing plan, i.e. `terraform
@@ -131,7 +187,15 @@ func (s *Service) ApplyPlan(taskID resource.ID) (task.Spec, error) {
 	if err != nil {
 		return task.Spec{}, err
 	}
-	return plan.applyTaskSpec()
+	return s.withSnapshot(plan.applyTaskSpec())
+}
+
+// withSnapshot configures an apply task spec to first snapshot the state.
+func (s *Service) withSnapshot(spec task.Spec, err error) (task.Spec, error) {
+	if err != nil {
+		return task.Spec{}, err
+	}
+	return s.states.WithSnapshot(spec)
 }
 
 func IsApplyable(t *task.Task) error {
@@ -149,12 +213,11 @@ func (s *Service) Get(runID resource.ID) (*plan, error) {
 }
 
 func (s *Service) getByTaskID(taskID resource.ID) (*plan, error) {
-	for _, plan := range s.List() {
-		if plan.taskID != nil && plan.taskID == taskID {
-			return plan, nil
-		}
+	plan, err := s.table.GetBy(taskIndex, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("task is not associated with a plan: %w", resource.ErrNotFound)
 	}
-	return nil, fmt.Errorf("task is not associated with a plan: %w", resource.ErrNotFound)
+	return plan, nil
 }
 
 func (s *Service) List() []*plan {
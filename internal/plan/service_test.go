@@ -0,0 +1,37 @@
+package plan
+
+import (
+	"testing"
+
+	"github.com/leg100/pug/internal/logging"
+	"github.com/leg100/pug/internal/task"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_Plan(t *testing.T) {
+	f, _, ws := setupTest(t)
+	tasks := task.NewService(task.ServiceOptions{Program: "terraform", Logger: logging.Discard})
+	svc := NewService(ServiceOptions{Tasks: tasks, Logger: logging.Discard})
+	svc.factory = f
+
+	spec, err := svc.Plan(ws.ID, CreateOptions{})
+	require.NoError(t, err)
+
+	// The plan is not recorded until its task is created.
+	assert.Empty(t, svc.List())
+
+	// Previewing the task does not record the plan either.
+	_, err = tasks.PreviewGroup(spec)
+	require.NoError(t, err)
+	assert.Empty(t, svc.List())
+
+	planTask, err := tasks.Create(spec)
+	require.NoError(t, err)
+
+	if assert.Len(t, svc.List(), 1) {
+		got, err := svc.getByTaskID(planTask.ID)
+		require.NoError(t, err)
+		assert.Equal(t, svc.List()[0], got)
+	}
+}
@@ -2,12 +2,17 @@ package pubsub
 
 import (
 	"context"
+	"reflect"
+	"slices"
 	"sync"
+	"sync/atomic"
 
 	"github.com/leg100/pug/internal/resource"
 )
 
-const bufferSize = 1024 * 1024
+// DefaultMaxQueue is the default maximum number of events queued for a
+// subscriber before the overflow policy is applied.
+const DefaultMaxQueue = 1024 * 1024
 
 type Logger interface {
 	Debug(msg string, args ...any)
@@ -16,20 +21,97 @@ type Logger interface {
 	Error(msg string, args ...any)
 }
 
-// Broker allows clients to publish events and subscribe to events
+// OverflowPolicy determines what happens when an updated event is published to
+// a subscriber whose queue is full. Created and deleted events are never
+// dropped, and are queued regardless of the size of the queue.
+type OverflowPolicy int
+
+const (
+	// DropOldest drops the oldest queued updated event to make room for the
+	// new event. If there is no queued updated event then the new event is
+	// dropped.
+	DropOldest OverflowPolicy = iota
+	// DropNewest drops the new event.
+	DropNewest
+)
+
+// OverflowPolicies maps the names of overflow policies to policies.
+var OverflowPolicies = map[string]OverflowPolicy{
+	"drop-oldest": DropOldest,
+	"drop-newest": DropNewest,
+}
+
+// Option configures a broker.
+type Option func(*config)
+
+type config struct {
+	maxQueue int
+	overflow OverflowPolicy
+}
+
+// WithMaxQueue sets the maximum number of events queued for each subscriber.
+// If n is zero or less then DefaultMaxQueue is used.
+func WithMaxQueue(n int) Option {
+	return func(c *config) {
+		if n > 0 {
+			c.maxQueue = n
+		}
+	}
+}
+
+// WithOverflowPolicy sets the policy to apply when a subscriber's queue is
+// full.
+func WithOverflowPolicy(policy OverflowPolicy) Option {
+	return func(c *config) {
+		c.overflow = policy
+	}
+}
+
+// Metrics are counts of events handled by a broker.
+type Metrics struct {
+	// Published is the number of events published.
+	Published uint64
+	// Delivered is the number of events received by subscribers.
+	Delivered uint64
+	// Coalesced is the number of events merged into an event already queued
+	// for a subscriber.
+	Coalesced uint64
+	// Dropped is the number of events dropped because a subscriber's queue was
+	// full.
+	Dropped uint64
+}
+
+// Broker allows clients to publish events and subscribe to events.
+//
+// Publishing never blocks on subscribers: each subscriber has its own queue of
+// events, from which events are delivered to the subscriber in the order in
+// which they were published. A subscriber that falls behind accumulates
+// events in its queue, and when an updated event is published for a resource
+// that already has an updated event in the queue, the two are coalesced into
+// one. Should the queue nonetheless fill up then the overflow policy is
+// applied to updated events.
 type Broker[T any] struct {
-	subs   map[chan resource.Event[T]]struct{} // subscriptions
-	mu     sync.Mutex                          // sync access to map
-	done   chan struct{}                       // close when broker is shutting down
+	subs   map[*subscriber[T]]struct{} // subscriptions
+	mu     sync.Mutex                  // sync access to map
+	done   chan struct{}               // close when broker is shutting down
 	logger Logger
+	config config
+
+	published atomic.Uint64
+	metrics   *subscriberMetrics
 }
 
 // NewBroker constructs a pub/sub broker.
-func NewBroker[T any](logger Logger) *Broker[T] {
+func NewBroker[T any](logger Logger, opts ...Option) *Broker[T] {
 	b := &Broker[T]{
-		subs:   make(map[chan resource.Event[T]]struct{}),
-		done:   make(chan struct{}),
-		logger: logger,
+		subs:    make(map[*subscriber[T]]struct{}),
+		done:    make(chan struct{}),
+		logger:  logger,
+		config:  config{maxQueue: DefaultMaxQueue},
+		metrics: &subscriberMetrics{},
+	}
+	for _, fn := range opts {
+		fn(&b.config)
 	}
 	return b
 }
@@ -42,16 +124,17 @@ func (b *Broker[T]) Shutdown() {
 	defer b.mu.Unlock()
 
 	// Remove each subscriber entry, so Publish() cannot send any further
-	// messages, and close each subscriber's channel, so the subscriber cannot
-	// consume any more messages.
-	for ch := range b.subs {
-		delete(b.subs, ch)
-		close(ch)
+	// messages, and stop each subscriber, which closes its channel once the
+	// events already queued have been delivered.
+	for sub := range b.subs {
+		delete(b.subs, sub)
+		close(sub.stop)
 	}
 }
 
 // Subscribe subscribes the caller to a stream of events. The returned channel
-// is closed when the broker is shutdown.
+// is closed when the broker is shutdown, after any events already queued for
+// the subscriber have been received, or when the context is done.
 func (b *Broker[T]) Subscribe(ctx context.Context) <-chan resource.Event[T] {
 	b.mu.Lock()
 	defer b.mu.Unlock()
@@ -66,8 +149,9 @@ func (b *Broker[T]) Subscribe(ctx context.Context) <-chan resource.Event[T] {
 	}
 
 	// Subscribe
-	sub := make(chan resource.Event[T], bufferSize)
+	sub := newSubscriber[T](ctx, b.config, b.metrics)
 	b.subs[sub] = struct{}{}
+	go sub.run()
 
 	// Unsubscribe when context is done.
 	go func() {
@@ -84,10 +168,10 @@ func (b *Broker[T]) Subscribe(ctx context.Context) <-chan resource.Event[T] {
 		}
 
 		delete(b.subs, sub)
-		close(sub)
+		close(sub.stop)
 	}()
 
-	return sub
+	return sub.ch
 }
 
 // Publish an event to subscribers.
@@ -95,10 +179,175 @@ func (b *Broker[T]) Publish(t resource.EventType, payload T) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
+	b.published.Add(1)
 	for sub := range b.subs {
+		sub.enqueue(resource.Event[T]{Type: t, Payload: payload})
+	}
+}
+
+// Metrics returns counts of events handled by the broker.
+func (b *Broker[T]) Metrics() Metrics {
+	return Metrics{
+		Published: b.published.Load(),
+		Delivered: b.metrics.delivered.Load(),
+		Coalesced: b.metrics.coalesced.Load(),
+		Dropped:   b.metrics.dropped.Load(),
+	}
+}
+
+type subscriberMetrics struct {
+	delivered atomic.Uint64
+	coalesced atomic.Uint64
+	dropped   atomic.Uint64
+}
+
+// subscriber queues events for delivery to a subscriber.
+type subscriber[T any] struct {
+	// ch is the channel on which events are delivered.
+	ch chan resource.Event[T]
+	// stop is closed to stop queueing events. Events already queued are
+	// delivered before ch is closed.
+	stop chan struct{}
+	// ctx is the subscriber's context. Once it is done no further events are
+	// delivered.
+	ctx context.Context
+	// wake is signalled when an event is queued.
+	wake chan struct{}
+
+	config  config
+	metrics *subscriberMetrics
+
+	// queue of events waiting to be delivered
+	queue []*queued[T]
+	// updates maps resource IDs to their updated event in the queue, for the
+	// purposes of coalescing updated events.
+	updates map[resource.ID]*queued[T]
+	mu      sync.Mutex
+}
+
+type queued[T any] struct {
+	event resource.Event[T]
+	// id is the ID of the event payload, or nil if it is not identifiable.
+	id resource.ID
+}
+
+func newSubscriber[T any](ctx context.Context, cfg config, metrics *subscriberMetrics) *subscriber[T] {
+	return &subscriber[T]{
+		ch:      make(chan resource.Event[T]),
+		stop:    make(chan struct{}),
+		ctx:     ctx,
+		wake:    make(chan struct{}, 1),
+		config:  cfg,
+		metrics: metrics,
+		updates: make(map[resource.ID]*queued[T]),
+	}
+}
+
+// enqueue queues an event for delivery. It never blocks.
+func (s *subscriber[T]) enqueue(event resource.Event[T]) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	q := &queued[T]{event: event, id: payloadID(event.Payload)}
+	if q.id != nil {
+		switch event.Type {
+		case resource.UpdatedEvent:
+			if existing, ok := s.updates[q.id]; ok {
+				// Coalesce with the queued updated event, retaining its
+				// position in the queue but with the latest payload.
+				existing.event = event
+				s.metrics.coalesced.Add(1)
+				return
+			}
+		case resource.DeletedEvent:
+			// Don't coalesce any subsequent update with an update preceding
+			// the deletion.
+			delete(s.updates, q.id)
+		}
+	}
+	if len(s.queue) >= s.config.maxQueue && event.Type == resource.UpdatedEvent {
+		// Only updated events are dropped: the subscriber relies upon
+		// receiving created and deleted events to track resources.
+		s.metrics.dropped.Add(1)
+		if s.config.overflow == DropNewest {
+			return
+		}
+		i := slices.IndexFunc(s.queue, func(q *queued[T]) bool {
+			return q.event.Type == resource.UpdatedEvent
+		})
+		if i < 0 {
+			return
+		}
+		s.forget(s.queue[i])
+		s.queue = slices.Delete(s.queue, i, i+1)
+	}
+	s.queue = append(s.queue, q)
+	if q.id != nil && event.Type == resource.UpdatedEvent {
+		s.updates[q.id] = q
+	}
+	// Wake up delivery, unless it has already been woken.
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// next removes and returns the event at the front of the queue.
+func (s *subscriber[T]) next() (resource.Event[T], bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.queue) == 0 {
+		return resource.Event[T]{}, false
+	}
+	q := s.queue[0]
+	s.queue[0] = nil
+	s.queue = s.queue[1:]
+	s.forget(q)
+	return q.event, true
+}
+
+// forget removes a queued event from the updates map. The caller must hold
+// the lock.
+func (s *subscriber[T]) forget(q *queued[T]) {
+	if q.id != nil && s.updates[q.id] == q {
+		delete(s.updates, q.id)
+	}
+}
+
+// payloadID returns the ID of an event payload, or nil if the payload is not
+// identifiable.
+func payloadID(payload any) resource.ID {
+	identifiable, ok := payload.(resource.Identifiable)
+	if !ok {
+		return nil
+	}
+	// A deleted event for a non-existent row has a nil payload.
+	if v := reflect.ValueOf(payload); v.Kind() == reflect.Pointer && v.IsNil() {
+		return nil
+	}
+	return identifiable.GetID()
+}
+
+// run delivers queued events to the subscriber until stopped and the queue has
+// been drained, or until the subscriber's context is done.
+func (s *subscriber[T]) run() {
+	defer close(s.ch)
+
+	for {
+		event, ok := s.next()
+		if !ok {
+			select {
+			case <-s.wake:
+				continue
+			case <-s.stop:
+				return
+			}
+		}
 		select {
-		case sub <- resource.Event[T]{Type: t, Payload: payload}:
-		case <-b.done:
+		case s.ch <- event:
+			s.metrics.delivered.Add(1)
+		case <-s.ctx.Done():
 			return
 		}
 	}
@@ -0,0 +1,248 @@
+package pubsub
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/leg100/pug/internal/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testPayload struct {
+	ID    resource.MonotonicID
+	Value int
+}
+
+func (p *testPayload) GetID() resource.ID { return p.ID }
+
+type discard struct{}
+
+func (discard) Debug(msg string, args ...any) {}
+func (discard) Info(msg string, args ...any)  {}
+func (discard) Warn(msg string, args ...any)  {}
+func (discard) Error(msg string, args ...any) {}
+
+func TestBroker(t *testing.T) {
+	broker := NewBroker[*testPayload](discard{})
+	sub := broker.Subscribe(context.Background())
+
+	payload := &testPayload{ID: resource.NewMonotonicID(resource.Task)}
+	broker.Publish(resource.CreatedEvent, payload)
+
+	got := receive(t, sub)
+	assert.Equal(t, resource.CreatedEvent, got.Type)
+	assert.Equal(t, payload, got.Payload)
+
+	broker.Shutdown()
+
+	// Channel should be closed upon shutdown.
+	_, ok := <-sub
+	assert.False(t, ok)
+	// Subscribing after shutdown should return a closed channel.
+	_, ok = <-broker.Subscribe(context.Background())
+	assert.False(t, ok)
+}
+
+func TestBroker_DrainOnShutdown(t *testing.T) {
+	broker := NewBroker[*testPayload](discard{})
+	sub := broker.Subscribe(context.Background())
+
+	for i := range 3 {
+		broker.Publish(resource.CreatedEvent, &testPayload{ID: resource.NewMonotonicID(resource.Task), Value: i})
+	}
+	broker.Shutdown()
+
+	// Events queued before shutdown should still be received before the
+	// channel is closed.
+	for i := range 3 {
+		assert.Equal(t, i, receive(t, sub).Payload.Value)
+	}
+	_, ok := <-sub
+	assert.False(t, ok)
+}
+
+func TestBroker_Unsubscribe(t *testing.T) {
+	broker := NewBroker[*testPayload](discard{})
+	ctx, cancel := context.WithCancel(context.Background())
+	sub := broker.Subscribe(ctx)
+
+	cancel()
+
+	select {
+	case _, ok := <-sub:
+		assert.False(t, ok)
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for channel to be closed")
+	}
+}
+
+func TestBroker_SlowSubscriber(t *testing.T) {
+	broker := NewBroker[*testPayload](discard{})
+	// Subscriber that never receives
+	_ = broker.Subscribe(context.Background())
+	fast := broker.Subscribe(context.Background())
+
+	// Publishing should not block despite the slow subscriber.
+	published := make(chan struct{})
+	go func() {
+		for range 1000 {
+			broker.Publish(resource.CreatedEvent, &testPayload{ID: resource.NewMonotonicID(resource.Task)})
+		}
+		close(published)
+	}()
+	select {
+	case <-published:
+	case <-time.After(time.Second):
+		t.Fatal("publishing blocked on slow subscriber")
+	}
+
+	// Fast subscriber should receive every event.
+	for range 1000 {
+		receive(t, fast)
+	}
+	assert.Equal(t, uint64(1000), broker.Metrics().Published)
+}
+
+func TestBroker_Coalesce(t *testing.T) {
+	broker := NewBroker[*testPayload](discard{})
+	sub := broker.Subscribe(context.Background())
+
+	a := &testPayload{ID: resource.NewMonotonicID(resource.Task)}
+	b := &testPayload{ID: resource.NewMonotonicID(resource.Task)}
+
+	broker.Publish(resource.CreatedEvent, a)
+	for i := range 10 {
+		broker.Publish(resource.UpdatedEvent, &testPayload{ID: a.ID, Value: i})
+	}
+	broker.Publish(resource.CreatedEvent, b)
+
+	// Expect created event for a, followed by a single updated event for a,
+	// with the latest payload, followed by created event for b.
+	got := receive(t, sub)
+	assert.Equal(t, resource.CreatedEvent, got.Type)
+	assert.Equal(t, a, got.Payload)
+
+	got = receive(t, sub)
+	assert.Equal(t, resource.UpdatedEvent, got.Type)
+	assert.Equal(t, a.ID, got.Payload.ID)
+	assert.Equal(t, 9, got.Payload.Value)
+
+	got = receive(t, sub)
+	assert.Equal(t, resource.CreatedEvent, got.Type)
+	assert.Equal(t, b, got.Payload)
+
+	assert.Equal(t, uint64(9), broker.Metrics().Coalesced)
+}
+
+func TestSubscriber(t *testing.T) {
+	newEvent := func(typ resource.EventType, id resource.MonotonicID, value int) resource.Event[*testPayload] {
+		return resource.Event[*testPayload]{Type: typ, Payload: &testPayload{ID: id, Value: value}}
+	}
+	values := func(sub *subscriber[*testPayload]) (got []int) {
+		for {
+			event, ok := sub.next()
+			if !ok {
+				return got
+			}
+			got = append(got, event.Payload.Value)
+		}
+	}
+
+	t.Run("drop oldest", func(t *testing.T) {
+		metrics := &subscriberMetrics{}
+		sub := newSubscriber[*testPayload](context.Background(), config{maxQueue: 3, overflow: DropOldest}, metrics)
+		sub.enqueue(newEvent(resource.CreatedEvent, resource.NewMonotonicID(resource.Task), 0))
+		for i := range 4 {
+			sub.enqueue(newEvent(resource.UpdatedEvent, resource.NewMonotonicID(resource.Task), i+1))
+		}
+		assert.Equal(t, []int{0, 3, 4}, values(sub))
+		assert.Equal(t, uint64(2), metrics.dropped.Load())
+	})
+
+	t.Run("drop newest", func(t *testing.T) {
+		metrics := &subscriberMetrics{}
+		sub := newSubscriber[*testPayload](context.Background(), config{maxQueue: 2, overflow: DropNewest}, metrics)
+		for i := range 4 {
+			sub.enqueue(newEvent(resource.UpdatedEvent, resource.NewMonotonicID(resource.Task), i))
+		}
+		assert.Equal(t, []int{0, 1}, values(sub))
+		assert.Equal(t, uint64(2), metrics.dropped.Load())
+	})
+
+	t.Run("never drop lifecycle events", func(t *testing.T) {
+		for _, policy := range []OverflowPolicy{DropOldest, DropNewest} {
+			metrics := &subscriberMetrics{}
+			sub := newSubscriber[*testPayload](context.Background(), config{maxQueue: 2, overflow: policy}, metrics)
+			sub.enqueue(newEvent(resource.CreatedEvent, resource.NewMonotonicID(resource.Task), 0))
+			sub.enqueue(newEvent(resource.CreatedEvent, resource.NewMonotonicID(resource.Task), 1))
+			sub.enqueue(newEvent(resource.UpdatedEvent, resource.NewMonotonicID(resource.Task), 2))
+			sub.enqueue(newEvent(resource.DeletedEvent, resource.NewMonotonicID(resource.Task), 3))
+			assert.Equal(t, []int{0, 1, 3}, values(sub))
+			assert.Equal(t, uint64(1), metrics.dropped.Load())
+		}
+	})
+
+	t.Run("coalesce within full queue", func(t *testing.T) {
+		metrics := &subscriberMetrics{}
+		sub := newSubscriber[*testPayload](context.Background(), config{maxQueue: 2}, metrics)
+		id := resource.NewMonotonicID(resource.Task)
+		sub.enqueue(newEvent(resource.CreatedEvent, id, 0))
+		sub.enqueue(newEvent(resource.UpdatedEvent, id, 1))
+		sub.enqueue(newEvent(resource.UpdatedEvent, id, 2))
+		assert.Equal(t, []int{0, 2}, values(sub))
+		assert.Equal(t, uint64(0), metrics.dropped.Load())
+		assert.Equal(t, uint64(1), metrics.coalesced.Load())
+	})
+
+	t.Run("do not coalesce across deletion", func(t *testing.T) {
+		metrics := &subscriberMetrics{}
+		sub := newSubscriber[*testPayload](context.Background(), config{maxQueue: 10}, metrics)
+		id := resource.NewMonotonicID(resource.Task)
+		sub.enqueue(newEvent(resource.UpdatedEvent, id, 0))
+		sub.enqueue(newEvent(resource.DeletedEvent, id, 1))
+		sub.enqueue(newEvent(resource.UpdatedEvent, id, 2))
+		assert.Equal(t, []int{0, 1, 2}, values(sub))
+	})
+
+	t.Run("do not coalesce delivered event", func(t *testing.T) {
+		metrics := &subscriberMetrics{}
+		sub := newSubscriber[*testPayload](context.Background(), config{maxQueue: 10}, metrics)
+		id := resource.NewMonotonicID(resource.Task)
+		sub.enqueue(newEvent(resource.UpdatedEvent, id, 0))
+		assert.Equal(t, []int{0}, values(sub))
+		sub.enqueue(newEvent(resource.UpdatedEvent, id, 1))
+		assert.Equal(t, []int{1}, values(sub))
+	})
+
+	t.Run("nil payload", func(t *testing.T) {
+		sub := newSubscriber[*testPayload](context.Background(), config{maxQueue: 10}, &subscriberMetrics{})
+		sub.enqueue(resource.Event[*testPayload]{Type: resource.DeletedEvent})
+		event, ok := sub.next()
+		require.True(t, ok)
+		assert.Nil(t, event.Payload)
+	})
+}
+
+func receive[T any](t *testing.T, sub <-chan resource.Event[T]) resource.Event[T] {
+	t.Helper()
+
+	select {
+	case event, ok := <-sub:
+		require.True(t, ok, "channel unexpectedly closed")
+		return event
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for event")
+	}
+	return resource.Event[T]{}
+}
+
+func TestBroker_Options(t *testing.T) {
+	broker := NewBroker[*testPayload](discard{}, WithMaxQueue(2), WithOverflowPolicy(DropNewest))
+	assert.Equal(t, config{maxQueue: 2, overflow: DropNewest}, broker.config)
+
+	// Zero max queue uses the default.
+	broker = NewBroker[*testPayload](discard{}, WithMaxQueue(0))
+	assert.Equal(t, DefaultMaxQueue, broker.config.maxQueue)
+}
@@ -2,6 +2,7 @@ package resource
 
 import (
 	"fmt"
+	"slices"
 	"sync"
 
 	"golang.org/x/exp/maps"
@@ -12,14 +13,55 @@ type Table[T any] struct {
 	rows map[ID]T
 	mu   sync.RWMutex
 
+	// indexes are secondary indexes, keyed by name.
+	indexes map[string]*index[T]
+	// order, if non-nil, orders rows returned by List.
+	order func(T, T) int
+	// list is a cached listing of rows, invalidated upon changes.
+	list  []T
+	stale bool
+
 	pub Publisher[T]
 }
 
-func NewTable[T any](pub Publisher[T]) *Table[T] {
-	return &Table[T]{
-		rows: make(map[ID]T),
-		pub:  pub,
+// TableOption configures a table.
+type TableOption[T any] func(t *Table[T])
+
+// IndexFunc returns the keys with which to index a row. A row can be indexed
+// by zero or more keys. Keys must be comparable.
+type IndexFunc[T any] func(row T) []any
+
+// WithIndex adds a secondary index to the table. The index is maintained
+// whenever a row is added, updated, or deleted, and rows can then be retrieved
+// using GetBy and ListBy.
+func WithIndex[T any](name string, fn IndexFunc[T]) TableOption[T] {
+	return func(t *Table[T]) {
+		t.indexes[name] = &index[T]{
+			fn:   fn,
+			rows: make(map[any]map[ID]T),
+			keys: make(map[ID][]any),
+		}
+	}
+}
+
+// WithOrder orders the rows returned by List, ListBy and GetBy.
+func WithOrder[T any](cmp func(T, T) int) TableOption[T] {
+	return func(t *Table[T]) {
+		t.order = cmp
+	}
+}
+
+func NewTable[T any](pub Publisher[T], opts ...TableOption[T]) *Table[T] {
+	t := &Table[T]{
+		rows:    make(map[ID]T),
+		indexes: make(map[string]*index[T]),
+		stale:   true,
+		pub:     pub,
+	}
+	for _, fn := range opts {
+		fn(t)
 	}
+	return t
 }
 
 func (t *Table[T]) Add(id ID, row T) {
@@ -27,6 +69,7 @@ func (t *Table[T]) Add(id ID, row T) {
 	defer t.mu.Unlock()
 
 	t.rows[id] = row
+	t.reindex(id, row)
 	t.pub.Publish(CreatedEvent, row)
 }
 
@@ -43,6 +86,7 @@ func (t *Table[T]) Update(id ID, updater func(existing T) error) (T, error) {
 		return *new(T), err
 	}
 	t.rows[id] = row
+	t.reindex(id, row)
 
 	t.pub.Publish(UpdatedEvent, row)
 	return row, nil
@@ -54,6 +98,10 @@ func (t *Table[T]) Delete(id ID) {
 
 	row := t.rows[id]
 	delete(t.rows, id)
+	for _, idx := range t.indexes {
+		idx.remove(id)
+	}
+	t.stale = true
 	t.pub.Publish(DeletedEvent, row)
 }
 
@@ -68,9 +116,97 @@ func (t *Table[T]) Get(id ID) (T, error) {
 	return row, nil
 }
 
-func (t *Table[T]) List() []T {
+// GetBy retrieves a row using a secondary index. If more than one row is
+// indexed with the key then the first row is returned, according to the
+// table's order.
+func (t *Table[T]) GetBy(index string, key any) (T, error) {
+	rows, err := t.ListBy(index, key)
+	if err != nil {
+		return *new(T), err
+	}
+	if len(rows) == 0 {
+		return *new(T), fmt.Errorf("%s: %v: %w", index, key, ErrNotFound)
+	}
+	return rows[0], nil
+}
+
+// ListBy lists rows using a secondary index.
+func (t *Table[T]) ListBy(index string, key any) ([]T, error) {
 	t.mu.RLock()
 	defer t.mu.RUnlock()
 
-	return maps.Values(t.rows)
+	idx, ok := t.indexes[index]
+	if !ok {
+		return nil, fmt.Errorf("no such table index: %s", index)
+	}
+	rows := maps.Values(idx.rows[key])
+	if t.order != nil {
+		slices.SortFunc(rows, t.order)
+	}
+	return rows, nil
+}
+
+func (t *Table[T]) List() []T {
+	t.mu.RLock()
+	if !t.stale {
+		defer t.mu.RUnlock()
+		return slices.Clone(t.list)
+	}
+	t.mu.RUnlock()
+
+	// Listing is stale so rebuild it, which requires the write lock.
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.stale {
+		t.list = maps.Values(t.rows)
+		if t.order != nil {
+			slices.SortFunc(t.list, t.order)
+		}
+		t.stale = false
+	}
+	return slices.Clone(t.list)
+}
+
+// reindex updates the secondary indexes for a row, and invalidates the
+// listing. The caller must hold the write lock.
+func (t *Table[T]) reindex(id ID, row T) {
+	for _, idx := range t.indexes {
+		idx.remove(id)
+		idx.add(id, row)
+	}
+	t.stale = true
+}
+
+// index is a secondary index of a table.
+type index[T any] struct {
+	fn IndexFunc[T]
+	// rows maps keys to the rows indexed with the key.
+	rows map[any]map[ID]T
+	// keys maps row IDs to the keys with which they were last indexed, so that
+	// a row can be removed from the index even after it has been mutated.
+	keys map[ID][]any
+}
+
+func (idx *index[T]) add(id ID, row T) {
+	keys := idx.fn(row)
+	for _, key := range keys {
+		rows, ok := idx.rows[key]
+		if !ok {
+			rows = make(map[ID]T)
+			idx.rows[key] = rows
+		}
+		rows[id] = row
+	}
+	idx.keys[id] = keys
+}
+
+func (idx *index[T]) remove(id ID) {
+	for _, key := range idx.keys[id] {
+		delete(idx.rows[key], id)
+		if len(idx.rows[key]) == 0 {
+			delete(idx.rows, key)
+		}
+	}
+	delete(idx.keys, id)
 }
@@ -0,0 +1,183 @@
+package resource
+
+import (
+	"cmp"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testRow struct {
+	ID    MonotonicID
+	Name  string
+	Group string
+	Tags  []string
+}
+
+type fakePublisher[T any] struct{}
+
+func (fakePublisher[T]) Publish(EventType, T) {}
+
+func newTestTable() *Table[*testRow] {
+	return NewTable[*testRow](fakePublisher[*testRow]{},
+		WithIndex("name", func(row *testRow) []any {
+			return []any{row.Name}
+		}),
+		WithIndex("tag", func(row *testRow) []any {
+			keys := make([]any, len(row.Tags))
+			for i, tag := range row.Tags {
+				keys[i] = tag
+			}
+			return keys
+		}),
+		WithOrder(func(i, j *testRow) int {
+			return cmp.Compare(i.Name, j.Name)
+		}),
+	)
+}
+
+func TestTable(t *testing.T) {
+	tbl := newTestTable()
+
+	add := func(name string, tags ...string) *testRow {
+		row := &testRow{ID: NewMonotonicID(Module), Name: name, Tags: tags}
+		tbl.Add(row.ID, row)
+		return row
+	}
+	listBy := func(t *testing.T, tag string) []*testRow {
+		rows, err := tbl.ListBy("tag", tag)
+		require.NoError(t, err)
+		return rows
+	}
+	c := add("c", "red")
+	a := add("a", "red", "blue")
+	b := add("b")
+
+	t.Run("ordered list", func(t *testing.T) {
+		assert.Equal(t, []*testRow{a, b, c}, tbl.List())
+	})
+
+	t.Run("get by index", func(t *testing.T) {
+		got, err := tbl.GetBy("name", "b")
+		require.NoError(t, err)
+		assert.Equal(t, b, got)
+	})
+
+	t.Run("get by index not found", func(t *testing.T) {
+		_, err := tbl.GetBy("name", "d")
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("list by index with multiple keys", func(t *testing.T) {
+		assert.Equal(t, []*testRow{a, c}, listBy(t, "red"))
+		assert.Equal(t, []*testRow{a}, listBy(t, "blue"))
+		assert.Empty(t, listBy(t, "green"))
+	})
+
+	t.Run("unknown index", func(t *testing.T) {
+		_, err := tbl.ListBy("colour", "red")
+		assert.EqualError(t, err, "no such table index: colour")
+
+		_, err = tbl.GetBy("colour", "red")
+		assert.Error(t, err)
+	})
+
+	t.Run("update reindexes row", func(t *testing.T) {
+		_, err := tbl.Update(b.ID, func(existing *testRow) error {
+			existing.Name = "z"
+			existing.Tags = []string{"green"}
+			return nil
+		})
+		require.NoError(t, err)
+
+		_, err = tbl.GetBy("name", "b")
+		assert.ErrorIs(t, err, ErrNotFound)
+
+		got, err := tbl.GetBy("name", "z")
+		require.NoError(t, err)
+		assert.Equal(t, b, got)
+
+		assert.Equal(t, []*testRow{b}, listBy(t, "green"))
+		assert.Equal(t, []*testRow{a, c, b}, tbl.List())
+	})
+
+	t.Run("delete removes row from indexes", func(t *testing.T) {
+		tbl.Delete(a.ID)
+
+		_, err := tbl.GetBy("name", "a")
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.Equal(t, []*testRow{c}, listBy(t, "red"))
+		assert.Empty(t, listBy(t, "blue"))
+		assert.Equal(t, []*testRow{c, b}, tbl.List())
+	})
+
+	t.Run("list returns copy", func(t *testing.T) {
+		list := tbl.List()
+		list[0] = nil
+		assert.Equal(t, []*testRow{c, b}, tbl.List())
+	})
+}
+
+// populateTestTable adds n rows to a table, akin to a working directory
+// containing n modules.
+func populateTestTable(n int) *Table[*testRow] {
+	tbl := newTestTable()
+	for i := range n {
+		row := &testRow{
+			ID:   NewMonotonicID(Module),
+			Name: fmt.Sprintf("modules/module-%d", i),
+		}
+		tbl.Add(row.ID, row)
+	}
+	return tbl
+}
+
+// BenchmarkTable_GetByScan retrieves a row by scanning a listing of all rows,
+// which is how rows were retrieved by anything other than their ID before
+// secondary indexes.
+func BenchmarkTable_GetByScan(b *testing.B) {
+	tbl := populateTestTable(800)
+	b.ResetTimer()
+	for i := range b.N {
+		name := fmt.Sprintf("modules/module-%d", i%800)
+		for _, row := range tbl.rowsScan() {
+			if row.Name == name {
+				break
+			}
+		}
+	}
+}
+
+func BenchmarkTable_GetByIndex(b *testing.B) {
+	tbl := populateTestTable(800)
+	b.ResetTimer()
+	for i := range b.N {
+		name := fmt.Sprintf("modules/module-%d", i%800)
+		if _, err := tbl.GetBy("name", name); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkTable_List(b *testing.B) {
+	tbl := populateTestTable(800)
+	b.ResetTimer()
+	for range b.N {
+		_ = tbl.List()
+	}
+}
+
+// rowsScan returns a copy of all rows in arbitrary order, as List did before
+// rows were cached and ordered.
+func (t *Table[T]) rowsScan() []T {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	rows := make([]T, 0, len(t.rows))
+	for _, row := range t.rows {
+		rows = append(rows, row)
+	}
+	return rows
+}
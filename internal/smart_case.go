@@ -0,0 +1,48 @@
+package internal
+
+import (
+	"regexp"
+	"unicode"
+)
+
+// CompileSmartCase compiles a regular expression that is case-insensitive
+// unless the pattern contains an upper case letter. Letters belonging to
+// escape sequences, e.g. \S or \p{Lu}, are ignored.
+func CompileSmartCase(pattern string) (*regexp.Regexp, error) {
+	if !hasUpper(pattern) {
+		pattern = "(?i)" + pattern
+	}
+	return regexp.Compile(pattern)
+}
+
+// hasUpper reports whether the pattern contains an upper case letter outside
+// of an escape sequence.
+func hasUpper(pattern string) bool {
+	runes := []rune(pattern)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '\\' {
+			if unicode.IsUpper(runes[i]) {
+				return true
+			}
+			continue
+		}
+		// Skip the escaped character
+		i++
+		if i >= len(runes) {
+			break
+		}
+		switch runes[i] {
+		case 'p', 'P', 'x':
+			// Skip the argument of a unicode class or hex code, which is
+			// either a single character or enclosed in braces.
+			if i+1 < len(runes) && runes[i+1] == '{' {
+				for i < len(runes) && runes[i] != '}' {
+					i++
+				}
+			} else if runes[i] != 'x' {
+				i++
+			}
+		}
+	}
+	return false
+}
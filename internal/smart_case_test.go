@@ -0,0 +1,34 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompileSmartCase(t *testing.T) {
+	tests := []struct {
+		pattern string
+		subject string
+		want    bool
+	}{
+		{"foo", "FOO", true},
+		{"Foo", "FOO", false},
+		{"Foo", "Foo", true},
+		{`foo\S`, "FOOD", true},
+		{`\Wfoo`, " FOO", true},
+		{`\d\D`, "1A", true},
+		{`\p{Lu}foo`, "AFOO", true},
+		{`\PLfoo`, "1FOO", true},
+		{`\x{41}b`, "AB", true},
+		{`\\Foo`, `\foo`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			re, err := CompileSmartCase(tt.pattern)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, re.MatchString(tt.subject))
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/leg100/pug/internal/resource"
+	"github.com/leg100/pug/internal/workspace"
+)
+
+// CompareID identifies a comparison between two workspaces.
+type CompareID struct {
+	A resource.ID
+	B resource.ID
+}
+
+// Comparison is a comparison of the state and variables of two workspaces.
+type Comparison struct {
+	A *workspace.Workspace
+	B *workspace.Workspace
+	// Resources are the differences between the state of workspace A and the
+	// state of workspace B: resources only in A are removed, and resources
+	// only in B are added.
+	Resources Diff
+	// Variables are the differences between the variable values of workspace
+	// A and the variable values of workspace B.
+	Variables []AttributeDiff
+	// Unloaded are the workspaces whose state has yet to be loaded, in which
+	// case their resources are not compared.
+	Unloaded []*workspace.Workspace
+}
+
+// Compare compares the state and variables of two workspaces. Resource
+// attributes matching the service's ignore rules are not compared. If the state
+// of either workspace has yet to be loaded then only variables are compared.
+func (s *Service) Compare(id CompareID) (*Comparison, error) {
+	var (
+		comparison Comparison
+		states     [2]*State
+		variables  [2]map[string]any
+	)
+	for i, workspaceID := range []resource.ID{id.A, id.B} {
+		ws, err := s.workspaces.Get(workspaceID)
+		if err != nil {
+			return nil, err
+		}
+		states[i], err = s.cache.Get(workspaceID)
+		if errors.Is(err, resource.ErrNotFound) {
+			comparison.Unloaded = append(comparison.Unloaded, ws)
+		} else if err != nil {
+			return nil, fmt.Errorf("retrieving state for workspace %s: %w", ws, err)
+		}
+		variables[i], err = ws.Variables(s.workdir)
+		if err != nil {
+			return nil, fmt.Errorf("retrieving variables for workspace %s: %w", ws, err)
+		}
+		if i == 0 {
+			comparison.A = ws
+		} else {
+			comparison.B = ws
+		}
+	}
+	if len(comparison.Unloaded) == 0 {
+		comparison.Resources = NewDiffIgnoring(states[0], states[1], s.compareIgnore)
+	}
+	comparison.Variables = DiffAttributes(variables[0], variables[1])
+	return &comparison, nil
+}
@@ -0,0 +1,185 @@
+package state
+
+import (
+	"cmp"
+	"fmt"
+	"path"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// Diff describes the differences between two states.
+type Diff struct {
+	// Added are the addresses of resources present only in the after state.
+	Added []ResourceAddress
+	// Removed are the addresses of resources present only in the before state.
+	Removed []ResourceAddress
+	// Changed are the resources present in both states but which differ.
+	Changed []ResourceDiff
+}
+
+// ResourceDiff describes the differences between two versions of a resource.
+type ResourceDiff struct {
+	Address       ResourceAddress
+	Attributes    []AttributeDiff
+	TaintedBefore bool
+	TaintedAfter  bool
+}
+
+// AttributeDiff describes a change to a resource attribute. Nested attributes
+// are flattened, with their path using dots for map keys and square brackets
+// for list indices, e.g. tags.Name or ingress[0].cidr_blocks[1]. Before or
+// After is nil if the attribute is absent or null.
+type AttributeDiff struct {
+	Path   string
+	Before any
+	After  any
+}
+
+// NewDiff returns the differences between two states. Either state may be nil,
+// in which case it is treated as having no resources.
+func NewDiff(before, after *State) Diff {
+	return NewDiffIgnoring(before, after, nil)
+}
+
+// NewDiffIgnoring returns the differences between two states, ignoring resource
+// attributes matching the ignore rules.
+func NewDiffIgnoring(before, after *State, ignore IgnoreRules) Diff {
+	var (
+		diff            Diff
+		beforeResources = resources(before)
+		afterResources  = resources(after)
+	)
+	for addr, a := range afterResources {
+		b, ok := beforeResources[addr]
+		if !ok {
+			diff.Added = append(diff.Added, addr)
+			continue
+		}
+		rdiff := ResourceDiff{
+			Address:       addr,
+			Attributes:    ignore.filter(DiffAttributes(b.Attributes, a.Attributes)),
+			TaintedBefore: b.Tainted,
+			TaintedAfter:  a.Tainted,
+		}
+		if len(rdiff.Attributes) > 0 || rdiff.TaintedBefore != rdiff.TaintedAfter {
+			diff.Changed = append(diff.Changed, rdiff)
+		}
+	}
+	for addr := range beforeResources {
+		if _, ok := afterResources[addr]; !ok {
+			diff.Removed = append(diff.Removed, addr)
+		}
+	}
+	slices.Sort(diff.Added)
+	slices.Sort(diff.Removed)
+	slices.SortFunc(diff.Changed, func(a, b ResourceDiff) int {
+		return cmp.Compare(a.Address, b.Address)
+	})
+	return diff
+}
+
+// Empty returns true if there are no differences.
+func (d Diff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// DiffAttributes returns the differences between two sets of resource
+// attributes, sorted by attribute path.
+func DiffAttributes(before, after map[string]any) []AttributeDiff {
+	var (
+		flatBefore = flattenAttributes(before)
+		flatAfter  = flattenAttributes(after)
+		diffs      []AttributeDiff
+	)
+	for path, a := range flatAfter {
+		b, ok := flatBefore[path]
+		if ok && reflect.DeepEqual(a, b) {
+			continue
+		}
+		diffs = append(diffs, AttributeDiff{Path: path, Before: b, After: a})
+	}
+	for path, b := range flatBefore {
+		if _, ok := flatAfter[path]; !ok {
+			diffs = append(diffs, AttributeDiff{Path: path, Before: b})
+		}
+	}
+	slices.SortFunc(diffs, func(a, b AttributeDiff) int {
+		return cmp.Compare(a.Path, b.Path)
+	})
+	return diffs
+}
+
+// IgnoreRules are patterns matching attributes to ignore when diffing resources.
+// Patterns use the syntax of path.Match, and are matched against both the full
+// path of an attribute and the last element of its path, e.g. the pattern
+// "arn" matches the attributes "arn" and "role.arn", and the pattern "*_id"
+// matches "vpc_id" and "subnets[0].subnet_id".
+type IgnoreRules []string
+
+// Match determines whether the attribute path matches any of the rules.
+func (r IgnoreRules) Match(attributePath string) bool {
+	last := attributePath
+	if i := strings.LastIndexByte(attributePath, '.'); i >= 0 {
+		last = attributePath[i+1:]
+	}
+	for _, pattern := range r {
+		if ok, _ := path.Match(pattern, attributePath); ok {
+			return true
+		}
+		if ok, _ := path.Match(pattern, last); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (r IgnoreRules) filter(diffs []AttributeDiff) []AttributeDiff {
+	if len(r) == 0 {
+		return diffs
+	}
+	return slices.DeleteFunc(diffs, func(diff AttributeDiff) bool {
+		return r.Match(diff.Path)
+	})
+}
+
+// flattenAttributes flattens nested attributes into a map of paths to leaf
+// values. Empty maps and lists are retained as leaf values.
+func flattenAttributes(attrs map[string]any) map[string]any {
+	flat := make(map[string]any)
+	for k, v := range attrs {
+		flatten(k, v, flat)
+	}
+	return flat
+}
+
+func flatten(path string, v any, into map[string]any) {
+	switch v := v.(type) {
+	case map[string]any:
+		if len(v) == 0 {
+			into[path] = v
+			return
+		}
+		for k, child := range v {
+			flatten(path+"."+k, child, into)
+		}
+	case []any:
+		if len(v) == 0 {
+			into[path] = v
+			return
+		}
+		for i, child := range v {
+			flatten(fmt.Sprintf("%s[%d]", path, i), child, into)
+		}
+	default:
+		into[path] = v
+	}
+}
+
+func resources(s *State) map[ResourceAddress]*Resource {
+	if s == nil {
+		return nil
+	}
+	return s.Resources
+}
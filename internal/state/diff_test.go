@@ -0,0 +1,135 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	before := &State{
+		Resources: map[ResourceAddress]*Resource{
+			"random_pet.removed": {Address: "random_pet.removed"},
+			"random_pet.unchanged": {
+				Address:    "random_pet.unchanged",
+				Attributes: map[string]any{"id": "foo"},
+			},
+			"random_pet.changed": {
+				Address: "random_pet.changed",
+				Attributes: map[string]any{
+					"id":      "bar",
+					"keepers": map[string]any{"now": "yesterday"},
+					"tags":    []any{"a", "b"},
+				},
+			},
+			"random_pet.tainted": {Address: "random_pet.tainted"},
+		},
+	}
+	after := &State{
+		Resources: map[ResourceAddress]*Resource{
+			"random_pet.added": {Address: "random_pet.added"},
+			"random_pet.unchanged": {
+				Address:    "random_pet.unchanged",
+				Attributes: map[string]any{"id": "foo"},
+			},
+			"random_pet.changed": {
+				Address: "random_pet.changed",
+				Attributes: map[string]any{
+					"id":      "bar",
+					"keepers": map[string]any{"now": "today"},
+					"tags":    []any{"a"},
+					"length":  float64(2),
+				},
+			},
+			"random_pet.tainted": {Address: "random_pet.tainted", Tainted: true},
+		},
+	}
+
+	got := NewDiff(before, after)
+
+	assert.Equal(t, []ResourceAddress{"random_pet.added"}, got.Added)
+	assert.Equal(t, []ResourceAddress{"random_pet.removed"}, got.Removed)
+	assert.Equal(t, []ResourceDiff{
+		{
+			Address: "random_pet.changed",
+			Attributes: []AttributeDiff{
+				{Path: "keepers.now", Before: "yesterday", After: "today"},
+				{Path: "length", After: float64(2)},
+				{Path: "tags[1]", Before: "b"},
+			},
+		},
+		{
+			Address:      "random_pet.tainted",
+			TaintedAfter: true,
+		},
+	}, got.Changed)
+}
+
+func TestDiff_Empty(t *testing.T) {
+	assert.True(t, NewDiff(nil, nil).Empty())
+
+	state := &State{
+		Resources: map[ResourceAddress]*Resource{
+			"random_pet.pet": {
+				Address:    "random_pet.pet",
+				Attributes: map[string]any{"keepers": map[string]any{}},
+			},
+		},
+	}
+	assert.True(t, NewDiff(state, state).Empty())
+	assert.Equal(t, []ResourceAddress{"random_pet.pet"}, NewDiff(nil, state).Added)
+	assert.Equal(t, []ResourceAddress{"random_pet.pet"}, NewDiff(state, nil).Removed)
+}
+
+func TestIgnoreRules(t *testing.T) {
+	rules := IgnoreRules{"id", "*_arn", "tags.*"}
+
+	assert.True(t, rules.Match("id"))
+	assert.True(t, rules.Match("ingress[0].id"))
+	assert.True(t, rules.Match("role_arn"))
+	assert.True(t, rules.Match("policy.role_arn"))
+	assert.True(t, rules.Match("tags.Name"))
+	assert.False(t, rules.Match("vpc_id"))
+	assert.False(t, rules.Match("arn"))
+	assert.False(t, rules.Match("name"))
+}
+
+func TestDiffIgnoring(t *testing.T) {
+	staging := &State{
+		Resources: map[ResourceAddress]*Resource{
+			"aws_instance.web": {
+				Address:    "aws_instance.web",
+				Attributes: map[string]any{"id": "i-123", "arn": "arn:1", "instance_type": "t3.small"},
+			},
+			"aws_vpc.main": {
+				Address:    "aws_vpc.main",
+				Attributes: map[string]any{"id": "vpc-123", "arn": "arn:2"},
+			},
+		},
+	}
+	prod := &State{
+		Resources: map[ResourceAddress]*Resource{
+			"aws_instance.web": {
+				Address:    "aws_instance.web",
+				Attributes: map[string]any{"id": "i-456", "arn": "arn:3", "instance_type": "t3.large"},
+			},
+			"aws_vpc.main": {
+				Address:    "aws_vpc.main",
+				Attributes: map[string]any{"id": "vpc-456", "arn": "arn:4"},
+			},
+		},
+	}
+
+	got := NewDiffIgnoring(staging, prod, IgnoreRules{"id", "arn"})
+
+	// Only the instance type should differ; the VPC differs only in ignored
+	// attributes.
+	assert.Equal(t, []ResourceDiff{
+		{
+			Address: "aws_instance.web",
+			Attributes: []AttributeDiff{
+				{Path: "instance_type", Before: "t3.small", After: "t3.large"},
+			},
+		},
+	}, got.Changed)
+}
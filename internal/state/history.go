@@ -0,0 +1,105 @@
+package state
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/leg100/pug/internal/resource"
+)
+
+// history retains the most recent states for each workspace.
+type history struct {
+	// states maps workspace IDs to their states, oldest first.
+	states map[resource.ID][]*State
+	// max is the maximum number of states to retain per workspace.
+	max int
+	mu  sync.Mutex
+}
+
+func newHistory(n int) *history {
+	return &history{
+		states: make(map[resource.ID][]*State),
+		// Always retain at least the current state.
+		max: max(1, n),
+	}
+}
+
+// add a state to its workspace's history, removing the oldest state if the
+// maximum number of states has been exceeded.
+func (h *history) add(state *State) {
+	if state.Serial < 0 {
+		// Don't retain empty states
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	states := append(h.states[state.WorkspaceID], state)
+	if len(states) > h.max {
+		states = slices.Delete(states, 0, len(states)-h.max)
+	}
+	h.states[state.WorkspaceID] = states
+}
+
+// list the states for a workspace, newest first.
+func (h *history) list(workspaceID resource.ID) []*State {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	states := slices.Clone(h.states[workspaceID])
+	slices.Reverse(states)
+	return states
+}
+
+// DiffID identifies a diff between two states in a workspace's history.
+// States are identified by their IDs rather than by their serials, which are
+// not unique, e.g. a restored state reuses an earlier serial.
+type DiffID struct {
+	WorkspaceID resource.ID
+	// Before is the ID of the state before the change. Nil refers to an empty
+	// state.
+	Before resource.ID
+	// After is the ID of the state after the change.
+	After resource.ID
+}
+
+func (id DiffID) String() string {
+	if id.Before == nil {
+		return fmt.Sprintf("empty..%s", id.After)
+	}
+	return fmt.Sprintf("%s..%s", id.Before, id.After)
+}
+
+// History lists the states retained for a workspace, newest first.
+func (s *Service) History(workspaceID resource.ID) []*State {
+	return s.history.list(workspaceID)
+}
+
+// GetFromHistory retrieves a state with the given ID from a workspace's
+// history. A nil ID returns an empty state.
+func (s *Service) GetFromHistory(workspaceID, stateID resource.ID) (*State, error) {
+	if stateID == nil {
+		return &State{WorkspaceID: workspaceID, Serial: -1}, nil
+	}
+	for _, state := range s.history.list(workspaceID) {
+		if state.ID == stateID {
+			return state, nil
+		}
+	}
+	return nil, fmt.Errorf("state %s: %w", stateID, resource.ErrNotFound)
+}
+
+// DiffHistory returns the differences between two states in a workspace's
+// history.
+func (s *Service) DiffHistory(id DiffID) (Diff, error) {
+	before, err := s.GetFromHistory(id.WorkspaceID, id.Before)
+	if err != nil {
+		return Diff{}, err
+	}
+	after, err := s.GetFromHistory(id.WorkspaceID, id.After)
+	if err != nil {
+		return Diff{}, err
+	}
+	return NewDiff(before, after), nil
+}
@@ -0,0 +1,74 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/leg100/pug/internal/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistory(t *testing.T) {
+	workspaceID := resource.NewMonotonicID(resource.Workspace)
+	svc := &Service{history: newHistory(2)}
+
+	for serial := range int64(3) {
+		svc.history.add(&State{
+			ID:          resource.NewMonotonicID(resource.State),
+			WorkspaceID: workspaceID,
+			Serial:      serial,
+			Resources: map[ResourceAddress]*Resource{
+				ResourceAddress("random_pet.pet"): {
+					Address:    "random_pet.pet",
+					Attributes: map[string]any{"length": float64(serial)},
+				},
+			},
+		})
+	}
+	// Empty states are not retained
+	svc.history.add(&State{WorkspaceID: workspaceID, Serial: -1})
+
+	// Oldest state should have been dropped
+	got := svc.History(workspaceID)
+	require.Len(t, got, 2)
+	assert.Equal(t, int64(2), got[0].Serial)
+	assert.Equal(t, int64(1), got[1].Serial)
+
+	_, err := svc.GetFromHistory(workspaceID, resource.NewMonotonicID(resource.State))
+	assert.ErrorIs(t, err, resource.ErrNotFound)
+
+	t.Run("diff states", func(t *testing.T) {
+		diff, err := svc.DiffHistory(DiffID{WorkspaceID: workspaceID, Before: got[1].ID, After: got[0].ID})
+		require.NoError(t, err)
+
+		assert.Equal(t, []ResourceDiff{
+			{
+				Address: "random_pet.pet",
+				Attributes: []AttributeDiff{
+					{Path: "length", Before: float64(1), After: float64(2)},
+				},
+			},
+		}, diff.Changed)
+	})
+
+	t.Run("diff with empty state", func(t *testing.T) {
+		diff, err := svc.DiffHistory(DiffID{WorkspaceID: workspaceID, After: got[1].ID})
+		require.NoError(t, err)
+
+		assert.Equal(t, []ResourceAddress{"random_pet.pet"}, diff.Added)
+	})
+
+	t.Run("repeated serial", func(t *testing.T) {
+		// A restored state reuses the serial of an earlier state.
+		restored := &State{
+			ID:          resource.NewMonotonicID(resource.State),
+			WorkspaceID: workspaceID,
+			Serial:      1,
+		}
+		svc.history.add(restored)
+
+		got, err := svc.GetFromHistory(workspaceID, restored.ID)
+		require.NoError(t, err)
+		assert.Same(t, restored, got)
+	})
+}
@@ -35,6 +35,11 @@ func (r *reloader) Reload(workspaceID resource.ID) (task.Spec, error) {
 			if err == nil && old.Serial == state.Serial {
 				return newReloadSummary(old, state), nil
 			}
+			// Retain state in history, so that it can later be compared with
+			// other states.
+			r.history.add(state)
+			// Index state's resources for retrieval and searching.
+			r.index.set(state)
 			// Add/replace state in cache.
 			r.cache.Add(workspaceID, state)
 			return newReloadSummary(old, state), nil
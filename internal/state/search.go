@@ -0,0 +1,289 @@
+package state
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/leg100/pug/internal"
+	"github.com/leg100/pug/internal/resource"
+)
+
+// SearchQuery is a query for resources across all cached states.
+type SearchQuery struct {
+	// Text to search for. Matched case-insensitively as a substring of
+	// resource addresses and attribute values, unless Regex is true, in which
+	// case it is matched case-insensitively only if it is all lower-case.
+	Text string
+	// Regex, if true, treats Text as a regular expression.
+	Regex bool
+}
+
+// ParseSearchQuery parses a search query entered by the user. A query
+// enclosed in forward slashes, e.g. /^i-[0-9a-f]+$/, is a regular expression;
+// otherwise the query is a substring.
+func ParseSearchQuery(s string) SearchQuery {
+	if len(s) > 1 && strings.HasPrefix(s, "/") && strings.HasSuffix(s, "/") {
+		return SearchQuery{Text: s[1 : len(s)-1], Regex: true}
+	}
+	return SearchQuery{Text: s}
+}
+
+func (q SearchQuery) String() string {
+	if q.Regex {
+		return "/" + q.Text + "/"
+	}
+	return q.Text
+}
+
+// SearchResult is a resource matching a search query.
+type SearchResult struct {
+	Resource *Resource
+	// Path of the attribute that matched the query. Empty if the resource
+	// address matched the query.
+	Path string
+	// Value that matched the query.
+	Value string
+}
+
+func (r SearchResult) GetID() resource.ID { return r.Resource.ID }
+
+// Search searches all cached states for resources with addresses or attribute
+// values matching the query. At most one result is returned per resource, and
+// results are ordered by address.
+func (s *Service) Search(query SearchQuery) ([]SearchResult, error) {
+	return s.index.search(query)
+}
+
+// minTrigramQuery is the minimum length of a query that can make use of the
+// trigram index.
+const minTrigramQuery = 3
+
+// index indexes the resources of cached states, for retrieving resources by ID
+// and for searching resources by address and attribute value.
+//
+// Searches make use of a trigram index: each field of a resource - its address
+// and its attribute values - is broken down into its constituent
+// three-character sequences, and each sequence maps to the resources containing
+// it. The candidate resources for a query are those containing every trigram in
+// the query, and only the candidates are then checked for a match.
+type index struct {
+	// resources maps resource IDs to their indexed resource.
+	resources map[resource.ID]*indexedResource
+	// workspaces maps workspace IDs to the IDs of their indexed resources.
+	workspaces map[resource.ID][]resource.ID
+	// trigrams maps lowercased trigrams to the IDs of resources containing the
+	// trigram.
+	trigrams map[string]map[resource.ID]struct{}
+	mu       sync.RWMutex
+}
+
+type indexedResource struct {
+	*Resource
+	// fields are the searchable fields of the resource, with the address
+	// first, followed by attribute values in order of attribute path.
+	fields []indexedField
+}
+
+type indexedField struct {
+	path  string
+	value string
+	// lower is the lowercased value
+	lower string
+}
+
+func newIndex() *index {
+	return &index{
+		resources:  make(map[resource.ID]*indexedResource),
+		workspaces: make(map[resource.ID][]resource.ID),
+		trigrams:   make(map[string]map[resource.ID]struct{}),
+	}
+}
+
+// set replaces the indexed resources of the state's workspace with the state's
+// resources.
+func (idx *index) set(state *State) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.remove(state.WorkspaceID)
+
+	ids := make([]resource.ID, 0, len(state.Resources))
+	for _, res := range state.Resources {
+		ir := &indexedResource{Resource: res}
+		ir.fields = append(ir.fields, newIndexedField("", string(res.Address)))
+		flat := flattenAttributes(res.Attributes)
+		for _, path := range slices.Sorted(maps.Keys(flat)) {
+			switch v := flat[path].(type) {
+			case string, float64, bool:
+				ir.fields = append(ir.fields, newIndexedField(path, fmt.Sprint(v)))
+			}
+		}
+		for _, f := range ir.fields {
+			for _, tri := range trigrams(f.lower) {
+				postings, ok := idx.trigrams[tri]
+				if !ok {
+					postings = make(map[resource.ID]struct{})
+					idx.trigrams[tri] = postings
+				}
+				postings[res.ID] = struct{}{}
+			}
+		}
+		idx.resources[res.ID] = ir
+		ids = append(ids, res.ID)
+	}
+	idx.workspaces[state.WorkspaceID] = ids
+}
+
+// remove the indexed resources of a workspace. The caller must hold the write
+// lock.
+func (idx *index) remove(workspaceID resource.ID) {
+	for _, id := range idx.workspaces[workspaceID] {
+		ir, ok := idx.resources[id]
+		if !ok {
+			continue
+		}
+		for _, f := range ir.fields {
+			for _, tri := range trigrams(f.lower) {
+				delete(idx.trigrams[tri], id)
+				if len(idx.trigrams[tri]) == 0 {
+					delete(idx.trigrams, tri)
+				}
+			}
+		}
+		delete(idx.resources, id)
+	}
+	delete(idx.workspaces, workspaceID)
+}
+
+// get retrieves an indexed resource by ID.
+func (idx *index) get(id resource.ID) (*Resource, error) {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	ir, ok := idx.resources[id]
+	if !ok {
+		return nil, resource.ErrNotFound
+	}
+	return ir.Resource, nil
+}
+
+func (idx *index) search(query SearchQuery) ([]SearchResult, error) {
+	if query.Text == "" {
+		return nil, nil
+	}
+	var (
+		match   func(f indexedField) bool
+		literal string
+	)
+	if query.Regex {
+		re, err := internal.CompileSmartCase(query.Text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression: %w", err)
+		}
+		match = func(f indexedField) bool { return re.MatchString(f.value) }
+		// Use the literal prefix of the regex, if there is one, to narrow down
+		// the candidates. A case-insensitive regex has no literal prefix, so
+		// take it from the case-sensitive form of the pattern instead; the
+		// index is lower case regardless.
+		if exact, err := regexp.Compile(query.Text); err == nil {
+			literal, _ = exact.LiteralPrefix()
+		}
+	} else {
+		literal = strings.ToLower(query.Text)
+		match = func(f indexedField) bool { return strings.Contains(f.lower, literal) }
+	}
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	var results []SearchResult
+	for _, ir := range idx.candidates(strings.ToLower(literal)) {
+		for _, f := range ir.fields {
+			if match(f) {
+				results = append(results, SearchResult{
+					Resource: ir.Resource,
+					Path:     f.path,
+					Value:    f.value,
+				})
+				break
+			}
+		}
+	}
+	slices.SortFunc(results, func(a, b SearchResult) int {
+		if n := cmp.Compare(a.Resource.Address, b.Resource.Address); n != 0 {
+			return n
+		}
+		return cmp.Compare(a.Resource.ID.Serial, b.Resource.ID.Serial)
+	})
+	return results, nil
+}
+
+// candidates returns the resources that possibly contain the literal. If the
+// literal is too short to make use of the trigram index then all resources are
+// returned. The caller must hold the read lock.
+func (idx *index) candidates(literal string) []*indexedResource {
+	if len(literal) < minTrigramQuery {
+		candidates := make([]*indexedResource, 0, len(idx.resources))
+		for _, ir := range idx.resources {
+			candidates = append(candidates, ir)
+		}
+		return candidates
+	}
+	// Start with the smallest posting list and intersect it with the others.
+	var lists []map[resource.ID]struct{}
+	for _, tri := range trigrams(literal) {
+		postings, ok := idx.trigrams[tri]
+		if !ok {
+			// No resource contains the trigram
+			return nil
+		}
+		lists = append(lists, postings)
+	}
+	slices.SortFunc(lists, func(a, b map[resource.ID]struct{}) int {
+		return cmp.Compare(len(a), len(b))
+	})
+	var candidates []*indexedResource
+	for id := range lists[0] {
+		if !inAll(id, lists[1:]) {
+			continue
+		}
+		candidates = append(candidates, idx.resources[id])
+	}
+	return candidates
+}
+
+func newIndexedField(path, value string) indexedField {
+	return indexedField{path: path, value: value, lower: strings.ToLower(value)}
+}
+
+// trigrams returns the unique trigrams in s.
+func trigrams(s string) []string {
+	if len(s) < minTrigramQuery {
+		return nil
+	}
+	seen := make(map[string]struct{}, len(s)-2)
+	tris := make([]string, 0, len(s)-2)
+	for i := 0; i+minTrigramQuery <= len(s); i++ {
+		tri := s[i : i+minTrigramQuery]
+		if _, ok := seen[tri]; ok {
+			continue
+		}
+		seen[tri] = struct{}{}
+		tris = append(tris, tri)
+	}
+	return tris
+}
+
+func inAll(id resource.ID, lists []map[resource.ID]struct{}) bool {
+	for _, list := range lists {
+		if _, ok := list[id]; !ok {
+			return false
+		}
+	}
+	return true
+}
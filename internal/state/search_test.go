@@ -0,0 +1,130 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/leg100/pug/internal/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSearchQuery(t *testing.T) {
+	assert.Equal(t, SearchQuery{Text: "foo"}, ParseSearchQuery("foo"))
+	assert.Equal(t, SearchQuery{Text: "^i-[0-9]+$", Regex: true}, ParseSearchQuery("/^i-[0-9]+$/"))
+	assert.Equal(t, SearchQuery{Text: "/"}, ParseSearchQuery("/"))
+}
+
+func TestSearch(t *testing.T) {
+	dev := resource.NewMonotonicID(resource.Workspace)
+	prod := resource.NewMonotonicID(resource.Workspace)
+
+	newState := func(workspaceID resource.ID, resources ...*Resource) *State {
+		state := &State{
+			WorkspaceID: workspaceID,
+			Resources:   make(map[ResourceAddress]*Resource),
+		}
+		for _, res := range resources {
+			res.ID = resource.NewMonotonicID(resource.StateResource)
+			res.WorkspaceID = workspaceID
+			state.Resources[res.Address] = res
+		}
+		return state
+	}
+
+	svc := &Service{index: newIndex()}
+	svc.index.set(newState(dev,
+		&Resource{
+			Address:    "aws_instance.web",
+			Attributes: map[string]any{"id": "i-0123abcd", "tags": map[string]any{"Name": "Web-Dev"}},
+		},
+		&Resource{
+			Address:    "random_pet.pet",
+			Attributes: map[string]any{"id": "clever-dog", "length": float64(2)},
+		},
+	))
+	svc.index.set(newState(prod,
+		&Resource{
+			Address:    "aws_instance.web",
+			Attributes: map[string]any{"id": "i-9876fedc", "tags": map[string]any{"Name": "web-prod"}},
+		},
+	))
+
+	search := func(t *testing.T, query string) []SearchResult {
+		t.Helper()
+		results, err := svc.Search(ParseSearchQuery(query))
+		require.NoError(t, err)
+		return results
+	}
+
+	t.Run("address", func(t *testing.T) {
+		got := search(t, "aws_instance")
+		require.Len(t, got, 2)
+		assert.Equal(t, "", got[0].Path)
+		assert.Equal(t, "aws_instance.web", got[0].Value)
+	})
+
+	t.Run("attribute value case-insensitively", func(t *testing.T) {
+		got := search(t, "WEB-PROD")
+		require.Len(t, got, 1)
+		assert.Equal(t, prod, got[0].Resource.WorkspaceID)
+		assert.Equal(t, "tags.Name", got[0].Path)
+		assert.Equal(t, "web-prod", got[0].Value)
+	})
+
+	t.Run("short query", func(t *testing.T) {
+		got := search(t, "2")
+		require.Len(t, got, 2)
+		assert.Equal(t, "i-0123abcd", got[0].Value)
+		assert.Equal(t, "2", got[1].Value)
+	})
+
+	t.Run("regex", func(t *testing.T) {
+		got := search(t, "/^i-[0-9]+[a-f]+$/")
+		require.Len(t, got, 2)
+		assert.Equal(t, "id", got[0].Path)
+	})
+
+	t.Run("regex is smart-case", func(t *testing.T) {
+		assert.Len(t, search(t, "/^web/"), 2)
+		assert.Len(t, search(t, "/^Web/"), 1)
+		assert.Len(t, search(t, "/(?i)^Web/"), 2)
+		// Escape sequences don't count as upper case
+		assert.Len(t, search(t, `/^web-\S/`), 2)
+		// Literal prefix is used to look up the index case-insensitively
+		assert.Len(t, search(t, "/web-dev$/"), 1)
+	})
+
+	t.Run("invalid regex", func(t *testing.T) {
+		_, err := svc.Search(ParseSearchQuery("/[/"))
+		assert.Error(t, err)
+	})
+
+	t.Run("no matches", func(t *testing.T) {
+		assert.Empty(t, search(t, "does-not-exist"))
+	})
+
+	t.Run("get resource", func(t *testing.T) {
+		want := search(t, "clever-dog")[0].Resource
+		got, err := svc.index.get(want.ID)
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	})
+
+	t.Run("replace state", func(t *testing.T) {
+		old := search(t, "clever-dog")[0].Resource
+
+		svc.index.set(newState(dev,
+			&Resource{
+				Address:    "random_pet.pet",
+				Attributes: map[string]any{"id": "happy-cat"},
+			},
+		))
+		assert.Empty(t, search(t, "clever-dog"))
+		assert.Len(t, search(t, "happy-cat"), 1)
+		// Resources from prod workspace are unaffected
+		assert.Len(t, search(t, "aws_instance"), 1)
+
+		_, err := svc.index.get(old.ID)
+		assert.ErrorIs(t, err, resource.ErrNotFound)
+	})
+}
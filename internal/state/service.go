@@ -1,6 +1,7 @@
 package state
 
 import (
+	"github.com/leg100/pug/internal"
 	"github.com/leg100/pug/internal/logging"
 	"github.com/leg100/pug/internal/module"
 	"github.com/leg100/pug/internal/pubsub"
@@ -17,29 +18,65 @@ type Service struct {
 
 	// Table mapping workspace IDs to states
 	cache *resource.Table[*State]
+	// Recent states for each workspace
+	history *history
+	// Index of the resources of cached states
+	index *index
+	// Table of state snapshots
+	snapshots *resource.Table[*Snapshot]
+	// Directory in which to store snapshots
+	dataDir string
+	// Maximum number of snapshots to retain per workspace. Zero disables
+	// snapshots.
+	maxSnapshots int
+	// Rules for ignoring attributes when comparing workspaces
+	compareIgnore IgnoreRules
+	workdir       internal.Workdir
+
+	SnapshotBroker *pubsub.Broker[*Snapshot]
 
 	*pubsub.Broker[*State]
 	*reloader
+	*snapshotter
 }
 
 type ServiceOptions struct {
-	Modules    *module.Service
-	Workspaces *workspace.Service
-	Tasks      *task.Service
-	Logger     logging.Interface
+	Modules      *module.Service
+	Workspaces   *workspace.Service
+	Tasks        *task.Service
+	Logger       logging.Interface
+	DataDir      string
+	MaxSnapshots int
+	MaxHistory   int
+	Workdir      internal.Workdir
+	// CompareIgnore are rules for ignoring resource attributes when comparing
+	// workspaces.
+	CompareIgnore IgnoreRules
+	// Events configures the brokers that publish the service's events.
+	Events []pubsub.Option
 }
 
 func NewService(opts ServiceOptions) *Service {
-	broker := pubsub.NewBroker[*State](opts.Logger)
+	broker := pubsub.NewBroker[*State](opts.Logger, opts.Events...)
+	snapshotBroker := pubsub.NewBroker[*Snapshot](opts.Logger, opts.Events...)
 	s := &Service{
-		modules:    opts.Modules,
-		workspaces: opts.Workspaces,
-		tasks:      opts.Tasks,
-		cache:      resource.NewTable(broker),
-		Broker:     broker,
-		logger:     opts.Logger,
+		modules:        opts.Modules,
+		workspaces:     opts.Workspaces,
+		tasks:          opts.Tasks,
+		cache:          resource.NewTable(broker),
+		history:        newHistory(opts.MaxHistory),
+		index:          newIndex(),
+		snapshots:      resource.NewTable(snapshotBroker),
+		dataDir:        opts.DataDir,
+		maxSnapshots:   opts.MaxSnapshots,
+		compareIgnore:  opts.CompareIgnore,
+		workdir:        opts.Workdir,
+		Broker:         broker,
+		SnapshotBroker: snapshotBroker,
+		logger:         opts.Logger,
 	}
 	s.reloader = &reloader{s}
+	s.snapshotter = newSnapshotter(s)
 	return s
 }
 
@@ -49,17 +86,8 @@ func (s *Service) Get(workspaceID resource.ID) (*State, error) {
 }
 
 // GetResource retrieves a state resource.
-//
-// TODO: this is massively inefficient
 func (s *Service) GetResource(resourceID resource.ID) (*Resource, error) {
-	for _, state := range s.cache.List() {
-		for _, res := range state.Resources {
-			if res.ID == resourceID {
-				return res, nil
-			}
-		}
-	}
-	return nil, resource.ErrNotFound
+	return s.index.get(resourceID)
 }
 
 func (s *Service) Delete(workspaceID resource.ID, addrs ...ResourceAddress) (task.Spec, error) {
@@ -67,7 +95,7 @@ func (s *Service) Delete(workspaceID resource.ID, addrs ...ResourceAddress) (tas
 	for i, addr := range addrs {
 		addrStrings[i] = string(addr)
 	}
-	return s.createTaskSpec(workspaceID, task.Spec{
+	return s.createMutatingTaskSpec(workspaceID, task.Spec{
 		Blocking: true,
 		Execution: task.Execution{
 			TerraformCommand: []string{"state", "rm"},
@@ -84,7 +112,7 @@ func (s *Service) Delete(workspaceID resource.ID, addrs ...ResourceAddress) (tas
 }
 
 func (s *Service) Taint(workspaceID resource.ID, addr ResourceAddress) (task.Spec, error) {
-	return s.createTaskSpec(workspaceID, task.Spec{
+	return s.createMutatingTaskSpec(workspaceID, task.Spec{
 		Blocking: true,
 		Execution: task.Execution{
 			TerraformCommand: []string{"taint"},
@@ -101,7 +129,7 @@ func (s *Service) Taint(workspaceID resource.ID, addr ResourceAddress) (task.Spe
 }
 
 func (s *Service) Untaint(workspaceID resource.ID, addr ResourceAddress) (task.Spec, error) {
-	return s.createTaskSpec(workspaceID, task.Spec{
+	return s.createMutatingTaskSpec(workspaceID, task.Spec{
 		Blocking: true,
 		Execution: task.Execution{
 			TerraformCommand: []string{"untaint"},
@@ -118,7 +146,7 @@ func (s *Service) Untaint(workspaceID resource.ID, addr ResourceAddress) (task.S
 }
 
 func (s *Service) Move(workspaceID resource.ID, src, dest ResourceAddress) (task.Spec, error) {
-	return s.createTaskSpec(workspaceID, task.Spec{
+	return s.createMutatingTaskSpec(workspaceID, task.Spec{
 		Blocking: true,
 		Execution: task.Execution{
 			TerraformCommand: []string{"state", "mv"},
@@ -134,6 +162,16 @@ func (s *Service) Move(workspaceID resource.ID, src, dest ResourceAddress) (task
 	})
 }
 
+// createMutatingTaskSpec creates a spec for a task that mutates state, which
+// first takes a snapshot of the state.
+func (s *Service) createMutatingTaskSpec(workspaceID resource.ID, opts task.Spec) (task.Spec, error) {
+	spec, err := s.createTaskSpec(workspaceID, opts)
+	if err != nil {
+		return task.Spec{}, err
+	}
+	return s.WithSnapshot(spec)
+}
+
 // TODO: move this logic into task.Create
 func (s *Service) createTaskSpec(workspaceID resource.ID, opts task.Spec) (task.Spec, error) {
 	ws, err := s.workspaces.Get(workspaceID)
@@ -0,0 +1,351 @@
+package state
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/leg100/pug/internal/resource"
+	"github.com/leg100/pug/internal/task"
+	"github.com/leg100/pug/internal/workspace"
+)
+
+// snapshotTimeFormat is the format of the timestamp in a snapshot's filename.
+const snapshotTimeFormat = "20060102T150405.000000000Z"
+
+// Snapshot is a copy of a workspace's state, taken before the state is
+// mutated, which can later be restored.
+type Snapshot struct {
+	ID          resource.MonotonicID
+	WorkspaceID resource.ID
+	// Path to the snapshot's state file.
+	Path      string
+	Serial    int64
+	Created   time.Time
+	Resources int
+}
+
+func (s *Snapshot) GetID() resource.ID { return s.ID }
+
+func (s *Snapshot) String() string {
+	return fmt.Sprintf("#%d (%d resources)", s.Serial, s.Resources)
+}
+
+type snapshotter struct {
+	*Service
+
+	// loaded tracks the workspaces for which existing snapshots have been
+	// loaded from disk.
+	loaded map[resource.ID]bool
+	// restoring counts the unfinished restore tasks of each snapshot, which
+	// are not pruned whilst they are being restored.
+	restoring map[resource.ID]int
+	mu        sync.Mutex
+}
+
+func newSnapshotter(svc *Service) *snapshotter {
+	return &snapshotter{
+		Service:   svc,
+		loaded:    make(map[resource.ID]bool),
+		restoring: make(map[resource.ID]int),
+	}
+}
+
+// Snapshot creates a task to take a snapshot of the state of the given
+// workspace. No snapshot is taken if the workspace has no state.
+func (s *snapshotter) Snapshot(workspaceID resource.ID) (task.Spec, error) {
+	ws, err := s.workspaces.Get(workspaceID)
+	if err != nil {
+		return task.Spec{}, err
+	}
+	return s.createTaskSpec(workspaceID, task.Spec{
+		Execution: task.Execution{
+			TerraformCommand: []string{"state", "pull"},
+		},
+		Description: "snapshot state",
+		JSON:        true,
+		BeforeExited: func(t *task.Task) (task.Summary, error) {
+			contents, err := io.ReadAll(t.NewReader(false))
+			if err != nil {
+				return nil, err
+			}
+			state, err := newState(workspaceID, bytes.NewReader(contents))
+			if err != nil {
+				return nil, fmt.Errorf("constructing pug state: %w", err)
+			}
+			if state.Serial < 0 {
+				// Nothing to snapshot
+				return Empty, nil
+			}
+			snapshot, err := s.addSnapshot(ws, state, contents)
+			if err != nil {
+				return nil, fmt.Errorf("saving snapshot: %w", err)
+			}
+			return snapshot, nil
+		},
+	})
+}
+
+// WithSnapshot configures the task spec so that before its task is run a
+// snapshot is first taken of the state of the spec's workspace. If the snapshot
+// fails then the task is canceled. If snapshots are disabled then the spec is
+// returned unchanged.
+func (s *snapshotter) WithSnapshot(spec task.Spec) (task.Spec, error) {
+	if s.maxSnapshots <= 0 || spec.WorkspaceID == nil {
+		return spec, nil
+	}
+	snapshot, err := s.Snapshot(spec.WorkspaceID)
+	if err != nil {
+		return task.Spec{}, fmt.Errorf("creating snapshot task spec: %w", err)
+	}
+	spec.Prerequisite = &snapshot
+	return spec, nil
+}
+
+// ListSnapshots lists the snapshots for the given workspace, newest first.
+func (s *snapshotter) ListSnapshots(workspaceID resource.ID) ([]*Snapshot, error) {
+	ws, err := s.workspaces.Get(workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.loadSnapshots(ws); err != nil {
+		return nil, fmt.Errorf("loading snapshots: %w", err)
+	}
+	return s.listSnapshots(workspaceID), nil
+}
+
+// GetSnapshot retrieves a snapshot.
+func (s *snapshotter) GetSnapshot(snapshotID resource.ID) (*Snapshot, error) {
+	return s.snapshots.Get(snapshotID)
+}
+
+// GetSnapshotState retrieves the state contained in a snapshot.
+func (s *snapshotter) GetSnapshotState(snapshotID resource.ID) (*State, error) {
+	snapshot, err := s.snapshots.Get(snapshotID)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(snapshot.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return newState(snapshot.WorkspaceID, f)
+}
+
+// RestoreSnapshot creates a task to overwrite the state of the snapshot's
+// workspace with the state contained in the snapshot, i.e. `terraform state
+// push -force <snapshot>`. A snapshot of the current state is taken
+// beforehand, so that the restore itself can be reverted.
+func (s *snapshotter) RestoreSnapshot(snapshotID resource.ID) (task.Spec, error) {
+	snapshot, err := s.snapshots.Get(snapshotID)
+	if err != nil {
+		return task.Spec{}, err
+	}
+	spec, err := s.createTaskSpec(snapshot.WorkspaceID, task.Spec{
+		Blocking: true,
+		Execution: task.Execution{
+			TerraformCommand: []string{"state", "push"},
+			Args:             []string{"-force", snapshot.Path},
+		},
+		Description: fmt.Sprintf("restore snapshot #%d", snapshot.Serial),
+		// Don't prune the snapshot until it has been restored.
+		AfterCreate: func(*task.Task) {
+			s.setRestoring(snapshot.ID, 1)
+		},
+		AfterFinish: func(*task.Task) {
+			s.setRestoring(snapshot.ID, -1)
+		},
+		AfterError: func(t *task.Task) {
+			s.logger.Error("restoring snapshot", "error", t.Err, "snapshot", snapshot.Path)
+		},
+		AfterExited: func(t *task.Task) {
+			s.CreateReloadTask(snapshot.WorkspaceID)
+		},
+	})
+	if err != nil {
+		return task.Spec{}, err
+	}
+	return s.WithSnapshot(spec)
+}
+
+// setRestoring adjusts the number of unfinished restore tasks of a snapshot.
+func (s *snapshotter) setRestoring(snapshotID resource.ID, delta int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.restoring[snapshotID] += delta
+	if s.restoring[snapshotID] <= 0 {
+		delete(s.restoring, snapshotID)
+	}
+}
+
+// isRestoring is true if the snapshot has an unfinished restore task.
+func (s *snapshotter) isRestoring(snapshotID resource.ID) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.restoring[snapshotID] > 0
+}
+
+// addSnapshot writes the state to a new snapshot file, and prunes older
+// snapshots for the workspace beyond the maximum number to retain.
+func (s *snapshotter) addSnapshot(ws *workspace.Workspace, state *State, contents []byte) (*Snapshot, error) {
+	// Load any existing snapshots first to ensure they're pruned too.
+	if err := s.loadSnapshots(ws); err != nil {
+		return nil, err
+	}
+	dir, err := s.snapshotDir(ws)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	created := time.Now().UTC()
+	fname := fmt.Sprintf("%s-%d.tfstate", created.Format(snapshotTimeFormat), state.Serial)
+	path := filepath.Join(dir, fname)
+	// Write to temporary file first and then rename, to avoid leaving a
+	// partially written snapshot.
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, contents, 0o600); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return nil, err
+	}
+	snapshot := &Snapshot{
+		ID:          resource.NewMonotonicID(resource.StateSnapshot),
+		WorkspaceID: ws.ID,
+		Path:        path,
+		Serial:      state.Serial,
+		Created:     created,
+		Resources:   len(state.Resources),
+	}
+	s.snapshots.Add(snapshot.ID, snapshot)
+	s.pruneSnapshots(ws.ID)
+	return snapshot, nil
+}
+
+// pruneSnapshots removes the oldest snapshots for a workspace beyond the
+// maximum number to retain. Snapshots that are being restored are skipped,
+// and are instead pruned once a later snapshot is taken.
+func (s *snapshotter) pruneSnapshots(workspaceID resource.ID) {
+	snapshots := s.listSnapshots(workspaceID)
+	if len(snapshots) <= s.maxSnapshots {
+		return
+	}
+	for _, snapshot := range snapshots[s.maxSnapshots:] {
+		if s.isRestoring(snapshot.ID) {
+			continue
+		}
+		if err := os.Remove(snapshot.Path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			s.logger.Error("removing snapshot", "error", err, "snapshot", snapshot.Path)
+			continue
+		}
+		s.snapshots.Delete(snapshot.ID)
+	}
+}
+
+// listSnapshots lists snapshots in the table for the given workspace, newest
+// first.
+func (s *snapshotter) listSnapshots(workspaceID resource.ID) []*Snapshot {
+	var snapshots []*Snapshot
+	for _, snapshot := range s.snapshots.List() {
+		if snapshot.WorkspaceID == workspaceID {
+			snapshots = append(snapshots, snapshot)
+		}
+	}
+	slices.SortFunc(snapshots, func(a, b *Snapshot) int {
+		return b.Created.Compare(a.Created)
+	})
+	return snapshots
+}
+
+// loadSnapshots loads snapshots for the workspace that were taken by previous
+// invocations of pug. Snapshots are only loaded once per workspace.
+func (s *snapshotter) loadSnapshots(ws *workspace.Workspace) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.loaded[ws.ID] {
+		return nil
+	}
+	dir, err := s.snapshotDir(ws)
+	if err != nil {
+		return err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		snapshot, err := loadSnapshot(ws.ID, filepath.Join(dir, entry.Name()))
+		if err != nil {
+			// Skip files that are not snapshots
+			s.logger.Debug("skipping snapshot file", "error", err, "path", entry.Name())
+			continue
+		}
+		s.snapshots.Add(snapshot.ID, snapshot)
+	}
+	s.loaded[ws.ID] = true
+	return nil
+}
+
+// snapshotDir returns the absolute path to the directory containing the
+// workspace's snapshots.
+func (s *snapshotter) snapshotDir(ws *workspace.Workspace) (string, error) {
+	// The module path is escaped to avoid the directories of nested modules
+	// colliding with workspace directories.
+	dir := filepath.Join(s.dataDir, "snapshots", url.PathEscape(ws.ModulePath), ws.Name)
+	return filepath.Abs(dir)
+}
+
+func loadSnapshot(workspaceID resource.ID, path string) (*Snapshot, error) {
+	name, ok := strings.CutSuffix(filepath.Base(path), ".tfstate")
+	if !ok {
+		return nil, errors.New("missing .tfstate suffix")
+	}
+	timestamp, serial, ok := strings.Cut(name, "-")
+	if !ok {
+		return nil, errors.New("malformed snapshot filename")
+	}
+	created, err := time.Parse(snapshotTimeFormat, timestamp)
+	if err != nil {
+		return nil, fmt.Errorf("parsing snapshot timestamp: %w", err)
+	}
+	snapshot := &Snapshot{
+		ID:          resource.NewMonotonicID(resource.StateSnapshot),
+		WorkspaceID: workspaceID,
+		Path:        path,
+		Created:     created,
+	}
+	snapshot.Serial, err = strconv.ParseInt(serial, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("parsing snapshot serial: %w", err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	state, err := newState(workspaceID, f)
+	if err != nil {
+		return nil, err
+	}
+	snapshot.Resources = len(state.Resources)
+	return snapshot, nil
+}
@@ -0,0 +1,104 @@
+package state
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/leg100/pug/internal/logging"
+	"github.com/leg100/pug/internal/module"
+	"github.com/leg100/pug/internal/pubsub"
+	"github.com/leg100/pug/internal/resource"
+	"github.com/leg100/pug/internal/workspace"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshots(t *testing.T) {
+	mod := module.New(module.Options{Path: "a/b/c"})
+	ws, err := workspace.New(mod, "dev")
+	require.NoError(t, err)
+
+	contents, err := os.ReadFile("./testdata/with_mods/terraform.tfstate.d/dev/terraform.tfstate")
+	require.NoError(t, err)
+
+	dataDir := t.TempDir()
+	newSnapshotter := func() *snapshotter {
+		svc := &Service{
+			snapshots:    resource.NewTable(pubsub.NewBroker[*Snapshot](logging.Discard)),
+			dataDir:      dataDir,
+			maxSnapshots: 2,
+			logger:       logging.Discard,
+		}
+		return newSnapshotter(svc)
+	}
+	s := newSnapshotter()
+
+	// Take three snapshots, with the oldest pruned.
+	var serials []int64
+	for i := range 3 {
+		state, err := newState(ws.ID, bytes.NewReader(contents))
+		require.NoError(t, err)
+		state.Serial = int64(i)
+		serials = append(serials, state.Serial)
+
+		snapshot, err := s.addSnapshot(ws, state, contents)
+		require.NoError(t, err)
+		assert.Equal(t, 17, snapshot.Resources)
+	}
+	got := s.listSnapshots(ws.ID)
+	require.Len(t, got, 2)
+	assert.Equal(t, serials[2], got[0].Serial)
+	assert.Equal(t, serials[1], got[1].Serial)
+
+	entries, err := os.ReadDir(mustSnapshotDir(t, s, ws))
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+
+	t.Run("load snapshots from disk", func(t *testing.T) {
+		s := newSnapshotter()
+		require.NoError(t, s.loadSnapshots(ws))
+
+		loaded := s.listSnapshots(ws.ID)
+		require.Len(t, loaded, 2)
+		assert.Equal(t, serials[2], loaded[0].Serial)
+		assert.Equal(t, 17, loaded[0].Resources)
+		assert.True(t, got[0].Created.Equal(loaded[0].Created))
+	})
+
+	t.Run("get snapshot state", func(t *testing.T) {
+		state, err := s.GetSnapshotState(got[0].ID)
+		require.NoError(t, err)
+		assert.Len(t, state.Resources, 17)
+	})
+
+	t.Run("don't prune snapshot being restored", func(t *testing.T) {
+		s := newSnapshotter()
+		require.NoError(t, s.loadSnapshots(ws))
+		oldest := s.listSnapshots(ws.ID)[1]
+		s.setRestoring(oldest.ID, 1)
+
+		state, err := newState(ws.ID, bytes.NewReader(contents))
+		require.NoError(t, err)
+		_, err = s.addSnapshot(ws, state, contents)
+		require.NoError(t, err)
+
+		assert.Len(t, s.listSnapshots(ws.ID), 3)
+		assert.FileExists(t, oldest.Path)
+
+		// Once restored, the snapshot is pruned when the next snapshot is
+		// taken.
+		s.setRestoring(oldest.ID, -1)
+		_, err = s.addSnapshot(ws, state, contents)
+		require.NoError(t, err)
+
+		assert.Len(t, s.listSnapshots(ws.ID), 2)
+		assert.NoFileExists(t, oldest.Path)
+	})
+}
+
+func mustSnapshotDir(t *testing.T, s *snapshotter, ws *workspace.Workspace) string {
+	dir, err := s.snapshotDir(ws)
+	require.NoError(t, err)
+	return dir
+}
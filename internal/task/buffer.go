@@ -1,22 +1,73 @@
 package task
 
 import (
-	"bytes"
+	"errors"
+	"fmt"
 	"io"
+	"os"
+	"path/filepath"
 	"sync"
+
+	"github.com/leg100/pug/internal/logging"
 )
 
+// DefaultMaxOutputMemory is the default maximum number of bytes a buffer of
+// task output holds in memory before spilling to disk.
+const DefaultMaxOutputMemory = 1024 * 1024
+
+// buffer is a buffer of task output. Once the output held in memory reaches a
+// maximum size, it is written to a segment file on disk and the memory is
+// released, leaving in memory only the tail of the output. Readers read both
+// the segments on disk and the tail in memory.
 type buffer struct {
-	buf *bytes.Buffer
+	// dir is the directory in which segment files are written. If empty then
+	// output is never spilled to disk.
+	dir string
+	// maxMemory is the maximum size of the tail.
+	maxMemory int
+	// spillFailed is true once spilling to disk has failed, after which
+	// output is only held in memory.
+	spillFailed bool
+	logger      logging.Interface
+	// segments are the segment files written thus far, in order.
+	segments []segment
+	// spilled is the number of bytes written to segment files.
+	spilled int64
+	// tail is the output following that written to segment files.
+	tail []byte
 
+	// avail is closed, and replaced, whenever bytes are written, to notify
+	// every streamer at once that there are bytes available to be read.
 	avail chan struct{}
-	mu    sync.Mutex
+	// closed is true once the buffer is closed.
+	closed bool
+	mu     sync.Mutex
+}
+
+type segment struct {
+	path string
+	// offset of the first byte of the segment within the buffer.
+	offset int64
+	size   int64
 }
 
-func newBuffer() *buffer {
+// BufferUsage reports the space used by a buffer.
+type BufferUsage struct {
+	// Memory is the number of bytes held in memory.
+	Memory int64
+	// Disk is the number of bytes held in segment files on disk.
+	Disk int64
+}
+
+func newBuffer(dir string, maxMemory int, logger logging.Interface) *buffer {
+	if maxMemory <= 0 {
+		maxMemory = DefaultMaxOutputMemory
+	}
 	return &buffer{
-		buf:   new(bytes.Buffer),
-		avail: make(chan struct{}, 1),
+		dir:       dir,
+		maxMemory: maxMemory,
+		logger:    logger,
+		avail:     make(chan struct{}),
 	}
 }
 
@@ -24,64 +75,183 @@ func (b *buffer) Write(p []byte) (int, error) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
-	n, err := b.buf.Write(p)
-	if err != nil {
-		return n, err
+	b.tail = append(b.tail, p...)
+	if b.dir != "" && !b.spillFailed && len(b.tail) >= b.maxMemory {
+		if err := b.spill(); err != nil {
+			// Rather than lose output, keep it in memory, and don't attempt
+			// to spill again.
+			b.logger.Error("spilling task output to disk; task output is held only in memory", "error", err)
+			b.spillFailed = true
+		}
 	}
 	// Let streamers know there are now available bytes to be read.
-	select {
-	case b.avail <- struct{}{}:
-	default:
+	close(b.avail)
+	b.avail = make(chan struct{})
+	return len(p), nil
+}
+
+// spill writes the tail to a new segment file and releases the tail's memory.
+// The caller must hold the lock.
+func (b *buffer) spill() error {
+	if err := os.MkdirAll(b.dir, 0o755); err != nil {
+		return fmt.Errorf("creating task output directory: %w", err)
 	}
-	return n, nil
+	path := filepath.Join(b.dir, fmt.Sprintf("%06d", len(b.segments)))
+	if err := os.WriteFile(path, b.tail, 0o644); err != nil {
+		return fmt.Errorf("writing task output segment: %w", err)
+	}
+	b.segments = append(b.segments, segment{
+		path:   path,
+		offset: b.spilled,
+		size:   int64(len(b.tail)),
+	})
+	b.spilled += int64(len(b.tail))
+	b.tail = nil
+	return nil
 }
 
-// NewReader returns a copy of the buffer to read from.
-func (b *buffer) NewReader() io.Reader {
+// Len returns the number of bytes written to the buffer.
+func (b *buffer) Len() int64 {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
-	r := new(bytes.Buffer)
-	r.Write(b.buf.Bytes())
-	return r
+	return b.spilled + int64(len(b.tail))
+}
+
+// Usage reports the space used by the buffer.
+func (b *buffer) Usage() BufferUsage {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return BufferUsage{Memory: int64(cap(b.tail)), Disk: b.spilled}
+}
+
+// readAt reads bytes from the buffer starting at the given offset. It reads no
+// further than the end of the segment or tail containing the offset.
+func (b *buffer) readAt(p []byte, off int64) (int, error) {
+	b.mu.Lock()
+	if off >= b.spilled {
+		defer b.mu.Unlock()
+
+		if off-b.spilled >= int64(len(b.tail)) {
+			return 0, io.EOF
+		}
+		return copy(p, b.tail[off-b.spilled:]), nil
+	}
+	// Offset falls within a segment; read it without holding the lock, which
+	// is safe because segments are never modified once written.
+	var seg segment
+	for _, s := range b.segments {
+		if off < s.offset+s.size {
+			seg = s
+			break
+		}
+	}
+	b.mu.Unlock()
+
+	f, err := os.Open(seg.path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	if remaining := seg.offset + seg.size - off; int64(len(p)) > remaining {
+		p = p[:remaining]
+	}
+	n, err := f.ReadAt(p, off-seg.offset)
+	if errors.Is(err, io.EOF) && n > 0 {
+		err = nil
+	}
+	return n, err
+}
+
+// NewReader returns a reader of what has been written to the buffer thus far.
+// The reader reads from the buffer rather than from a copy of it.
+func (b *buffer) NewReader() io.Reader {
+	return &bufferReader{buf: b, limit: b.Len()}
+}
+
+type bufferReader struct {
+	buf    *buffer
+	offset int64
+	// limit is the size of the buffer when the reader was created; the reader
+	// reads no further.
+	limit int64
+}
+
+func (r *bufferReader) Read(p []byte) (int, error) {
+	if r.offset >= r.limit {
+		return 0, io.EOF
+	}
+	if remaining := r.limit - r.offset; int64(len(p)) > remaining {
+		p = p[:remaining]
+	}
+	n, err := r.buf.readAt(p, r.offset)
+	r.offset += int64(n)
+	return n, err
 }
 
 // Stream buffer as it is written to. The return channel is closed when the
 // buffer is closed.
 func (b *buffer) Stream() <-chan []byte {
 	var (
-		offset int
+		offset int64
 		ch     = make(chan []byte)
 	)
 
-	copyBytes := func() []byte {
-		b.mu.Lock()
-		byts := b.buf.Bytes()
-		size := b.buf.Len() - offset
-		dst := make([]byte, size)
-		offset += copy(dst, byts[offset:])
-		b.mu.Unlock()
-		return dst
+	// sendBytes sends what has been written since the last send, in chunks no
+	// larger than the maximum size of the tail.
+	sendBytes := func() {
+		for {
+			chunk := make([]byte, min(b.Len()-offset, int64(b.maxMemory)))
+			if len(chunk) == 0 {
+				return
+			}
+			n, err := b.readAt(chunk, offset)
+			if n > 0 {
+				offset += int64(n)
+				ch <- chunk[:n]
+			}
+			if err != nil {
+				return
+			}
+		}
 	}
 
 	go func() {
-		if b := copyBytes(); len(b) > 0 {
-			ch <- b
-		}
 		for {
-			_, ok := <-b.avail
-			if b := copyBytes(); len(b) > 0 {
-				ch <- b
-			}
-			if !ok {
+			// Retrieve the notification channel before sending bytes, to
+			// ensure bytes written whilst sending are not missed.
+			b.mu.Lock()
+			avail, closed := b.avail, b.closed
+			b.mu.Unlock()
+
+			sendBytes()
+			if closed {
 				close(ch)
 				return
 			}
+			<-avail
 		}
 	}()
 	return ch
 }
 
 func (b *buffer) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
 	close(b.avail)
 }
+
+// Remove removes the buffer's segment files.
+func (b *buffer) Remove() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.dir == "" {
+		return nil
+	}
+	return os.RemoveAll(b.dir)
+}
@@ -1,8 +1,13 @@
 package task
 
 import (
+	"io"
+	"os"
+	"path/filepath"
 	"testing"
+	"testing/iotest"
 
+	"github.com/leg100/pug/internal/logging"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -12,7 +17,7 @@ import (
 func TestBuffer_NewReader(t *testing.T) {
 	t.Parallel()
 
-	buf := newBuffer()
+	buf := newBuffer("", 0, logging.Discard)
 	_, err := buf.Write([]byte("hello world"))
 	require.NoError(t, err)
 
@@ -33,7 +38,7 @@ func TestBuffer_NewReader(t *testing.T) {
 func TestBuffer_Stream(t *testing.T) {
 	t.Parallel()
 
-	buf := newBuffer()
+	buf := newBuffer("", 0, logging.Discard)
 	ch := buf.Stream()
 
 	_, err := buf.Write([]byte("hello"))
@@ -53,3 +58,110 @@ func TestBuffer_Stream(t *testing.T) {
 	got = <-ch
 	assert.Nil(t, got)
 }
+
+func TestBuffer_Spill(t *testing.T) {
+	t.Parallel()
+
+	dir := filepath.Join(t.TempDir(), "stdout")
+	buf := newBuffer(dir, 10, logging.Discard)
+
+	// Write 25 bytes in varying sizes, which should spill two segments of at
+	// least 10 bytes each to disk.
+	var want string
+	for _, s := range []string{"abc", "defghijkl", "mnopqrstuvwx", "y"} {
+		_, err := buf.Write([]byte(s))
+		require.NoError(t, err)
+		want += s
+	}
+	assert.Equal(t, int64(len(want)), buf.Len())
+	assert.Equal(t, int64(24), buf.Usage().Disk)
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+
+	t.Run("read all", func(t *testing.T) {
+		got, err := io.ReadAll(buf.NewReader())
+		require.NoError(t, err)
+		assert.Equal(t, want, string(got))
+	})
+
+	t.Run("read in small chunks", func(t *testing.T) {
+		got, err := io.ReadAll(iotest.OneByteReader(buf.NewReader()))
+		require.NoError(t, err)
+		assert.Equal(t, want, string(got))
+	})
+
+	t.Run("reader does not read further than size at creation", func(t *testing.T) {
+		r := buf.NewReader()
+		_, err := buf.Write([]byte("z"))
+		require.NoError(t, err)
+		got, err := io.ReadAll(r)
+		require.NoError(t, err)
+		assert.Equal(t, want, string(got))
+		want += "z"
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		ch := buf.Stream()
+		var got string
+		for len(got) < len(want) {
+			got += string(<-ch)
+		}
+		assert.Equal(t, want, got)
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		require.NoError(t, buf.Remove())
+		_, err := os.Stat(dir)
+		assert.True(t, os.IsNotExist(err))
+	})
+}
+
+// TestBuffer_MultipleStreams tests that every streamer is notified of writes,
+// and that a streamer that isn't being read doesn't hold up the others.
+func TestBuffer_SpillFailure(t *testing.T) {
+	t.Parallel()
+
+	// The segment directory cannot be created beneath a regular file.
+	file := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, os.WriteFile(file, nil, 0o644))
+	buf := newBuffer(filepath.Join(file, "stdout"), 10, logging.Discard)
+
+	// Output is retained in memory despite failing to spill it to disk.
+	var want string
+	for _, s := range []string{"abcdefghijkl", "mnopqrstuvwx"} {
+		n, err := buf.Write([]byte(s))
+		require.NoError(t, err)
+		assert.Equal(t, len(s), n)
+		want += s
+	}
+	assert.True(t, buf.spillFailed)
+	assert.Equal(t, int64(0), buf.Usage().Disk)
+
+	got, err := io.ReadAll(buf.NewReader())
+	require.NoError(t, err)
+	assert.Equal(t, want, string(got))
+}
+
+func TestBuffer_MultipleStreams(t *testing.T) {
+	t.Parallel()
+
+	buf := newBuffer("", 0, logging.Discard)
+	ch1 := buf.Stream()
+	ch2 := buf.Stream()
+	// Never read from this streamer.
+	_ = buf.Stream()
+
+	for _, s := range []string{"hello", "world"} {
+		_, err := buf.Write([]byte(s))
+		require.NoError(t, err)
+
+		assert.Equal(t, s, string(<-ch1))
+		assert.Equal(t, s, string(<-ch2))
+	}
+
+	buf.Close()
+
+	assert.Nil(t, <-ch1)
+	assert.Nil(t, <-ch2)
+}
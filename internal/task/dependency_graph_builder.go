@@ -23,7 +23,10 @@ func createDependentTasks(svc taskCreator, reverse bool, specs ...Spec) ([]*Task
 	for _, spec := range specs {
 		node, ok := b.nodes[spec.ModuleID]
 		if !ok {
-			node = &dependencyGraphNode{dependencies: spec.Dependencies.ModuleIDs}
+			node = &dependencyGraphNode{}
+			if spec.Dependencies != nil {
+				node.dependencies = spec.Dependencies.ModuleIDs
+			}
 		}
 		node.specs = append(node.specs, spec)
 		b.nodes[spec.ModuleID] = node
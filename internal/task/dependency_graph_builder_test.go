@@ -70,6 +70,22 @@ func TestNewGroupWithDependencies(t *testing.T) {
 			_ = hasDependencies(t, got, mqID)
 		}
 	})
+
+	t.Run("group mixing specs with and without dependencies", func(t *testing.T) {
+		// A module without dependencies need not specify dependencies.
+		g, err := newGroup(&fakeTaskCreator{},
+			Spec{ModuleID: vpcID},
+			mysqlSpec,
+			Spec{ModuleID: mqID},
+		)
+		require.NoError(t, err)
+
+		if assert.Len(t, g.Tasks, 3) {
+			vpcTask := hasDependencies(t, g.Tasks, vpcID)
+			_ = hasDependencies(t, g.Tasks, mysqlID, vpcTask)
+			_ = hasDependencies(t, g.Tasks, mqID)
+		}
+	})
 }
 
 func hasDependencies(t *testing.T, got []*Task, wantModuleID resource.ID, deps ...resource.MonotonicID) resource.MonotonicID {
@@ -1,7 +1,9 @@
 package task
 
 import (
+	"cmp"
 	"context"
+	"slices"
 
 	"github.com/leg100/pug/internal/resource"
 )
@@ -16,8 +18,38 @@ import (
 // successfully.
 //
 // Otherwise the enqueuer leaves the task in a pending state.
+//
+// Rather than re-examine every task upon every task event, the enqueuer
+// maintains its state incrementally: it tracks which tasks are blocking which
+// modules and workspaces, and for each pending task the number of its
+// dependencies yet to finish. Only those pending tasks affected by an event are
+// re-examined.
 type enqueuer struct {
 	tasks enqueuerTaskService
+
+	// initialized is true once the enqueuer has been seeded with existing tasks.
+	initialized bool
+	// pending tasks, keyed by task ID
+	pending map[resource.ID]*pendingTask
+	// pending tasks keyed by module ID and by workspace ID, to be
+	// re-examined when the module or workspace is unblocked.
+	pendingByModule    map[resource.ID]map[resource.ID]*pendingTask
+	pendingByWorkspace map[resource.ID]map[resource.ID]*pendingTask
+	// moduleHolders and workspaceHolders are the active blocking tasks that
+	// are blocking a module or workspace: the keys are the IDs of the modules
+	// and workspaces and the values are the IDs of the tasks blocking the
+	// respective module or workspace.
+	moduleHolders    map[resource.ID]map[resource.ID]struct{}
+	workspaceHolders map[resource.ID]map[resource.ID]struct{}
+	// holding maps the ID of each active blocking task to the task.
+	holding map[resource.ID]*Task
+	// dependents maps task IDs to the pending tasks that depend on the task.
+	dependents map[resource.ID][]*pendingTask
+	// dirty are pending tasks to be re-examined.
+	dirty map[resource.ID]*pendingTask
+	// seq is incremented for each pending task, to order pending tasks by
+	// oldest first.
+	seq uint64
 }
 
 type enqueuerTaskService interface {
@@ -26,12 +58,31 @@ type enqueuerTaskService interface {
 	Get(taskID resource.ID) (*Task, error)
 }
 
+// pendingTask is a task in a pending state.
+type pendingTask struct {
+	*Task
+
+	seq uint64
+	// unfinished is the number of the task's dependencies that are yet to
+	// finish.
+	unfinished int
+}
+
 func StartEnqueuer(tasks *Service) {
 	e := enqueuer{tasks: tasks}
 	sub := tasks.TaskBroker.Subscribe(context.Background())
 
 	go func() {
-		for range sub {
+		var dropped uint64
+		for event := range sub {
+			// The enqueuer's state is only accurate if it has received every
+			// event. If events have been dropped then re-seed the enqueuer
+			// from the tasks table.
+			if n := tasks.TaskBroker.Metrics().Dropped; n != dropped {
+				dropped = n
+				e.initialized = false
+			}
+			e.handle(event)
 			for _, t := range e.enqueuable() {
 				tasks.Enqueue(t.ID)
 			}
@@ -39,101 +90,256 @@ func StartEnqueuer(tasks *Service) {
 	}()
 }
 
-// enqueuable returns a list of a tasks to be moved from the pending state to the
-// queued state.
-func (e *enqueuer) enqueuable() []*Task {
-	// Retrieve active tasks
+// init seeds the enqueuer with existing tasks.
+func (e *enqueuer) init() {
+	if e.initialized {
+		return
+	}
+	e.initialized = true
+	e.pending = make(map[resource.ID]*pendingTask)
+	e.pendingByModule = make(map[resource.ID]map[resource.ID]*pendingTask)
+	e.pendingByWorkspace = make(map[resource.ID]map[resource.ID]*pendingTask)
+	e.moduleHolders = make(map[resource.ID]map[resource.ID]struct{})
+	e.workspaceHolders = make(map[resource.ID]map[resource.ID]struct{})
+	e.holding = make(map[resource.ID]*Task)
+	e.dependents = make(map[resource.ID][]*pendingTask)
+	e.dirty = make(map[resource.ID]*pendingTask)
+
 	active := e.tasks.List(ListOptions{
 		Status: []Status{Queued, Running},
 	})
-	// blockedModules are those modules blocked by tasks: the keys are the IDs
-	// of the modules and the values are the IDs of tasks blocking the
-	// respective module.
-	blockedModules := make(map[resource.ID]struct{})
-	// blockedWorkspaces are those workspaces blocked by tasks: the keys are the IDs
-	// of the workspaces and the values are the IDs of tasks blocking the
-	// respective workspace.
-	blockedWorkspaces := make(map[resource.ID]struct{})
-	// Populate set of currently blocked workspaces/modules.
 	for _, t := range active {
 		if t.Blocking {
-			if t.ModuleID != nil {
-				blockedModules[t.ModuleID] = struct{}{}
-			}
-			if t.WorkspaceID != nil {
-				blockedWorkspaces[t.WorkspaceID] = struct{}{}
-			}
+			e.hold(t)
 		}
 	}
-	// Retrieve pending tasks in order of oldest first.
 	pending := e.tasks.List(ListOptions{
 		Status: []Status{Pending},
 		Oldest: true,
 	})
+	for _, t := range pending {
+		e.addPending(t)
+	}
+}
+
+// handle updates the enqueuer's state in response to a task event.
+func (e *enqueuer) handle(event resource.Event[*Task]) {
+	e.init()
+
+	t := event.Payload
+	if event.Type == resource.DeletedEvent {
+		e.removePending(t.ID)
+		e.release(t.ID)
+		return
+	}
+	// Take a snapshot of the task's state, which is liable to change while the
+	// event is handled.
+	switch state := t.CurrentState(); {
+	case state == Pending:
+		if _, ok := e.pending[t.ID]; !ok {
+			e.addPending(t)
+		}
+	case state == Queued || state == Running:
+		e.removePending(t.ID)
+		if t.Blocking {
+			e.hold(t)
+		}
+	case state.IsFinal():
+		e.removePending(t.ID)
+		e.release(t.ID)
+		e.finish(t.ID, state)
+	}
+}
+
+// enqueuable returns a list of a tasks to be moved from the pending state to the
+// queued state.
+func (e *enqueuer) enqueuable() []*Task {
+	e.init()
+
+	// Examine dirty pending tasks in order of oldest first.
+	dirty := make([]*pendingTask, 0, len(e.dirty))
+	for _, pt := range e.dirty {
+		dirty = append(dirty, pt)
+	}
+	clear(e.dirty)
+	slices.SortFunc(dirty, func(a, b *pendingTask) int {
+		return cmp.Compare(a.seq, b.seq)
+	})
+
 	// Build list of tasks to enqueue
 	var enqueue []*Task
-	for _, t := range pending {
-		if t.Immediate {
+	for _, pt := range dirty {
+		if _, ok := e.pending[pt.ID]; !ok {
+			// No longer pending
+			continue
+		}
+		if pt.Immediate {
 			// Always enqueue immediate tasks.
-			enqueue = append(enqueue, t)
+			enqueue = append(enqueue, pt.Task)
+			e.removePending(pt.ID)
 			continue
 		}
-		if t.WorkspaceID != nil {
-			if _, ok := blockedWorkspaces[t.WorkspaceID]; ok {
-				// Don't enqueue task belonging to workspace blocked by another task
-				continue
-			}
+		if pt.WorkspaceID != nil && len(e.workspaceHolders[pt.WorkspaceID]) > 0 {
+			// Don't enqueue task belonging to workspace blocked by another task
+			continue
 		}
-		if t.ModuleID != nil {
-			if _, ok := blockedModules[t.ModuleID]; ok {
-				// Don't enqueue task belonging to module blocked by another task
-				continue
-			}
+		if pt.ModuleID != nil && len(e.moduleHolders[pt.ModuleID]) > 0 {
+			// Don't enqueue task belonging to module blocked by another task
+			continue
 		}
-		if !e.enqueueDependentTask(t) {
+		if pt.unfinished > 0 {
 			// Don't enqueue task with dependencies on other tasks that have yet
-			// to complete or have failed.
+			// to complete.
 			continue
 		}
 		// Enqueue task.
-		enqueue = append(enqueue, t)
-		// Blocking tasks can block workspaces and modules.
-		if t.Blocking {
-			if t.WorkspaceID != nil {
-				// Task blocks workspace; no further tasks belonging to workspace
-				// shall be enqueued.
-				blockedWorkspaces[t.WorkspaceID] = struct{}{}
-			}
-			if t.ModuleID != nil {
-				// Task blocks module; no further tasks belonging to module
-				// shall be enqueued.
-				blockedModules[t.ModuleID] = struct{}{}
-			}
+		enqueue = append(enqueue, pt.Task)
+		e.removePending(pt.ID)
+		// Blocking tasks can block workspaces and modules; no further tasks
+		// belonging to the workspace or module shall be enqueued.
+		if pt.Blocking {
+			e.hold(pt.Task)
 		}
 	}
 	return enqueue
 }
 
-func (e *enqueuer) enqueueDependentTask(t *Task) bool {
+// addPending adds a pending task, counting its unfinished dependencies.
+func (e *enqueuer) addPending(t *Task) {
+	e.seq++
+	pt := &pendingTask{Task: t, seq: e.seq}
 	for _, id := range t.DependsOn {
 		dependency, err := e.tasks.Get(id)
 		if err != nil {
-			// TODO: decide what to do in case of error
-			return false
+			// Dependency no longer exists, e.g. it has been pruned, so it
+			// can never finish: treat it as failed.
+			cancelDependentTask(t)
+			return
 		}
-		switch dependency.State {
+		switch dependency.CurrentState() {
 		case Exited:
 			// Is enqueuable if all dependencies have exited successfully.
 		case Canceled, Errored:
-			// Dependency failed so mark task as failed too by cancelling it
-			// along with a reason why it was canceled.
-			t.stdout.Write([]byte("task dependency failed"))
-			t.updateState(Canceled)
-			return false
+			// Dependency failed so mark task as failed too.
+			cancelDependentTask(t)
+			return
 		default:
-			// Not enqueueable
-			return false
+			pt.unfinished++
+			e.dependents[id] = append(e.dependents[id], pt)
+		}
+	}
+	e.pending[t.ID] = pt
+	if t.ModuleID != nil {
+		addToIndex(e.pendingByModule, t.ModuleID, t.ID, pt)
+	}
+	if t.WorkspaceID != nil {
+		addToIndex(e.pendingByWorkspace, t.WorkspaceID, t.ID, pt)
+	}
+	e.dirty[t.ID] = pt
+}
+
+func (e *enqueuer) removePending(taskID resource.ID) {
+	pt, ok := e.pending[taskID]
+	if !ok {
+		return
+	}
+	delete(e.pending, taskID)
+	delete(e.dirty, taskID)
+	if pt.ModuleID != nil {
+		removeFromIndex(e.pendingByModule, pt.ModuleID, taskID)
+	}
+	if pt.WorkspaceID != nil {
+		removeFromIndex(e.pendingByWorkspace, pt.WorkspaceID, taskID)
+	}
+}
+
+// hold records an active blocking task as blocking its module and workspace.
+func (e *enqueuer) hold(t *Task) {
+	if _, ok := e.holding[t.ID]; ok {
+		return
+	}
+	e.holding[t.ID] = t
+	if t.ModuleID != nil {
+		addToIndex(e.moduleHolders, t.ModuleID, t.ID, struct{}{})
+	}
+	if t.WorkspaceID != nil {
+		addToIndex(e.workspaceHolders, t.WorkspaceID, t.ID, struct{}{})
+	}
+}
+
+// release unblocks the module and workspace blocked by a task, marking their
+// pending tasks for re-examination.
+func (e *enqueuer) release(taskID resource.ID) {
+	t, ok := e.holding[taskID]
+	if !ok {
+		return
+	}
+	delete(e.holding, taskID)
+	if t.ModuleID != nil {
+		removeFromIndex(e.moduleHolders, t.ModuleID, taskID)
+		for id, pt := range e.pendingByModule[t.ModuleID] {
+			e.dirty[id] = pt
+		}
+	}
+	if t.WorkspaceID != nil {
+		removeFromIndex(e.workspaceHolders, t.WorkspaceID, taskID)
+		for id, pt := range e.pendingByWorkspace[t.WorkspaceID] {
+			e.dirty[id] = pt
 		}
 	}
-	return true
+}
+
+// finish updates the tasks that depend on a finished task.
+func (e *enqueuer) finish(taskID resource.ID, state Status) {
+	dependents, ok := e.dependents[taskID]
+	if !ok {
+		return
+	}
+	delete(e.dependents, taskID)
+	for _, pt := range dependents {
+		if _, ok := e.pending[pt.ID]; !ok {
+			continue
+		}
+		switch state {
+		case Exited:
+			pt.unfinished--
+			if pt.unfinished == 0 {
+				e.dirty[pt.ID] = pt
+			}
+		case Canceled, Errored:
+			// Dependency failed so mark task as failed too. The task's own
+			// dependents are canceled in turn upon handling the event for the
+			// task's cancelation.
+			e.removePending(pt.ID)
+			cancelDependentTask(pt.Task)
+		}
+	}
+}
+
+// cancelDependentTask cancels a task along with a reason why it was canceled.
+func cancelDependentTask(t *Task) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.State.IsFinal() {
+		return
+	}
+	t.stdout.Write([]byte("task dependency failed"))
+	t.updateState(Canceled)
+}
+
+func addToIndex[V any](index map[resource.ID]map[resource.ID]V, key, id resource.ID, v V) {
+	m, ok := index[key]
+	if !ok {
+		m = make(map[resource.ID]V)
+		index[key] = m
+	}
+	m[id] = v
+}
+
+func removeFromIndex[V any](index map[resource.ID]map[resource.ID]V, key, id resource.ID) {
+	delete(index[key], id)
+	if len(index[key]) == 0 {
+		delete(index, key)
+	}
 }
@@ -2,9 +2,9 @@ package task
 
 import (
 	"slices"
+	"sync/atomic"
 	"testing"
 
-	"github.com/leg100/pug/internal"
 	"github.com/leg100/pug/internal/resource"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -120,7 +120,7 @@ func TestEnqueuer(t *testing.T) {
 }
 
 func newTestTask(t *testing.T, spec Spec) *Task {
-	f := &factory{counter: internal.Int(0)}
+	f := &factory{counter: &atomic.Int64{}}
 	task, err := f.newTask(spec)
 	require.NoError(t, err)
 	return task
@@ -148,3 +148,89 @@ func (f *fakeEnqueuerTaskService) Get(id resource.ID) (*Task, error) {
 	}
 	return nil, resource.ErrNotFound
 }
+
+func TestEnqueuer_Incremental(t *testing.T) {
+	t.Parallel()
+
+	mod1ID := resource.NewMonotonicID(resource.Module)
+	ws1ID := resource.NewMonotonicID(resource.Workspace)
+
+	svc := &fakeEnqueuerTaskService{}
+	e := enqueuer{tasks: svc}
+
+	// create adds a pending task and notifies the enqueuer
+	create := func(spec Spec) *Task {
+		task := newTestTask(t, spec)
+		svc.pending = append(svc.pending, task)
+		e.handle(resource.Event[*Task]{Type: resource.CreatedEvent, Payload: task})
+		return task
+	}
+	// update changes the state of a task and notifies the enqueuer
+	update := func(task *Task, state Status) {
+		task.State = state
+		e.handle(resource.Event[*Task]{Type: resource.UpdatedEvent, Payload: task})
+	}
+
+	blocking1 := create(Spec{ModuleID: mod1ID, WorkspaceID: ws1ID, Blocking: true})
+	blocking2 := create(Spec{ModuleID: mod1ID, WorkspaceID: ws1ID, Blocking: true})
+	dependent := create(Spec{dependsOn: []resource.ID{blocking1.ID}})
+
+	// Only first blocking task can be enqueued.
+	assert.Equal(t, []*Task{blocking1}, e.enqueuable())
+	update(blocking1, Queued)
+	assert.Empty(t, e.enqueuable())
+	update(blocking1, Running)
+	assert.Empty(t, e.enqueuable())
+
+	// Finishing the first blocking task unblocks the second blocking task
+	// and satisfies the dependent task's dependency.
+	update(blocking1, Exited)
+	assert.Equal(t, []*Task{blocking2, dependent}, e.enqueuable())
+	update(blocking2, Queued)
+	update(dependent, Queued)
+
+	// A task depending on a failed task is canceled.
+	failed := create(Spec{ModuleID: mod1ID, WorkspaceID: ws1ID})
+	canceled := create(Spec{dependsOn: []resource.ID{failed.ID}})
+	// The workspace is still blocked by the second blocking task.
+	assert.Empty(t, e.enqueuable())
+	update(failed, Errored)
+	assert.Empty(t, e.enqueuable())
+	assert.Equal(t, Canceled, canceled.State)
+
+	// A task depending on a non-existent task is canceled.
+	orphan := create(Spec{dependsOn: []resource.ID{resource.NewMonotonicID(resource.Task)}})
+	assert.Empty(t, e.enqueuable())
+	assert.Equal(t, Canceled, orphan.State)
+}
+
+// BenchmarkEnqueuer measures handling a task event in a session with many
+// finished tasks.
+func BenchmarkEnqueuer(b *testing.B) {
+	svc := &fakeEnqueuerTaskService{}
+	for range 10_000 {
+		task := &Task{
+			ID:       resource.NewMonotonicID(resource.Task),
+			ModuleID: resource.NewMonotonicID(resource.Module),
+			State:    Exited,
+		}
+		svc.other = append(svc.other, task)
+	}
+	e := enqueuer{tasks: svc}
+	modID := resource.NewMonotonicID(resource.Module)
+
+	b.ResetTimer()
+	for range b.N {
+		task := &Task{
+			ID:       resource.NewMonotonicID(resource.Task),
+			ModuleID: modID,
+			State:    Pending,
+			Blocking: true,
+		}
+		e.handle(resource.Event[*Task]{Type: resource.CreatedEvent, Payload: task})
+		for _, enqueued := range e.enqueuable() {
+			enqueued.State = Exited
+			e.handle(resource.Event[*Task]{Type: resource.UpdatedEvent, Payload: enqueued})
+		}
+	}
+}
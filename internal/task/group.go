@@ -17,7 +17,7 @@ type Group struct {
 	CreateErrors []error
 }
 
-func newGroup(service *Service, specs ...Spec) (*Group, error) {
+func newGroup(service taskCreator, specs ...Spec) (*Group, error) {
 	if len(specs) == 0 {
 		return nil, errors.New("no specs provided")
 	}
@@ -25,30 +25,23 @@ func newGroup(service *Service, specs ...Spec) (*Group, error) {
 		ID:      resource.NewMonotonicID(resource.TaskGroup),
 		Created: time.Now(),
 	}
-	// Validate specifications. There are some settings that are incompatible
-	// with one another within a task group.
-	var (
-		respectModuleDependencies *bool
-		inverseDependencyOrder    *bool
-	)
+	// Module dependencies are respected if any spec specifies dependencies;
+	// specs that don't are treated as belonging to modules without
+	// dependencies. All specs specifying dependencies must set
+	// InverseDependencyOrder to the same value.
+	var inverseDependencyOrder *bool
 	for _, spec := range specs {
-		// All specs must specify Dependencies or not specify Dependencies.
-		deps := (spec.Dependencies != nil)
-		if respectModuleDependencies == nil {
-			respectModuleDependencies = &deps
-		} else if *respectModuleDependencies != deps {
-			return nil, fmt.Errorf("not all specs share same respect-module-dependencies setting")
+		if spec.Dependencies == nil {
+			continue
 		}
-		// All specs specifying dependencies must set InverseDependencyOrder to
-		// the same value
-		inverse := (spec.Dependencies != nil && spec.Dependencies.InverseDependencyOrder)
+		inverse := spec.Dependencies.InverseDependencyOrder
 		if inverseDependencyOrder == nil {
 			inverseDependencyOrder = &inverse
 		} else if *inverseDependencyOrder != inverse {
 			return nil, fmt.Errorf("not all specs share same inverse-dependency-order setting")
 		}
 	}
-	if *respectModuleDependencies {
+	if inverseDependencyOrder != nil {
 		tasks, err := createDependentTasks(service, *inverseDependencyOrder, specs...)
 		if err != nil {
 			return nil, err
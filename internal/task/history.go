@@ -0,0 +1,328 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/leg100/pug/internal/logging"
+	"github.com/leg100/pug/internal/resource"
+)
+
+const (
+	// historyFile is the name of the file beneath the data directory in which
+	// task durations are persisted.
+	historyFile = "durations.json"
+	// maxDurationSamples is the maximum number of durations recorded for each
+	// history key.
+	maxDurationSamples = 10
+	// overdueFactor is the multiple of its estimated duration beyond which a
+	// running task is deemed overdue.
+	overdueFactor = 2
+)
+
+// HistoryKey identifies tasks whose durations are comparable, i.e. the same
+// type of task run on the same workspace.
+type HistoryKey struct {
+	ModulePath    string     `json:"module"`
+	WorkspaceName string     `json:"workspace,omitempty"`
+	Identifier    Identifier `json:"identifier"`
+}
+
+// History records how long tasks take to run, persisting durations across pug
+// sessions, and estimates how long tasks and task groups will take to finish.
+type History struct {
+	path     string
+	logger   logging.Interface
+	key      func(*Task) (HistoryKey, bool)
+	maxTasks int
+
+	durations map[HistoryKey][]time.Duration
+	// recorded are the IDs of the tasks whose durations have been recorded,
+	// to avoid recording a task more than once.
+	recorded map[resource.ID]struct{}
+	mu       sync.Mutex
+}
+
+type HistoryOptions struct {
+	// DataDir is the directory in which durations are persisted. If empty
+	// then durations are only held in memory.
+	DataDir string
+	Logger  logging.Interface
+	// MaxTasks is the maximum number of tasks that run in parallel.
+	MaxTasks int
+	// Key determines the history key for a task. False is returned if the
+	// task's duration is not to be recorded.
+	Key func(*Task) (HistoryKey, bool)
+}
+
+// historyRecord is the persisted form of the durations for a history key.
+type historyRecord struct {
+	HistoryKey
+	Durations []time.Duration `json:"durations"`
+}
+
+// NewHistory constructs a history of task durations, loading any previously
+// persisted durations.
+func NewHistory(opts HistoryOptions) *History {
+	h := &History{
+		logger:    opts.Logger,
+		key:       opts.Key,
+		maxTasks:  opts.MaxTasks,
+		durations: make(map[HistoryKey][]time.Duration),
+		recorded:  make(map[resource.ID]struct{}),
+	}
+	if opts.DataDir != "" {
+		h.path = filepath.Join(opts.DataDir, historyFile)
+		if err := h.load(); err != nil {
+			h.logger.Error("loading task durations", "error", err, "path", h.path)
+		}
+	}
+	return h
+}
+
+// StartHistory records the duration of each task that finishes successfully,
+// until the context is canceled. It returns a function that waits for the
+// history to stop recording, so that durations are not being saved when the
+// caller exits.
+func StartHistory(ctx context.Context, tasks *Service, h *History) func() {
+	sub := tasks.TaskBroker.Subscribe(ctx)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		for event := range sub {
+			switch {
+			case event.Type == resource.DeletedEvent:
+				h.forget(event.Payload)
+			case event.Type == resource.UpdatedEvent && event.Payload.CurrentState() == Exited:
+				h.record(event.Payload)
+			}
+		}
+	}()
+	return func() { <-done }
+}
+
+func (h *History) load() error {
+	data, err := os.ReadFile(h.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	var records []historyRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		return err
+	}
+	for _, r := range records {
+		h.durations[r.HistoryKey] = r.Durations
+	}
+	return nil
+}
+
+// save persists the durations, writing to a temporary file first to avoid
+// leaving a partially written file.
+func (h *History) save() error {
+	records := make([]historyRecord, 0, len(h.durations))
+	for key, durations := range h.durations {
+		records = append(records, historyRecord{HistoryKey: key, Durations: durations})
+	}
+	data, err := json.Marshal(records)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(h.path), 0o755); err != nil {
+		return err
+	}
+	tmp := h.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, h.path)
+}
+
+// record records how long a task ran for. A task is only recorded once.
+func (h *History) record(t *Task) {
+	key, ok := h.key(t)
+	if !ok {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.recorded[t.ID]; ok {
+		return
+	}
+	h.recorded[t.ID] = struct{}{}
+
+	durations := append(h.durations[key], t.Elapsed(Running))
+	if len(durations) > maxDurationSamples {
+		durations = durations[len(durations)-maxDurationSamples:]
+	}
+	h.durations[key] = durations
+
+	if h.path == "" {
+		return
+	}
+	if err := h.save(); err != nil {
+		h.logger.Error("saving task durations", "error", err, "path", h.path)
+	}
+}
+
+// forget removes a deleted task from the set of recorded tasks.
+func (h *History) forget(t *Task) {
+	// A deleted event for a non-existent task has a nil payload.
+	if t == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delete(h.recorded, t.ID)
+}
+
+// Estimate estimates how long a task takes to run, using the median of its
+// recorded durations. False is returned if there are no recorded durations.
+func (h *History) Estimate(t *Task) (time.Duration, bool) {
+	key, ok := h.key(t)
+	if !ok {
+		return 0, false
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	durations := h.durations[key]
+	if len(durations) == 0 {
+		return 0, false
+	}
+	sorted := slices.Clone(durations)
+	slices.Sort(sorted)
+	return sorted[len(sorted)/2], true
+}
+
+// ETA is an estimate of the time remaining until a task finishes.
+type ETA struct {
+	Remaining time.Duration
+	// Overdue is true if the task has been running for much longer than
+	// usual.
+	Overdue bool
+}
+
+// TaskETA estimates the time remaining until a task finishes: for a running
+// task, the estimated duration less how long it has been running; otherwise
+// the estimated duration. False is returned if the task has finished or there
+// is no estimate.
+func (h *History) TaskETA(t *Task) (ETA, bool) {
+	state := t.CurrentState()
+	if state.IsFinal() {
+		return ETA{}, false
+	}
+	estimate, ok := h.Estimate(t)
+	if !ok {
+		return ETA{}, false
+	}
+	if state != Running {
+		return ETA{Remaining: estimate}, true
+	}
+	elapsed := t.Elapsed(Running)
+	return ETA{
+		Remaining: max(0, estimate-elapsed),
+		Overdue:   elapsed > overdueFactor*estimate,
+	}, true
+}
+
+// GroupETA estimates the time remaining until all the tasks in a task group
+// finish, simulating the order in which the unfinished tasks would run, given
+// their dependencies and the maximum number of tasks that run in parallel.
+// Tasks without an estimate are assumed to take the median estimate of the
+// other tasks. False is returned if the group has finished or there are no
+// estimates.
+func (h *History) GroupETA(g *Group) (time.Duration, bool) {
+	var (
+		remaining = make(map[resource.ID]time.Duration)
+		known     []time.Duration
+		unknown   []*Task
+		running   []*Task
+		waiting   []*Task
+	)
+	for _, t := range g.Tasks {
+		state := t.CurrentState()
+		if state.IsFinal() {
+			continue
+		}
+		eta, ok := h.TaskETA(t)
+		if !ok {
+			unknown = append(unknown, t)
+		} else {
+			remaining[t.ID] = eta.Remaining
+			known = append(known, eta.Remaining)
+		}
+		if state == Running {
+			running = append(running, t)
+		} else {
+			waiting = append(waiting, t)
+		}
+	}
+	if len(known) == 0 {
+		return 0, false
+	}
+	slices.Sort(known)
+	for _, t := range unknown {
+		remaining[t.ID] = known[len(known)/2]
+	}
+	return simulate(running, waiting, remaining, max(h.maxTasks, 1)), true
+}
+
+// simulate simulates running tasks, returning the time at which the last task
+// finishes. Running tasks finish after their remaining time; waiting tasks
+// start, in order, once a slot is free and any of their dependencies in the
+// simulation have finished.
+func simulate(running, waiting []*Task, remaining map[resource.ID]time.Duration, slots int) time.Duration {
+	var (
+		now      time.Duration
+		finishes = make(map[resource.ID]time.Duration)
+		finished = make(map[resource.ID]bool)
+	)
+	for _, t := range running {
+		finishes[t.ID] = remaining[t.ID]
+	}
+	ready := func(t *Task) bool {
+		for _, id := range t.DependsOn {
+			if _, ok := remaining[id]; ok && !finished[id] {
+				return false
+			}
+		}
+		return true
+	}
+	for len(finishes) > 0 || len(waiting) > 0 {
+		// Start as many ready tasks as there are free slots.
+		for i := 0; i < len(waiting) && len(finishes) < slots; {
+			if t := waiting[i]; ready(t) {
+				finishes[t.ID] = now + remaining[t.ID]
+				waiting = slices.Delete(waiting, i, i+1)
+				continue
+			}
+			i++
+		}
+		if len(finishes) == 0 {
+			// Remaining tasks can never start.
+			break
+		}
+		// Advance to the next task to finish.
+		var next resource.ID
+		for id, at := range finishes {
+			if next == nil || at < finishes[next] {
+				next = id
+			}
+		}
+		now = finishes[next]
+		finished[next] = true
+		delete(finishes, next)
+	}
+	return now
+}
@@ -0,0 +1,143 @@
+package task
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/leg100/pug/internal/logging"
+	"github.com/leg100/pug/internal/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistory(t *testing.T) {
+	// Key tasks by their description, to keep the tests simple.
+	key := func(t *Task) (HistoryKey, bool) {
+		return HistoryKey{ModulePath: t.Description, Identifier: t.Identifier}, t.Description != ""
+	}
+	f := factory{counter: &atomic.Int64{}}
+	newTask := func(t *testing.T, description string, state Status, running time.Duration, deps ...*Task) *Task {
+		spec := Spec{Description: description, Identifier: "apply"}
+		for _, dep := range deps {
+			spec.dependsOn = append(spec.dependsOn, dep.ID)
+		}
+		task, err := f.newTask(spec)
+		require.NoError(t, err)
+		task.State = state
+		if state == Running || state.IsFinal() {
+			started := time.Now().Add(-running)
+			ts := statusTimestamps{started: started}
+			if state.IsFinal() {
+				ts.ended = time.Now()
+			}
+			task.timestamps[Running] = ts
+		}
+		return task
+	}
+
+	t.Run("estimate", func(t *testing.T) {
+		h := NewHistory(HistoryOptions{Logger: logging.Discard, Key: key})
+		task := newTask(t, "a", Exited, 0)
+		_, ok := h.Estimate(task)
+		assert.False(t, ok)
+
+		for _, d := range []time.Duration{5, 1, 3} {
+			h.record(newTask(t, "a", Exited, d*time.Second))
+		}
+		got, ok := h.Estimate(task)
+		require.True(t, ok)
+		assert.InDelta(t, 3*time.Second, got, float64(100*time.Millisecond))
+	})
+
+	t.Run("maximum samples", func(t *testing.T) {
+		h := NewHistory(HistoryOptions{Logger: logging.Discard, Key: key})
+		for range maxDurationSamples + 5 {
+			h.record(newTask(t, "a", Exited, time.Second))
+		}
+		assert.Len(t, h.durations[HistoryKey{ModulePath: "a", Identifier: "apply"}], maxDurationSamples)
+	})
+
+	t.Run("record task once", func(t *testing.T) {
+		h := NewHistory(HistoryOptions{Logger: logging.Discard, Key: key})
+		task := newTask(t, "a", Exited, time.Second)
+		h.record(task)
+		h.record(task)
+		assert.Len(t, h.durations[HistoryKey{ModulePath: "a", Identifier: "apply"}], 1)
+	})
+
+	t.Run("persist", func(t *testing.T) {
+		dir := t.TempDir()
+		h := NewHistory(HistoryOptions{DataDir: dir, Logger: logging.Discard, Key: key})
+		h.record(newTask(t, "a", Exited, 2*time.Second))
+
+		// Durations should be loaded by a new history.
+		h = NewHistory(HistoryOptions{DataDir: dir, Logger: logging.Discard, Key: key})
+		got, ok := h.Estimate(newTask(t, "a", Pending, 0))
+		require.True(t, ok)
+		assert.InDelta(t, 2*time.Second, got, float64(100*time.Millisecond))
+	})
+
+	t.Run("task eta", func(t *testing.T) {
+		h := NewHistory(HistoryOptions{Logger: logging.Discard, Key: key})
+		h.record(newTask(t, "a", Exited, 10*time.Second))
+
+		eta, ok := h.TaskETA(newTask(t, "a", Queued, 0))
+		require.True(t, ok)
+		assert.InDelta(t, 10*time.Second, eta.Remaining, float64(100*time.Millisecond))
+		assert.False(t, eta.Overdue)
+
+		eta, ok = h.TaskETA(newTask(t, "a", Running, 4*time.Second))
+		require.True(t, ok)
+		assert.InDelta(t, 6*time.Second, eta.Remaining, float64(100*time.Millisecond))
+		assert.False(t, eta.Overdue)
+
+		eta, ok = h.TaskETA(newTask(t, "a", Running, 30*time.Second))
+		require.True(t, ok)
+		assert.Equal(t, time.Duration(0), eta.Remaining)
+		assert.True(t, eta.Overdue)
+
+		_, ok = h.TaskETA(newTask(t, "a", Exited, 0))
+		assert.False(t, ok)
+	})
+
+	t.Run("group eta", func(t *testing.T) {
+		h := NewHistory(HistoryOptions{Logger: logging.Discard, Key: key, MaxTasks: 2})
+		h.record(newTask(t, "a", Exited, 10*time.Second))
+		h.record(newTask(t, "b", Exited, 20*time.Second))
+
+		var (
+			a1 = newTask(t, "a", Pending, 0)
+			a2 = newTask(t, "a", Pending, 0)
+			a3 = newTask(t, "a", Pending, 0)
+			// b depends on a1, so starts after a1 finishes.
+			b = newTask(t, "b", Pending, 0, a1)
+			// unknown is assumed to take the median of the other estimates.
+			unknown = newTask(t, "", Pending, 0)
+		)
+		got, ok := h.GroupETA(&Group{Tasks: []*Task{a1, a2, a3, b}})
+		require.True(t, ok)
+		// a1 and a2 run from 0-10s, a3 runs from 10-20s, b runs from 10-30s.
+		assert.InDelta(t, 30*time.Second, got, float64(time.Second))
+
+		got, ok = h.GroupETA(&Group{Tasks: []*Task{a1, unknown}})
+		require.True(t, ok)
+		assert.InDelta(t, 10*time.Second, got, float64(time.Second))
+
+		_, ok = h.GroupETA(&Group{Tasks: []*Task{unknown}})
+		assert.False(t, ok)
+	})
+}
+
+func TestSimulate(t *testing.T) {
+	a := &Task{ID: resource.NewMonotonicID(resource.Task)}
+	b := &Task{ID: resource.NewMonotonicID(resource.Task), DependsOn: []resource.ID{a.ID}}
+	c := &Task{ID: resource.NewMonotonicID(resource.Task)}
+	remaining := map[resource.ID]time.Duration{a.ID: 5, b.ID: 3, c.ID: 4}
+
+	// a is already running and b waits for a. With one slot, all tasks run
+	// one after the other.
+	assert.Equal(t, 12*time.Nanosecond, simulate([]*Task{a}, []*Task{b, c}, remaining, 1))
+	// With two slots, c runs alongside a, and b runs once a finishes.
+	assert.Equal(t, 8*time.Nanosecond, simulate([]*Task{a}, []*Task{b, c}, remaining, 2))
+}
@@ -0,0 +1,160 @@
+package task
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/leg100/pug/internal/resource"
+)
+
+// Preview is a dry run of the creation of a task group. Its tasks are
+// constructed using the same code path as a task group created with
+// CreateGroup, but they are neither persisted nor run.
+type Preview struct {
+	// Group is the task group that would be created.
+	Group *Group
+	// Tasks are the tasks that would be created, including any prerequisite
+	// tasks, ordered by stage.
+	Tasks []*PreviewTask
+	// Stages is the number of stages in which the tasks would run.
+	Stages int
+
+	specs []Spec
+}
+
+// PreviewTask is a task that would be created as part of a task group.
+type PreviewTask struct {
+	*Task
+
+	// Stage is the stage in which the task would run. Tasks without
+	// dependencies are in the first stage, zero, and tasks in the same stage
+	// would run in parallel.
+	Stage int
+	// Dependencies are the tasks that must finish successfully before the
+	// task would start.
+	Dependencies []*Task
+	// BlockedBy are existing tasks that currently block the task's module or
+	// workspace, which must finish before the task would start.
+	BlockedBy []*Task
+}
+
+// previewer constructs tasks without adding them to the service.
+type previewer struct {
+	*factory
+
+	tasks []*Task
+}
+
+func (p *previewer) Create(spec Spec) (*Task, error) {
+	task, err := p.construct(p, spec)
+	if err != nil {
+		return nil, err
+	}
+	p.tasks = append(p.tasks, task)
+	return task, nil
+}
+
+// newPreview constructs a preview from a group and all the tasks constructed
+// for the group. Tasks are checked against the active tasks to determine
+// whether they would be blocked.
+func newPreview(group *Group, tasks []*Task, active []*Task, specs []Spec) *Preview {
+	preview := &Preview{
+		Group: group,
+		Tasks: make([]*PreviewTask, len(tasks)),
+		specs: specs,
+	}
+	// Tasks are constructed after the tasks they depend upon, so each task's
+	// dependencies have already been assigned a stage.
+	byID := make(map[resource.ID]*PreviewTask, len(tasks))
+	for i, t := range tasks {
+		pt := &PreviewTask{Task: t}
+		for _, id := range t.DependsOn {
+			dep, ok := byID[id]
+			if !ok {
+				continue
+			}
+			pt.Dependencies = append(pt.Dependencies, dep.Task)
+			pt.Stage = max(pt.Stage, dep.Stage+1)
+		}
+		if !t.Immediate {
+			for _, a := range active {
+				if (t.WorkspaceID != nil && a.WorkspaceID == t.WorkspaceID) ||
+					(t.ModuleID != nil && a.ModuleID == t.ModuleID) {
+					pt.BlockedBy = append(pt.BlockedBy, a)
+				}
+			}
+		}
+		preview.Stages = max(preview.Stages, pt.Stage+1)
+		byID[t.ID] = pt
+		preview.Tasks[i] = pt
+	}
+	slices.SortStableFunc(preview.Tasks, func(a, b *PreviewTask) int {
+		return a.Stage - b.Stage
+	})
+	return preview
+}
+
+// Stage returns the tasks in the given stage.
+func (p *Preview) Stage(stage int) []*PreviewTask {
+	var tasks []*PreviewTask
+	for _, t := range p.Tasks {
+		if t.Stage == stage {
+			tasks = append(tasks, t)
+		}
+	}
+	return tasks
+}
+
+// PreviewGroup previews the creation of a task group from one or more task
+// specs, without creating any tasks. The preview is retained until it is
+// either confirmed or discarded. The specs are those used to create the task
+// group upon confirmation, so building them must not persist anything.
+func (s *Service) PreviewGroup(specs ...Spec) (*Preview, error) {
+	p := &previewer{factory: s.factory}
+	g, err := newGroup(p, specs...)
+	if err != nil {
+		return nil, err
+	}
+	active := s.List(ListOptions{Status: []Status{Queued, Running}, Blocking: true})
+	preview := newPreview(g, p.tasks, active, specs)
+
+	s.previewsMu.Lock()
+	s.previews[g.ID] = preview
+	s.previewsMu.Unlock()
+
+	s.logger.Debug("previewed task group", "group", g, "stages", preview.Stages)
+
+	return preview, nil
+}
+
+// GetPreview retrieves a task group preview by the ID of the group that would
+// be created.
+func (s *Service) GetPreview(groupID resource.ID) (*Preview, error) {
+	s.previewsMu.Lock()
+	defer s.previewsMu.Unlock()
+
+	preview, ok := s.previews[groupID]
+	if !ok {
+		return nil, fmt.Errorf("task group preview: %w", resource.ErrNotFound)
+	}
+	return preview, nil
+}
+
+// ConfirmPreview creates the task group that was previewed, from the same
+// specs as the preview. The preview is discarded.
+func (s *Service) ConfirmPreview(groupID resource.ID) (*Group, error) {
+	preview, err := s.GetPreview(groupID)
+	if err != nil {
+		return nil, err
+	}
+	s.DiscardPreview(groupID)
+	return s.CreateGroup(preview.specs...)
+}
+
+// DiscardPreview discards a task group preview.
+func (s *Service) DiscardPreview(groupID resource.ID) {
+	s.previewsMu.Lock()
+	defer s.previewsMu.Unlock()
+
+	delete(s.previews, groupID)
+}
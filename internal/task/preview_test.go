@@ -0,0 +1,125 @@
+package task
+
+import (
+	"testing"
+
+	"github.com/leg100/pug/internal/logging"
+	"github.com/leg100/pug/internal/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreviewGroup(t *testing.T) {
+	vpcID := resource.NewMonotonicID(resource.Module)
+	dbID := resource.NewMonotonicID(resource.Module)
+	appID := resource.NewMonotonicID(resource.Module)
+	appWorkspaceID := resource.NewMonotonicID(resource.Workspace)
+
+	specs := []Spec{
+		{ModuleID: vpcID, Dependencies: &Dependencies{}},
+		{ModuleID: dbID, Dependencies: &Dependencies{ModuleIDs: []resource.ID{vpcID}}},
+		{
+			ModuleID:     appID,
+			WorkspaceID:  appWorkspaceID,
+			Dependencies: &Dependencies{ModuleIDs: []resource.ID{vpcID, dbID}},
+			Prerequisite: &Spec{ModuleID: appID, WorkspaceID: appWorkspaceID, Description: "snapshot"},
+			Execution:    Execution{TerraformCommand: []string{"apply"}, Args: []string{"-auto-approve"}},
+			Env:          []string{"TF_VAR_foo=bar"},
+		},
+	}
+
+	setup := func(t *testing.T) *Service {
+		return NewService(ServiceOptions{
+			Program:  "terraform",
+			Logger:   logging.Discard,
+			UserEnvs: []string{"TF_LOG=debug"},
+		})
+	}
+	taskOf := func(t *testing.T, preview *Preview, moduleID resource.ID, description string) *PreviewTask {
+		t.Helper()
+		for _, pt := range preview.Tasks {
+			if pt.ModuleID == moduleID && (description == "" || pt.Description == description) {
+				return pt
+			}
+		}
+		t.Fatalf("no task found for module %s", moduleID)
+		return nil
+	}
+
+	t.Run("stages", func(t *testing.T) {
+		svc := setup(t)
+
+		preview, err := svc.PreviewGroup(specs...)
+		require.NoError(t, err)
+
+		// Prerequisite tasks are included in the preview but not in the group.
+		assert.Len(t, preview.Tasks, 4)
+		assert.Len(t, preview.Group.Tasks, 3)
+		assert.Equal(t, 3, preview.Stages)
+
+		vpc := taskOf(t, preview, vpcID, "")
+		assert.Equal(t, 0, vpc.Stage)
+		db := taskOf(t, preview, dbID, "")
+		assert.Equal(t, 1, db.Stage)
+		assert.Equal(t, []*Task{vpc.Task}, db.Dependencies)
+		snapshot := taskOf(t, preview, appID, "snapshot")
+		assert.Equal(t, 0, snapshot.Stage)
+		app := taskOf(t, preview, appID, "apply")
+		assert.Equal(t, 2, app.Stage)
+		assert.ElementsMatch(t, []*Task{vpc.Task, db.Task, snapshot.Task}, app.Dependencies)
+
+		// Command line and environment are those of the real task.
+		assert.Equal(t, "terraform", app.Program)
+		assert.Equal(t, []string{"apply", "-auto-approve"}, app.Args)
+		assert.Equal(t, []string{"TF_LOG=debug", "TF_VAR_foo=bar"}, app.AdditionalEnv)
+
+		// Tasks are ordered by stage.
+		for i := 1; i < len(preview.Tasks); i++ {
+			assert.LessOrEqual(t, preview.Tasks[i-1].Stage, preview.Tasks[i].Stage)
+		}
+		assert.Len(t, preview.Stage(0), 2)
+
+		// No tasks are created.
+		assert.Empty(t, svc.List(ListOptions{}))
+	})
+
+	t.Run("blocked by active task", func(t *testing.T) {
+		svc := setup(t)
+		active, err := svc.Create(Spec{ModuleID: appID, WorkspaceID: appWorkspaceID, Blocking: true})
+		require.NoError(t, err)
+		active.updateState(Running)
+
+		preview, err := svc.PreviewGroup(specs...)
+		require.NoError(t, err)
+
+		assert.Equal(t, []*Task{active}, taskOf(t, preview, appID, "apply").BlockedBy)
+		assert.Empty(t, taskOf(t, preview, vpcID, "").BlockedBy)
+	})
+
+	t.Run("confirm", func(t *testing.T) {
+		svc := setup(t)
+		preview, err := svc.PreviewGroup(specs...)
+		require.NoError(t, err)
+
+		group, err := svc.ConfirmPreview(preview.Group.ID)
+		require.NoError(t, err)
+		assert.Len(t, group.Tasks, 3)
+		assert.Len(t, svc.List(ListOptions{}), 4)
+
+		// Preview is discarded upon confirmation.
+		_, err = svc.GetPreview(preview.Group.ID)
+		assert.ErrorIs(t, err, resource.ErrNotFound)
+	})
+
+	t.Run("discard", func(t *testing.T) {
+		svc := setup(t)
+		preview, err := svc.PreviewGroup(specs...)
+		require.NoError(t, err)
+
+		svc.DiscardPreview(preview.Group.ID)
+
+		_, err = svc.ConfirmPreview(preview.Group.ID)
+		assert.ErrorIs(t, err, resource.ErrNotFound)
+		assert.Empty(t, svc.List(ListOptions{}))
+	})
+}
@@ -0,0 +1,136 @@
+package task
+
+import (
+	"context"
+	"time"
+
+	"github.com/leg100/pug/internal/logging"
+	"github.com/leg100/pug/internal/resource"
+)
+
+// pruneInterval is how often the pruner checks for tasks that have exceeded
+// the maximum age.
+const pruneInterval = time.Minute
+
+// RetentionPolicy determines which finished tasks are retained. A zero value
+// for any of its fields disables that limit.
+type RetentionPolicy struct {
+	// MaxFinished is the maximum number of finished tasks to retain.
+	MaxFinished int
+	// MaxAge is the maximum length of time to retain a task after it has
+	// finished.
+	MaxAge time.Duration
+	// KeepPerWorkspace is the number of most recently finished tasks to retain
+	// for each workspace and task identifier, e.g. the last 10 plans for a
+	// workspace.
+	KeepPerWorkspace int
+}
+
+// IsZero is true if the policy imposes no limits.
+func (p RetentionPolicy) IsZero() bool {
+	return p == RetentionPolicy{}
+}
+
+// pruner deletes finished tasks that fall outside of a retention policy.
+// Tasks that are protected, or that unfinished tasks depend upon, are never
+// pruned, although they still count towards the policy's limits.
+type pruner struct {
+	tasks  prunerTaskService
+	policy RetentionPolicy
+	logger logging.Interface
+}
+
+type prunerTaskService interface {
+	taskLister
+
+	Delete(taskID resource.ID) error
+	isProtected(t *Task) bool
+	pruneGroups()
+}
+
+// retentionKey identifies the tasks subject to the KeepPerWorkspace limit.
+type retentionKey struct {
+	workspaceID resource.ID
+	identifier  Identifier
+}
+
+// StartPruner starts pruning finished tasks according to the retention policy,
+// both whenever a task finishes and periodically, until the context is
+// canceled.
+func StartPruner(ctx context.Context, logger logging.Interface, tasks *Service, policy RetentionPolicy) {
+	if policy.IsZero() {
+		return
+	}
+	p := &pruner{tasks: tasks, policy: policy, logger: logger}
+	sub := tasks.TaskBroker.Subscribe(ctx)
+	ticker := time.NewTicker(pruneInterval)
+
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case event, ok := <-sub:
+				if !ok {
+					return
+				}
+				if event.Type != resource.UpdatedEvent || !event.Payload.CurrentState().IsFinal() {
+					continue
+				}
+			case <-ticker.C:
+			}
+			p.prune(time.Now())
+		}
+	}()
+}
+
+// prune deletes finished tasks that fall outside of the retention policy,
+// along with any task groups left without tasks, and returns the number of
+// tasks deleted.
+func (p *pruner) prune(now time.Time) int {
+	var (
+		// finished tasks, most recently finished first.
+		finished = p.tasks.List(ListOptions{Status: []Status{Exited, Errored, Canceled}})
+		perKey   = make(map[retentionKey]int)
+		pruned   int
+		// dependencies are the IDs of tasks that unfinished tasks depend
+		// upon: pruning them would fail their dependents.
+		dependencies = make(map[resource.ID]struct{})
+	)
+	for _, t := range p.tasks.List(ListOptions{Status: []Status{Pending, Queued, Running}}) {
+		for _, id := range t.DependsOn {
+			dependencies[id] = struct{}{}
+		}
+	}
+	for i, t := range finished {
+		var prune bool
+		if p.policy.MaxFinished > 0 && i >= p.policy.MaxFinished {
+			prune = true
+		}
+		if p.policy.MaxAge > 0 && now.Sub(t.LastUpdated()) > p.policy.MaxAge {
+			prune = true
+		}
+		if p.policy.KeepPerWorkspace > 0 && t.WorkspaceID != nil {
+			key := retentionKey{workspaceID: t.WorkspaceID, identifier: t.Identifier}
+			if perKey[key] >= p.policy.KeepPerWorkspace {
+				prune = true
+			}
+			perKey[key]++
+		}
+		if !prune || p.tasks.isProtected(t) {
+			continue
+		}
+		if _, ok := dependencies[t.ID]; ok {
+			continue
+		}
+		if err := p.tasks.Delete(t.ID); err != nil {
+			p.logger.Error("pruning task", "task", t, "error", err)
+			continue
+		}
+		p.logger.Debug("pruned task", "task", t)
+		pruned++
+	}
+	if pruned > 0 {
+		p.tasks.pruneGroups()
+	}
+	return pruned
+}
@@ -0,0 +1,117 @@
+package task
+
+import (
+	"testing"
+	"time"
+
+	"github.com/leg100/pug/internal/logging"
+	"github.com/leg100/pug/internal/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPruner(t *testing.T) {
+	now := time.Now()
+	mod := resource.NewMonotonicID(resource.Module)
+	ws1 := resource.NewMonotonicID(resource.Workspace)
+	ws2 := resource.NewMonotonicID(resource.Workspace)
+
+	// setup creates a service with one task per spec, each task having
+	// finished the given number of minutes ago, in order.
+	type finishedSpec struct {
+		Spec
+		ago int
+	}
+	setup := func(t *testing.T, specs ...finishedSpec) (*Service, []*Task) {
+		svc := NewService(ServiceOptions{Logger: logging.Discard})
+		tasks := make([]*Task, len(specs))
+		for i, spec := range specs {
+			task, err := svc.Create(spec.Spec)
+			require.NoError(t, err)
+			task.updateState(Exited)
+			task.Updated = now.Add(-time.Duration(spec.ago) * time.Minute)
+			tasks[i] = task
+		}
+		return svc, tasks
+	}
+	exists := func(svc *Service, tasks ...*Task) (got []bool) {
+		for _, task := range tasks {
+			_, err := svc.Get(task.ID)
+			got = append(got, err == nil)
+		}
+		return got
+	}
+
+	t.Run("max finished", func(t *testing.T) {
+		svc, tasks := setup(t, finishedSpec{ago: 1}, finishedSpec{ago: 2}, finishedSpec{ago: 3})
+		// Unfinished tasks are never pruned.
+		pending, err := svc.Create(Spec{})
+		require.NoError(t, err)
+
+		p := &pruner{tasks: svc, policy: RetentionPolicy{MaxFinished: 2}, logger: logging.Discard}
+		assert.Equal(t, 1, p.prune(now))
+		assert.Equal(t, []bool{true, true, false, true}, exists(svc, append(tasks, pending)...))
+	})
+
+	t.Run("max age", func(t *testing.T) {
+		svc, tasks := setup(t, finishedSpec{ago: 1}, finishedSpec{ago: 10}, finishedSpec{ago: 20})
+
+		p := &pruner{tasks: svc, policy: RetentionPolicy{MaxAge: 5 * time.Minute}, logger: logging.Discard}
+		assert.Equal(t, 2, p.prune(now))
+		assert.Equal(t, []bool{true, false, false}, exists(svc, tasks...))
+	})
+
+	t.Run("keep per workspace", func(t *testing.T) {
+		svc, tasks := setup(t,
+			finishedSpec{Spec{ModuleID: mod, WorkspaceID: ws1, Identifier: "plan"}, 1},
+			finishedSpec{Spec{ModuleID: mod, WorkspaceID: ws1, Identifier: "plan"}, 2},
+			finishedSpec{Spec{ModuleID: mod, WorkspaceID: ws1, Identifier: "apply"}, 3},
+			finishedSpec{Spec{ModuleID: mod, WorkspaceID: ws2, Identifier: "plan"}, 4},
+			// Tasks without a workspace are not subject to the limit.
+			finishedSpec{Spec{Identifier: "plan"}, 5},
+			finishedSpec{Spec{Identifier: "plan"}, 6},
+		)
+
+		p := &pruner{tasks: svc, policy: RetentionPolicy{KeepPerWorkspace: 1}, logger: logging.Discard}
+		assert.Equal(t, 1, p.prune(now))
+		assert.Equal(t, []bool{true, false, true, true, true, true}, exists(svc, tasks...))
+	})
+
+	t.Run("protected", func(t *testing.T) {
+		svc, tasks := setup(t, finishedSpec{ago: 1}, finishedSpec{ago: 2}, finishedSpec{ago: 3})
+		svc.Protect(func(task *Task) bool { return task == tasks[1] })
+
+		p := &pruner{tasks: svc, policy: RetentionPolicy{MaxFinished: 1}, logger: logging.Discard}
+		assert.Equal(t, 1, p.prune(now))
+		assert.Equal(t, []bool{true, true, false}, exists(svc, tasks...))
+	})
+
+	t.Run("dependency of unfinished task", func(t *testing.T) {
+		svc, tasks := setup(t, finishedSpec{ago: 1}, finishedSpec{ago: 2}, finishedSpec{ago: 3})
+		_, err := svc.Create(Spec{dependsOn: []resource.ID{tasks[2].ID}})
+		require.NoError(t, err)
+
+		p := &pruner{tasks: svc, policy: RetentionPolicy{MaxFinished: 1}, logger: logging.Discard}
+		assert.Equal(t, 1, p.prune(now))
+		assert.Equal(t, []bool{true, false, true}, exists(svc, tasks...))
+	})
+
+	t.Run("prune groups", func(t *testing.T) {
+		svc, tasks := setup(t, finishedSpec{ago: 1}, finishedSpec{ago: 2}, finishedSpec{ago: 3})
+		retained := &Group{ID: resource.NewMonotonicID(resource.TaskGroup), Tasks: tasks[:2]}
+		emptied := &Group{ID: resource.NewMonotonicID(resource.TaskGroup), Tasks: tasks[2:]}
+		svc.AddGroup(retained)
+		svc.AddGroup(emptied)
+
+		p := &pruner{tasks: svc, policy: RetentionPolicy{MaxFinished: 1}, logger: logging.Discard}
+		assert.Equal(t, 2, p.prune(now))
+
+		// Pruned tasks are removed from groups.
+		got, err := svc.GetGroup(retained.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []*Task{tasks[0]}, got.Tasks)
+		// Groups whose tasks have all been pruned are deleted.
+		_, err = svc.GetGroup(emptied.ID)
+		assert.ErrorIs(t, err, resource.ErrNotFound)
+	})
+}
@@ -1,18 +1,36 @@
 package task
 
 import (
+	"container/list"
 	"context"
 	"sync"
 
 	"github.com/leg100/pug/internal/logging"
+	"github.com/leg100/pug/internal/resource"
 )
 
 // Runner is the global task Runner that provides a couple of invariants:
 // (a) no more than MAX tasks run at any given time
 // (b) no more than one 'exclusive' task runs at any given time
+//
+// The runner maintains a ready queue of queued tasks, along with the set of
+// running tasks, updating both incrementally upon each task event.
 type runner struct {
 	max   int
 	tasks taskLister
+
+	// initialized is true once the runner has been seeded with existing tasks.
+	initialized bool
+	// ready is the queue of queued tasks, oldest first.
+	ready *list.List
+	// queued maps queued tasks to their element in the ready queue.
+	queued map[*Task]*list.Element
+	// running is the set of running tasks.
+	running map[*Task]struct{}
+	// exclusive is the number of running exclusive tasks.
+	exclusive int
+	// immediate is the number of immediate tasks in the ready queue.
+	immediate int
 }
 
 // StartRunner starts the task runner and returns a function that waits for
@@ -28,7 +46,16 @@ func StartRunner(ctx context.Context, logger logging.Interface, tasks *Service,
 	// On each task event, get a list of tasks to be run, start them, and wait
 	// for them to complete in the background.
 	go func() {
-		for range sub {
+		var dropped uint64
+		for event := range sub {
+			// The runner's state is only accurate if it has received every
+			// event. If events have been dropped then re-seed the runner from
+			// the tasks table.
+			if n := tasks.TaskBroker.Metrics().Dropped; n != dropped {
+				dropped = n
+				r.initialized = false
+			}
+			r.handle(event)
 			for _, task := range r.runnable() {
 				waitfn, err := task.start(ctx)
 				if err != nil {
@@ -48,51 +75,138 @@ func StartRunner(ctx context.Context, logger logging.Interface, tasks *Service,
 	return g.Wait
 }
 
-// runnable retrieves a list of tasks to be run
-func (r *runner) runnable() []*Task {
-	// exclusive is true if the one and only exclusive slot is occupied
-	var exclusive bool
+// init seeds the runner with existing tasks.
+func (r *runner) init() {
+	if r.initialized {
+		return
+	}
+	r.initialized = true
+	r.ready = list.New()
+	r.queued = make(map[*Task]*list.Element)
+	r.running = make(map[*Task]struct{})
 
 	running := r.tasks.List(ListOptions{
 		Status: []Status{Running},
 	})
-	avail := r.max - len(running)
-
-	// Process queue, starting with oldest task
+	for _, t := range running {
+		r.running[t] = struct{}{}
+	}
+	r.exclusive = len(r.tasks.List(ListOptions{
+		Exclusive: true,
+		Status:    []Status{Running},
+	}))
 	queued := r.tasks.List(ListOptions{
 		Status: []Status{Queued},
 		Oldest: true,
 	})
-	var i int
-	for _, qt := range queued {
+	for _, t := range queued {
+		r.enqueue(t)
+	}
+}
+
+// handle updates the runner's state in response to a task event.
+func (r *runner) handle(event resource.Event[*Task]) {
+	r.init()
+
+	t := event.Payload
+	if event.Type == resource.DeletedEvent {
+		r.dequeue(t)
+		r.stop(t)
+		return
+	}
+	// Take a snapshot of the task's state, which is liable to change while the
+	// event is handled.
+	switch state := t.CurrentState(); {
+	case state == Queued:
+		if _, ok := r.queued[t]; !ok {
+			r.enqueue(t)
+		}
+	case state == Running:
+		r.dequeue(t)
+		if _, ok := r.running[t]; !ok {
+			r.start(t)
+		}
+	case state.IsFinal():
+		r.dequeue(t)
+		r.stop(t)
+	}
+}
+
+// runnable retrieves a list of tasks to be run
+func (r *runner) runnable() []*Task {
+	r.init()
+
+	runnable := make([]*Task, 0)
+	// exclusive is true if the one and only exclusive slot is occupied
+	exclusive := r.exclusive > 0
+	avail := r.max - len(r.running)
+
+	// Process queue, starting with oldest task
+	for elem := r.ready.Front(); elem != nil; {
+		if avail <= 0 && r.immediate == 0 {
+			// No more available slots and no immediate tasks to start.
+			break
+		}
+		next := elem.Next()
+		qt := elem.Value.(*Task)
 		if avail <= 0 && !qt.Immediate {
 			// No more available slots. Note: immediate tasks are immediately runnable, so they
 			// are exempt from the max. For this reason the number of slots may
 			// go into negative territory.
+			elem = next
 			continue
 		}
 		if qt.exclusive {
 			if exclusive {
 				// Exclusive slot taken
+				elem = next
 				continue
 			}
-			// Check if there is an exclusive task running
-			runningExclusiveTasks := r.tasks.List(ListOptions{
-				Exclusive: true,
-				Status:    []Status{Running},
-			})
-			if len(runningExclusiveTasks) > 0 {
-				// Exclusive slot taken
-				exclusive = true
-				continue
-			}
-			// No exclusive tasks are already running, and this exclusive task
-			// takes the available exclusive slot.
+			// This exclusive task takes the available exclusive slot.
 			exclusive = true
 		}
 		avail--
-		queued[i] = qt
-		i++
+		runnable = append(runnable, qt)
+		// The task is about to be started, so treat it as running.
+		r.dequeue(qt)
+		r.start(qt)
+		elem = next
+	}
+	return runnable
+}
+
+func (r *runner) enqueue(t *Task) {
+	r.queued[t] = r.ready.PushBack(t)
+	if t.Immediate {
+		r.immediate++
+	}
+}
+
+func (r *runner) dequeue(t *Task) {
+	elem, ok := r.queued[t]
+	if !ok {
+		return
+	}
+	r.ready.Remove(elem)
+	delete(r.queued, t)
+	if t.Immediate {
+		r.immediate--
+	}
+}
+
+func (r *runner) start(t *Task) {
+	r.running[t] = struct{}{}
+	if t.exclusive {
+		r.exclusive++
+	}
+}
+
+func (r *runner) stop(t *Task) {
+	if _, ok := r.running[t]; !ok {
+		return
+	}
+	delete(r.running, t)
+	if t.exclusive {
+		r.exclusive--
 	}
-	return queued[:i]
 }
@@ -4,6 +4,7 @@ import (
 	"slices"
 	"testing"
 
+	"github.com/leg100/pug/internal/resource"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -125,3 +126,41 @@ func (f *fakeRunnerLister) List(opts ListOptions) []*Task {
 	}
 	return nil
 }
+
+func TestRunner_Incremental(t *testing.T) {
+	t.Parallel()
+
+	r := &runner{max: 1, tasks: &fakeRunnerLister{}}
+
+	// update changes the state of a task and notifies the runner
+	update := func(task *Task, state Status) {
+		task.State = state
+		r.handle(resource.Event[*Task]{Type: resource.UpdatedEvent, Payload: task})
+	}
+
+	t1 := &Task{}
+	t2 := &Task{}
+	ex1 := &Task{exclusive: true}
+	immediate := &Task{Immediate: true}
+
+	update(t1, Queued)
+	update(t2, Queued)
+	assert.Equal(t, []*Task{t1}, r.runnable())
+	update(t1, Running)
+	assert.Equal(t, []*Task{}, r.runnable())
+
+	// Immediate task is runnable despite max tasks already running.
+	update(immediate, Queued)
+	assert.Equal(t, []*Task{immediate}, r.runnable())
+
+	// Finishing tasks frees up slots
+	update(t1, Exited)
+	update(immediate, Exited)
+	assert.Equal(t, []*Task{t2}, r.runnable())
+
+	// Exclusive task waits for slot and then takes exclusive slot.
+	update(ex1, Queued)
+	assert.Equal(t, []*Task{}, r.runnable())
+	update(t2, Canceled)
+	assert.Equal(t, []*Task{ex1}, r.runnable())
+}
@@ -1,7 +1,12 @@
 package task
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
 	"slices"
+	"sync"
+	"sync/atomic"
 
 	"github.com/leg100/pug/internal"
 	"github.com/leg100/pug/internal/logging"
@@ -12,9 +17,18 @@ import (
 type Service struct {
 	tasks   *resource.Table[*Task]
 	groups  *resource.Table[*Group]
-	counter *int
+	counter *atomic.Int64
 	logger  logging.Interface
 
+	// protectors determine whether a task is protected from pruning.
+	protectors []func(*Task) bool
+	protectMu  sync.Mutex
+
+	// previews are task group previews awaiting confirmation, keyed by the
+	// ID of the group that would be created.
+	previews   map[resource.ID]*Preview
+	previewsMu sync.Mutex
+
 	TaskBroker  *pubsub.Broker[*Task]
 	GroupBroker *pubsub.Broker[*Group]
 	*factory
@@ -27,22 +41,41 @@ type ServiceOptions struct {
 	UserEnvs   []string
 	UserArgs   []string
 	Terragrunt bool
+	// DataDir is the directory beneath which task output is spilled to disk.
+	// If empty then task output is only held in memory.
+	DataDir string
+	// MaxOutputMemory is the maximum number of bytes of output each task
+	// output stream holds in memory before spilling to disk.
+	MaxOutputMemory int
+	// Events configures the brokers that publish the service's events.
+	Events []pubsub.Option
 }
 
 func NewService(opts ServiceOptions) *Service {
-	var counter int
+	var counter atomic.Int64
 
-	taskBroker := pubsub.NewBroker[*Task](opts.Logger)
-	groupBroker := pubsub.NewBroker[*Group](opts.Logger)
+	taskBroker := pubsub.NewBroker[*Task](opts.Logger, opts.Events...)
+	groupBroker := pubsub.NewBroker[*Group](opts.Logger, opts.Events...)
 
 	factory := &factory{
-		publisher:  taskBroker,
-		counter:    &counter,
-		program:    opts.Program,
-		workdir:    opts.Workdir,
-		userEnvs:   opts.UserEnvs,
-		userArgs:   opts.UserArgs,
-		terragrunt: opts.Terragrunt,
+		publisher:       taskBroker,
+		counter:         &counter,
+		program:         opts.Program,
+		workdir:         opts.Workdir,
+		userEnvs:        opts.UserEnvs,
+		userArgs:        opts.UserArgs,
+		terragrunt:      opts.Terragrunt,
+		maxOutputMemory: opts.MaxOutputMemory,
+		logger:          opts.Logger,
+	}
+	if opts.DataDir != "" {
+		// Each pug process spills task output to its own directory, which is
+		// removed upon cleanup.
+		dir, err := newOutputDir(opts.DataDir)
+		if err != nil {
+			opts.Logger.Error("creating task output directory; task output is held only in memory", "error", err)
+		}
+		factory.outputDir = dir
 	}
 
 	return &Service{
@@ -53,13 +86,14 @@ func NewService(opts ServiceOptions) *Service {
 		factory:     factory,
 		counter:     &counter,
 		logger:      opts.Logger,
+		previews:    make(map[resource.ID]*Preview),
 	}
 }
 
 // Create a task. The task is placed into a pending state and requires enqueuing
 // before it'll be processed.
 func (s *Service) Create(spec Spec) (*Task, error) {
-	task, err := s.newTask(spec)
+	task, err := s.construct(s, spec)
 	if err != nil {
 		return nil, err
 	}
@@ -69,7 +103,7 @@ func (s *Service) Create(spec Spec) (*Task, error) {
 	// Add to db
 	s.tasks.Add(task.ID, task)
 	// Increment counter of number of live tasks
-	*s.counter++
+	s.counter.Add(1)
 
 	if spec.AfterCreate != nil {
 		spec.AfterCreate(task)
@@ -91,6 +125,28 @@ func (s *Service) Create(spec Spec) (*Task, error) {
 	return task, nil
 }
 
+// construct constructs a task from a spec, first creating its prerequisite
+// task, if any, using the creator.
+func (f *factory) construct(creator taskCreator, spec Spec) (*Task, error) {
+	original := spec
+	if spec.Prerequisite != nil {
+		// Create prerequisite task first and make this task depend upon it.
+		pre, err := creator.Create(*spec.Prerequisite)
+		if err != nil {
+			return nil, fmt.Errorf("creating prerequisite task: %w", err)
+		}
+		spec.dependsOn = append(slices.Clone(spec.dependsOn), pre.ID)
+	}
+	task, err := f.newTask(spec)
+	if err != nil {
+		return nil, err
+	}
+	// Retain the original spec, so that a retry creates a fresh prerequisite
+	// task rather than depending upon the old prerequisite task.
+	task.Spec = original
+	return task, nil
+}
+
 // Create a task group from one or more task specs. An error is returned if zero
 // specs are provided, or if it fails to create at least one task.
 func (s *Service) CreateGroup(specs ...Spec) (*Group, error) {
@@ -114,10 +170,10 @@ func (s *Service) AddGroup(group *Group) {
 
 // Enqueue moves the task onto the global queue for processing.
 func (s *Service) Enqueue(taskID resource.ID) (*Task, error) {
-	task, err := s.tasks.Update(taskID, func(existing *Task) error {
-		existing.updateState(Queued)
-		return nil
-	})
+	task, err := s.tasks.Get(taskID)
+	if err == nil {
+		err = task.enqueue()
+	}
 	if err != nil {
 		s.logger.Error("enqueuing task", "error", err)
 		return nil, err
@@ -156,7 +212,7 @@ func (s *Service) List(opts ListOptions) []*Task {
 			continue
 		}
 		if opts.Status != nil {
-			if !slices.Contains(opts.Status, t.State) {
+			if !slices.Contains(opts.Status, t.CurrentState()) {
 				continue
 			}
 		}
@@ -177,7 +233,7 @@ func (s *Service) List(opts ListOptions) []*Task {
 
 	// Sort list according to options
 	slices.SortFunc(tasks, func(a, b *Task) int {
-		cmp := a.Updated.Compare(b.Updated)
+		cmp := a.LastUpdated().Compare(b.LastUpdated())
 		if opts.Oldest {
 			return cmp
 		}
@@ -216,13 +272,79 @@ func (s *Service) Cancel(taskID resource.ID) (*Task, error) {
 	return task, nil
 }
 
+// Protect registers a function that determines whether a task is protected
+// from being pruned.
+func (s *Service) Protect(fn func(*Task) bool) {
+	s.protectMu.Lock()
+	defer s.protectMu.Unlock()
+
+	s.protectors = append(s.protectors, fn)
+}
+
+func (s *Service) isProtected(t *Task) bool {
+	s.protectMu.Lock()
+	defer s.protectMu.Unlock()
+
+	for _, fn := range s.protectors {
+		if fn(t) {
+			return true
+		}
+	}
+	return false
+}
+
 func (s *Service) Delete(taskID resource.ID) error {
 	// TODO: only allow deleting task if in finished state (error message should
 	// instruct user to cancel task first).
+	task, err := s.tasks.Get(taskID)
+	if err != nil {
+		// Deleting a non-existent task is a no-op.
+		return nil
+	}
 	s.tasks.Delete(taskID)
-	return nil
+	return task.removeOutput()
+}
+
+// pruneGroups removes deleted tasks from task groups, so that the tasks can be
+// garbage collected, and deletes task groups whose tasks have all been
+// deleted.
+func (s *Service) pruneGroups() {
+	for _, g := range s.groups.List() {
+		if len(g.Tasks) == 0 {
+			continue
+		}
+		retained := slices.DeleteFunc(slices.Clone(g.Tasks), func(t *Task) bool {
+			_, err := s.tasks.Get(t.ID)
+			return err != nil
+		})
+		switch {
+		case len(retained) == 0:
+			s.groups.Delete(g.ID)
+		case len(retained) < len(g.Tasks):
+			_, _ = s.groups.Update(g.ID, func(existing *Group) error {
+				existing.Tasks = retained
+				return nil
+			})
+		}
+	}
+}
+
+// Cleanup removes all task output spilled to disk.
+func (s *Service) Cleanup() error {
+	if s.outputDir == "" {
+		return nil
+	}
+	return os.RemoveAll(s.outputDir)
+}
+
+func newOutputDir(dataDir string) (string, error) {
+	parent := filepath.Join(dataDir, "output")
+	if err := os.MkdirAll(parent, 0o755); err != nil {
+		return "", err
+	}
+	return os.MkdirTemp(parent, "")
 }
 
 func (s *Service) Counter() int {
-	return *s.counter
+	return int(s.counter.Load())
 }
@@ -1,10 +1,14 @@
 package task
 
 import (
+	"context"
 	"testing"
 
+	"github.com/leg100/pug/internal/logging"
+	"github.com/leg100/pug/internal/pubsub"
 	"github.com/leg100/pug/internal/resource"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestService_List(t *testing.T) {
@@ -75,3 +79,108 @@ func TestService_List(t *testing.T) {
 		})
 	}
 }
+
+func TestService_CreateWithPrerequisite(t *testing.T) {
+	svc := NewService(ServiceOptions{Logger: logging.Discard})
+
+	spec := Spec{
+		Execution:    Execution{TerraformCommand: []string{"apply"}},
+		Prerequisite: &Spec{Execution: Execution{TerraformCommand: []string{"state", "pull"}}},
+	}
+	got, err := svc.Create(spec)
+	require.NoError(t, err)
+
+	// Prerequisite task should have been created too, with the task depending
+	// upon it.
+	tasks := svc.List(ListOptions{Oldest: true})
+	require.Len(t, tasks, 2)
+	pre := tasks[0]
+	assert.Equal(t, "state pull", pre.String())
+	assert.Equal(t, []resource.ID{pre.ID}, got.DependsOn)
+
+	// Task should retain original spec, for use when retrying the task.
+	assert.Equal(t, spec.Prerequisite, got.Spec.Prerequisite)
+	assert.Empty(t, got.Spec.dependsOn)
+}
+
+func TestService_Delete(t *testing.T) {
+	svc := NewService(ServiceOptions{Logger: logging.Discard})
+
+	task, err := svc.Create(Spec{})
+	require.NoError(t, err)
+
+	require.NoError(t, svc.Delete(task.ID))
+	_, err = svc.Get(task.ID)
+	assert.ErrorIs(t, err, resource.ErrNotFound)
+
+	// Deleting a non-existent task is a no-op.
+	assert.NoError(t, svc.Delete(task.ID))
+}
+
+func TestService_Schedule(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	svc := NewService(ServiceOptions{Logger: logging.Discard})
+	StartEnqueuer(svc)
+	wait := StartRunner(ctx, logging.Discard, svc, 2)
+
+	modID := resource.NewMonotonicID(resource.Module)
+	wsID := resource.NewMonotonicID(resource.Workspace)
+	create := func(spec Spec) *Task {
+		task, err := svc.Create(spec)
+		require.NoError(t, err)
+		return task
+	}
+	sh := func(script string) Execution {
+		return Execution{Program: "sh", Args: []string{"-c", script}}
+	}
+
+	blocking1 := create(Spec{ModuleID: modID, WorkspaceID: wsID, Blocking: true, Execution: sh("true")})
+	blocking2 := create(Spec{ModuleID: modID, WorkspaceID: wsID, Blocking: true, Execution: sh("false")})
+	dependent := create(Spec{Execution: sh("true"), dependsOn: []resource.ID{blocking2.ID}})
+	others := make([]*Task, 10)
+	for i := range others {
+		others[i] = create(Spec{Execution: sh("true")})
+	}
+
+	assert.NoError(t, blocking1.Wait())
+	assert.Error(t, blocking2.Wait())
+	// Second blocking task only starts once the first has finished.
+	assert.False(t, blocking2.timestamps[Running].started.Before(blocking1.timestamps[Exited].started))
+	// Dependent task is canceled because its dependency failed.
+	_ = dependent.Wait()
+	assert.Equal(t, Canceled, dependent.State)
+	for _, task := range others {
+		assert.NoError(t, task.Wait())
+	}
+	wait()
+}
+
+func TestService_ScheduleDroppedEvents(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	// A queue of one event ensures updated events are dropped.
+	svc := NewService(ServiceOptions{
+		Logger: logging.Discard,
+		Events: []pubsub.Option{pubsub.WithMaxQueue(1)},
+	})
+	StartEnqueuer(svc)
+	wait := StartRunner(ctx, logging.Discard, svc, 2)
+
+	var previous *Task
+	for range 20 {
+		spec := Spec{Execution: Execution{Program: "true"}}
+		if previous != nil {
+			spec.dependsOn = []resource.ID{previous.ID}
+		}
+		task, err := svc.Create(spec)
+		require.NoError(t, err)
+		previous = task
+	}
+	// Every task should nonetheless complete.
+	assert.NoError(t, previous.Wait())
+	assert.NotZero(t, svc.TaskBroker.Metrics().Dropped)
+	wait()
+}
@@ -60,10 +60,14 @@ type Spec struct {
 	// Call this function after the task terminates for whatever reason.
 	AfterFinish func(*Task)
 	// Dependencies specifies that the task respect its module's dependencies.
-	// Only makes sense when the task is specified as part of a task group. All
-	// specs in the task group must set Dependencies to either nil, or to
-	// non-nil.
+	// Only makes sense when the task is specified as part of a task group. If
+	// any spec in the task group sets Dependencies then the specs that leave
+	// it nil are treated as belonging to modules without dependencies.
 	Dependencies *Dependencies
+	// Prerequisite specifies a task to be created before this task, which
+	// must finish successfully before this task can be enqueued. If the
+	// prerequisite task fails then this task is canceled.
+	Prerequisite *Spec
 	// dependsOn are other tasks that all must successfully exit before the
 	// task can be enqueued. If any of the other tasks are canceled or error
 	// then the task will be canceled.
@@ -9,12 +9,15 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 	"unicode"
 
 	"github.com/leg100/pug/internal"
+	"github.com/leg100/pug/internal/logging"
 	"github.com/leg100/pug/internal/resource"
 )
 
@@ -50,6 +53,13 @@ type Task struct {
 	// Nil until task has started
 	proc *os.Process
 
+	// usage is the most recently sampled resource usage of the task's
+	// process tree, and peakUsage is the peak of each type of usage sampled.
+	usage     Usage
+	peakUsage Usage
+	sampled   bool
+	usageMu   sync.Mutex
+
 	Created time.Time
 	Updated time.Time
 
@@ -88,7 +98,7 @@ type Task struct {
 }
 
 type factory struct {
-	counter   *int
+	counter   *atomic.Int64
 	program   string
 	publisher resource.Publisher[*Task]
 	workdir   internal.Workdir
@@ -98,6 +108,21 @@ type factory struct {
 	userArgs []string
 	// Terragrunt mode
 	terragrunt bool
+	// outputDir is the directory in which task output is spilled to disk. If
+	// empty then output is only held in memory.
+	outputDir string
+	// maxOutputMemory is the maximum number of bytes of output each task
+	// buffer holds in memory before spilling to disk.
+	maxOutputMemory int
+	logger          logging.Interface
+}
+
+// outputPath returns the directory in which to spill a task's output stream.
+func (f *factory) outputPath(id resource.MonotonicID, stream string) string {
+	if f.outputDir == "" {
+		return ""
+	}
+	return filepath.Join(f.outputDir, strconv.FormatUint(uint64(id.Serial), 10), stream)
 }
 
 // Summary summarises the outcome of a task.
@@ -114,8 +139,9 @@ func (f *factory) newTask(spec Spec) (*Task, error) {
 	if spec.Blocking && spec.Immediate {
 		return nil, errors.New("a task cannot both be blocking and immediately")
 	}
+	id := resource.NewMonotonicID(resource.Task)
 	task := &Task{
-		ID:                  resource.NewMonotonicID(resource.Task),
+		ID:                  id,
 		ModuleID:            spec.ModuleID,
 		WorkspaceID:         spec.WorkspaceID,
 		TaskGroupID:         spec.TaskGroupID,
@@ -124,8 +150,8 @@ func (f *factory) newTask(spec Spec) (*Task, error) {
 		Created:             time.Now(),
 		Updated:             time.Now(),
 		finished:            make(chan struct{}),
-		stdout:              newBuffer(),
-		combined:            newBuffer(),
+		stdout:              newBuffer(f.outputPath(id, "stdout"), f.maxOutputMemory, f.logger),
+		combined:            newBuffer(f.outputPath(id, "combined"), f.maxOutputMemory, f.logger),
 		terragrunt:          f.terragrunt,
 		Path:                filepath.Join(f.workdir.String(), spec.Path),
 		AdditionalExecution: spec.AdditionalExecution,
@@ -156,7 +182,7 @@ func (f *factory) newTask(spec Spec) (*Task, error) {
 		},
 		// Decrement live task counter whenever task terminates
 		afterFinish: func(t *Task) {
-			*f.counter--
+			f.counter.Add(-1)
 		},
 		timestamps: map[Status]statusTimestamps{
 			Pending: {
@@ -211,12 +237,55 @@ func (t *Task) NewReader(combined bool) io.Reader {
 	return t.stdout.NewReader()
 }
 
+// OutputUsage reports the space used by the task's output.
+func (t *Task) OutputUsage() BufferUsage {
+	stdout := t.stdout.Usage()
+	combined := t.combined.Usage()
+	return BufferUsage{
+		Memory: stdout.Memory + combined.Memory,
+		Disk:   stdout.Disk + combined.Disk,
+	}
+}
+
+// removeOutput removes any of the task's output that has been spilled to disk.
+func (t *Task) removeOutput() error {
+	return errors.Join(t.stdout.Remove(), t.combined.Remove())
+}
+
 // NewStreamer returns a stream of output from the task; the channel is closed
 // when the task has finished.
 func (t *Task) NewStreamer() <-chan []byte {
 	return t.combined.Stream()
 }
 
+// CurrentState returns the task's state. Unlike reading the State field, it is
+// safe to call concurrently with the task changing state, and should be used
+// by subscribers to task events.
+func (t *Task) CurrentState() Status {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.State
+}
+
+// LastUpdated returns the time at which the task's state was last updated.
+func (t *Task) LastUpdated() time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.Updated
+}
+
+// Result returns the task's state along with its summary and error, if any.
+// Like CurrentState, it is safe to call concurrently with the task changing
+// state.
+func (t *Task) Result() (Status, Summary, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.State, t.Summary, t.Err
+}
+
 func (t *Task) IsActive() bool {
 	switch t.State {
 	case Queued, Running:
@@ -228,6 +297,9 @@ func (t *Task) IsActive() bool {
 
 // Elapsed returns the length of time the task has been in the given status.
 func (t *Task) Elapsed(s Status) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
 	st, ok := t.timestamps[s]
 	if !ok {
 		return 0
@@ -235,6 +307,76 @@ func (t *Task) Elapsed(s Status) time.Duration {
 	return st.Elapsed()
 }
 
+// Span returns the times at which the task entered and left the given status.
+// The end time is zero if the task is still in the status. False is returned
+// if the task has never been in the status.
+func (t *Task) Span(s Status) (start, end time.Time, ok bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	st, ok := t.timestamps[s]
+	if !ok {
+		return time.Time{}, time.Time{}, false
+	}
+	return st.started, st.ended, true
+}
+
+// Usage returns the most recently sampled resource usage of the task's process
+// tree. False is returned if the task's usage has never been sampled.
+func (t *Task) Usage() (Usage, bool) {
+	t.usageMu.Lock()
+	defer t.usageMu.Unlock()
+
+	return t.usage, t.sampled
+}
+
+// PeakUsage returns the peak resource usage of the task's process tree, which
+// is retained after the task has finished. False is returned if the task's
+// usage has never been sampled.
+func (t *Task) PeakUsage() (Usage, bool) {
+	t.usageMu.Lock()
+	defer t.usageMu.Unlock()
+
+	return t.peakUsage, t.sampled
+}
+
+// setUsage sets the resource usage of a running task, publishing an event to
+// notify subscribers. The usage of a task that is no longer running is left
+// untouched, and false is returned.
+func (t *Task) setUsage(usage Usage) bool {
+	// Hold the task's lock so that the event is published before the event
+	// for the task finishing.
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.State != Running {
+		return false
+	}
+	t.usageMu.Lock()
+	t.usage = usage
+	t.peakUsage = t.peakUsage.max(usage)
+	t.sampled = true
+	t.usageMu.Unlock()
+
+	if t.afterUpdate != nil {
+		t.afterUpdate(t)
+	}
+	return true
+}
+
+// pid returns the process ID of the task's process. False is returned if the
+// task is not running, in which case the process ID may since have been
+// reused by another process.
+func (t *Task) pid() (int, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.proc == nil || t.State != Running {
+		return 0, false
+	}
+	return t.proc.Pid, true
+}
+
 // Wait for task to complete successfully. If the task completes unsuccessfully
 // then the returned error is non-nil.
 func (t *Task) Wait() error {
@@ -291,6 +433,18 @@ func (t *Task) cancel() error {
 	}
 }
 
+// enqueue moves a pending task into the queued state.
+func (t *Task) enqueue() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.State != Pending {
+		return errors.New("invalid state transition")
+	}
+	t.updateState(Queued)
+	return nil
+}
+
 func (t *Task) start(ctx context.Context) (func(), error) {
 	cmd := t.execute(ctx, t.Program, t.Args)
 
@@ -302,8 +456,8 @@ func (t *Task) start(ctx context.Context) (func(), error) {
 	}
 
 	if err := cmd.Start(); err != nil {
-		t.updateState(Errored)
 		t.Err = fmt.Errorf("starting task: %w", err)
+		t.updateState(Errored)
 		return nil, err
 	}
 	t.updateState(Running)
@@ -311,22 +465,22 @@ func (t *Task) start(ctx context.Context) (func(), error) {
 	t.proc = cmd.Process
 
 	wait := func() {
-		state := Exited
-		if err := cmd.Wait(); err != nil {
-			state = Errored
-			t.Err = fmt.Errorf("task failed: %w", err)
-		} else if t.AdditionalExecution != nil {
+		err := cmd.Wait()
+		if err == nil && t.AdditionalExecution != nil {
 			// Execute additional program.
 			cmd = t.execute(ctx, t.AdditionalExecution.Program, t.AdditionalExecution.Args)
-			if err := cmd.Run(); err != nil {
-				state = Errored
-				t.Err = fmt.Errorf("task failed: %w", err)
-			}
+			err = cmd.Run()
 		}
 
 		t.mu.Lock()
-		t.updateState(state)
-		t.mu.Unlock()
+		defer t.mu.Unlock()
+
+		if err != nil {
+			t.Err = fmt.Errorf("task failed: %w", err)
+			t.updateState(Errored)
+		} else {
+			t.updateState(Exited)
+		}
 	}
 	return wait, nil
 }
@@ -353,6 +507,8 @@ func (t *Task) recordStatusEndTime(now time.Time) {
 	t.timestamps[t.State] = currentStateTimestamps
 }
 
+// updateState transitions the task into a new state. The caller must hold the
+// task's lock.
 func (t *Task) updateState(state Status) {
 	now := time.Now()
 	t.Updated = now
@@ -5,9 +5,9 @@ import (
 	"io"
 	"os"
 	"strings"
+	"sync/atomic"
 	"testing"
 
-	"github.com/leg100/pug/internal"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -16,7 +16,7 @@ func TestTask_NewReader(t *testing.T) {
 	t.Parallel()
 
 	f := factory{
-		counter:   internal.Int(0),
+		counter:   &atomic.Int64{},
 		program:   "./testdata/task",
 		publisher: &fakePublisher[*Task]{},
 	}
@@ -55,7 +55,7 @@ func TestTask_cancel(t *testing.T) {
 	t.Parallel()
 
 	f := factory{
-		counter:   internal.Int(0),
+		counter:   &atomic.Int64{},
 		program:   "./testdata/killme",
 		publisher: &fakePublisher[*Task]{},
 	}
@@ -79,6 +79,29 @@ func TestTask_cancel(t *testing.T) {
 	assert.Equal(t, Exited, task.State)
 }
 
+func TestTask_Span(t *testing.T) {
+	t.Parallel()
+
+	f := factory{counter: &atomic.Int64{}}
+	task, err := f.newTask(Spec{})
+	require.NoError(t, err)
+	task.updateState(Queued)
+
+	start, end, ok := task.Span(Pending)
+	require.True(t, ok)
+	assert.False(t, start.IsZero())
+	assert.False(t, end.IsZero())
+
+	// Still queued, so the span has no end.
+	start, end, ok = task.Span(Queued)
+	require.True(t, ok)
+	assert.False(t, start.IsZero())
+	assert.True(t, end.IsZero())
+
+	_, _, ok = task.Span(Running)
+	assert.False(t, ok)
+}
+
 func TestStripError(t *testing.T) {
 	b, err := os.ReadFile("./testdata/validate.out")
 	require.NoError(t, err)
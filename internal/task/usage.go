@@ -0,0 +1,138 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/leg100/pug/internal/logging"
+	"github.com/leg100/pug/internal/resource"
+)
+
+// usageInterval is how often the resource usage of running tasks is sampled.
+const usageInterval = 2 * time.Second
+
+// errUsageUnsupported is returned when sampling resource usage is unsupported
+// on the current platform.
+var errUsageUnsupported = errors.New("sampling resource usage is unsupported on this platform")
+
+// Usage is the resource usage of a task's process, together with its
+// descendant processes.
+type Usage struct {
+	// CPU is the percentage of a single CPU used, i.e. a process tree using
+	// two CPUs uses 200%.
+	CPU float64
+	// RSS is the resident set size in bytes.
+	RSS int64
+	// Processes is the number of processes.
+	Processes int
+}
+
+// max returns the maximum of each type of usage.
+func (u Usage) max(other Usage) Usage {
+	return Usage{
+		CPU:       max(u.CPU, other.CPU),
+		RSS:       max(u.RSS, other.RSS),
+		Processes: max(u.Processes, other.Processes),
+	}
+}
+
+// processSample is a sample of a process tree's cumulative CPU time and its
+// current memory usage.
+type processSample struct {
+	cpuTime   time.Duration
+	rss       int64
+	processes int
+	taken     time.Time
+}
+
+// processSnapshot is a snapshot of the processes running at a point in time.
+type processSnapshot interface {
+	// sample samples the process tree rooted at the given pid.
+	sample(pid int) (processSample, error)
+}
+
+// usageSampler samples the resource usage of running tasks.
+type usageSampler struct {
+	tasks  taskLister
+	logger logging.Interface
+	// snapshot takes a snapshot of running processes, from which the process
+	// tree of each task is sampled.
+	snapshot func() (processSnapshot, error)
+	// last is the last sample taken for each task, from which CPU usage is
+	// calculated.
+	last map[resource.ID]processSample
+}
+
+// StartUsageSampler periodically samples the resource usage of running tasks,
+// until the context is canceled. It does nothing on platforms where sampling
+// is unsupported.
+func StartUsageSampler(ctx context.Context, logger logging.Interface, tasks *Service) {
+	if !usageSupported {
+		logger.Debug("not sampling task resource usage", "error", errUsageUnsupported)
+		return
+	}
+	s := &usageSampler{
+		tasks:    tasks,
+		logger:   logger,
+		snapshot: takeProcessSnapshot,
+		last:     make(map[resource.ID]processSample),
+	}
+	ticker := time.NewTicker(usageInterval)
+
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.sampleAll()
+			}
+		}
+	}()
+}
+
+// sampleAll samples the resource usage of each running task, from a single
+// snapshot of running processes.
+func (s *usageSampler) sampleAll() {
+	running := s.tasks.List(ListOptions{Status: []Status{Running}})
+	seen := make(map[resource.ID]bool, len(running))
+	var snapshot processSnapshot
+	for _, t := range running {
+		seen[t.ID] = true
+		pid, ok := t.pid()
+		if !ok {
+			continue
+		}
+		if snapshot == nil {
+			var err error
+			snapshot, err = s.snapshot()
+			if err != nil {
+				s.logger.Error("sampling task resource usage", "error", err)
+				return
+			}
+		}
+		sample, err := snapshot.sample(pid)
+		if err != nil {
+			// The process may well have just exited.
+			s.logger.Debug("sampling task resource usage", "task", t, "error", err)
+			continue
+		}
+		usage := Usage{RSS: sample.rss, Processes: sample.processes}
+		if last, ok := s.last[t.ID]; ok {
+			if wall := sample.taken.Sub(last.taken); wall > 0 {
+				usage.CPU = 100 * float64(sample.cpuTime-last.cpuTime) / float64(wall)
+				usage.CPU = max(0, usage.CPU)
+			}
+		}
+		s.last[t.ID] = sample
+		t.setUsage(usage)
+	}
+	// Forget about tasks that are no longer running.
+	for id := range s.last {
+		if !seen[id] {
+			delete(s.last, id)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package task
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// usageSupported is true if sampling resource usage is supported on the
+// current platform.
+const usageSupported = true
+
+// clockTicks is the number of clock ticks per second in which process CPU
+// times are reported. It is almost always 100 on Linux.
+const clockTicks = 100
+
+// procStat is the subset of a process's /proc/<pid>/stat relevant to
+// resource usage.
+type procStat struct {
+	pid     int
+	ppid    int
+	cpuTime time.Duration
+	rss     int64
+}
+
+// procSnapshot is a snapshot of every process in the proc filesystem.
+type procSnapshot struct {
+	stats    map[int]procStat
+	children map[int][]int
+	taken    time.Time
+}
+
+// takeProcessSnapshot reads the proc filesystem once, from which any number of
+// process trees can then be sampled.
+func takeProcessSnapshot() (processSnapshot, error) {
+	taken := time.Now()
+	stats, err := readProcStats("/proc")
+	if err != nil {
+		return nil, err
+	}
+	children := make(map[int][]int)
+	for _, st := range stats {
+		children[st.ppid] = append(children[st.ppid], st.pid)
+	}
+	return &procSnapshot{stats: stats, children: children, taken: taken}, nil
+}
+
+// sample samples the resource usage of the process with the given pid
+// together with all its descendants.
+func (s *procSnapshot) sample(pid int) (processSample, error) {
+	root, ok := s.stats[pid]
+	if !ok {
+		return processSample{}, fmt.Errorf("process not found: %d", pid)
+	}
+	// Walk the process tree breadth first.
+	sample := processSample{taken: s.taken}
+	queue := []procStat{root}
+	for len(queue) > 0 {
+		st := queue[0]
+		queue = queue[1:]
+		sample.cpuTime += st.cpuTime
+		sample.rss += st.rss
+		sample.processes++
+		for _, child := range s.children[st.pid] {
+			queue = append(queue, s.stats[child])
+		}
+	}
+	return sample, nil
+}
+
+// readProcStats reads the stat file of every process in the proc filesystem,
+// keyed by pid. Processes that exit whilst being read are skipped.
+func readProcStats(procDir string) (map[int]procStat, error) {
+	entries, err := os.ReadDir(procDir)
+	if err != nil {
+		return nil, err
+	}
+	stats := make(map[int]procStat, len(entries))
+	for _, entry := range entries {
+		if _, err := strconv.Atoi(entry.Name()); err != nil {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(procDir, entry.Name(), "stat"))
+		if err != nil {
+			continue
+		}
+		st, err := parseProcStat(string(data), os.Getpagesize())
+		if err != nil {
+			continue
+		}
+		stats[st.pid] = st
+	}
+	return stats, nil
+}
+
+// parseProcStat parses the contents of a /proc/<pid>/stat file. See proc(5).
+func parseProcStat(data string, pageSize int) (procStat, error) {
+	// The command name is in parentheses and may itself contain spaces and
+	// parentheses, so parse the fields either side of the last parenthesis.
+	open := strings.IndexByte(data, '(')
+	closing := strings.LastIndexByte(data, ')')
+	if open < 0 || closing < open {
+		return procStat{}, fmt.Errorf("malformed stat: %q", data)
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(data[:open]))
+	if err != nil {
+		return procStat{}, fmt.Errorf("parsing pid: %w", err)
+	}
+	// Fields after the command name, starting with the state (field 3).
+	fields := strings.Fields(data[closing+1:])
+	field := func(n int) (int64, error) {
+		i := n - 3
+		if i >= len(fields) {
+			return 0, fmt.Errorf("missing stat field %d", n)
+		}
+		return strconv.ParseInt(fields[i], 10, 64)
+	}
+	ppid, err := field(4)
+	if err != nil {
+		return procStat{}, err
+	}
+	utime, err := field(14)
+	if err != nil {
+		return procStat{}, err
+	}
+	stime, err := field(15)
+	if err != nil {
+		return procStat{}, err
+	}
+	rss, err := field(24)
+	if err != nil {
+		return procStat{}, err
+	}
+	return procStat{
+		pid:     pid,
+		ppid:    int(ppid),
+		cpuTime: time.Duration(utime+stime) * time.Second / clockTicks,
+		rss:     rss * int64(pageSize),
+	}, nil
+}
@@ -0,0 +1,39 @@
+package task
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseProcStat(t *testing.T) {
+	data := "4321 (terraform (x) y) S 1234 4321 4321 0 -1 4194560 1000 0 0 0 250 50 0 0 20 0 12 0 100 1073741824 2048 18446744073709551615 0 0 0 0 0 0 0 0 0 0 0 0 17 3 0 0 0 0 0\n"
+
+	got, err := parseProcStat(data, 4096)
+	require.NoError(t, err)
+	assert.Equal(t, procStat{
+		pid:     4321,
+		ppid:    1234,
+		cpuTime: 3 * time.Second,
+		rss:     2048 * 4096,
+	}, got)
+
+	_, err = parseProcStat("4321 (terraform) S 1234", 4096)
+	assert.Error(t, err)
+}
+
+func TestProcSnapshot(t *testing.T) {
+	snapshot, err := takeProcessSnapshot()
+	require.NoError(t, err)
+
+	got, err := snapshot.sample(os.Getpid())
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, got.processes, 1)
+	assert.Greater(t, got.rss, int64(0))
+
+	_, err = snapshot.sample(-1)
+	assert.Error(t, err)
+}
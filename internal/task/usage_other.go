@@ -0,0 +1,13 @@
+//go:build !linux
+
+package task
+
+// usageSupported is true if sampling resource usage is supported on the
+// current platform, which is only Linux.
+const usageSupported = false
+
+// takeProcessSnapshot takes a snapshot of running processes, which is only
+// supported on Linux.
+func takeProcessSnapshot() (processSnapshot, error) {
+	return nil, errUsageUnsupported
+}
@@ -0,0 +1,101 @@
+package task
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/leg100/pug/internal/logging"
+	"github.com/leg100/pug/internal/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUsageSampler(t *testing.T) {
+	svc := NewService(ServiceOptions{Logger: logging.Discard})
+	task, err := svc.Create(Spec{})
+	require.NoError(t, err)
+	task.updateState(Running)
+	task.proc = &os.Process{Pid: 123}
+	other, err := svc.Create(Spec{})
+	require.NoError(t, err)
+	other.updateState(Running)
+	other.proc = &os.Process{Pid: 456}
+
+	// Not yet started tasks are not sampled.
+	pending, err := svc.Create(Spec{})
+	require.NoError(t, err)
+
+	now := time.Now()
+	samples := []processSample{
+		{cpuTime: 0, rss: 100, processes: 2, taken: now},
+		{cpuTime: time.Second, rss: 300, processes: 3, taken: now.Add(2 * time.Second)},
+		{cpuTime: time.Second, rss: 200, processes: 1, taken: now.Add(4 * time.Second)},
+	}
+	var snapshots int
+	s := &usageSampler{
+		tasks:  svc,
+		logger: logging.Discard,
+		snapshot: func() (processSnapshot, error) {
+			snapshots++
+			sample := samples[0]
+			samples = samples[1:]
+			return fakeSnapshot{123: sample, 456: sample}, nil
+		},
+		last: make(map[resource.ID]processSample),
+	}
+
+	// The first sample has no prior sample from which to calculate CPU usage.
+	s.sampleAll()
+	got, ok := task.Usage()
+	require.True(t, ok)
+	assert.Equal(t, Usage{RSS: 100, Processes: 2}, got)
+
+	s.sampleAll()
+	got, _ = task.Usage()
+	assert.Equal(t, Usage{CPU: 50, RSS: 300, Processes: 3}, got)
+
+	s.sampleAll()
+	got, _ = task.Usage()
+	assert.Equal(t, Usage{CPU: 0, RSS: 200, Processes: 1}, got)
+
+	// Peak usage is retained.
+	peak, ok := task.PeakUsage()
+	require.True(t, ok)
+	assert.Equal(t, Usage{CPU: 50, RSS: 300, Processes: 3}, peak)
+
+	_, ok = pending.Usage()
+	assert.False(t, ok)
+
+	// One snapshot is taken each time, regardless of the number of running
+	// tasks.
+	assert.Equal(t, 3, snapshots)
+
+	// Finished tasks are forgotten, and no snapshot is taken when there are
+	// no running tasks.
+	task.updateState(Exited)
+	other.updateState(Exited)
+	s.sampleAll()
+	assert.Empty(t, s.last)
+	assert.Equal(t, 3, snapshots)
+
+	// The pid of a finished task may have been reused, and its usage is no
+	// longer updated.
+	_, ok = task.pid()
+	assert.False(t, ok)
+	assert.False(t, task.setUsage(Usage{RSS: 1}))
+	got, _ = task.Usage()
+	assert.Equal(t, Usage{CPU: 0, RSS: 200, Processes: 1}, got)
+}
+
+// fakeSnapshot is a snapshot of process samples keyed by pid.
+type fakeSnapshot map[int]processSample
+
+func (s fakeSnapshot) sample(pid int) (processSample, error) {
+	sample, ok := s[pid]
+	if !ok {
+		return processSample{}, fmt.Errorf("process not found: %d", pid)
+	}
+	return sample, nil
+}
@@ -0,0 +1,24 @@
+package testutils
+
+import (
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/leg100/pug/internal"
+	"github.com/stretchr/testify/require"
+)
+
+// Fixture returns the path to a fake terraform program along with a workdir
+// containing the modules a and b, against which the program can be run.
+func Fixture(t *testing.T) (program string, workdir internal.Workdir) {
+	t.Helper()
+
+	_, file, _, ok := runtime.Caller(0)
+	require.True(t, ok)
+	testdata := filepath.Join(filepath.Dir(file), "testdata")
+
+	workdir, err := internal.NewWorkdir(filepath.Join(testdata, "modules"))
+	require.NoError(t, err)
+	return filepath.Join(testdata, "terraform"), workdir
+}
@@ -108,6 +108,11 @@ func (m *ActionHandler) Update(msg tea.Msg) tea.Cmd {
 			fn := func(workspaceID resource.ID) (task.Spec, error) {
 				return m.Plans.Apply(workspaceID, createPlanOptions)
 			}
+			if len(ids) > 1 {
+				// Preview the task group, from which the user can confirm
+				// or abort.
+				return m.PreviewTasks(fn, ids...)
+			}
 			return YesNoPrompt(
 				fmt.Sprintf(applyPrompt, len(ids)),
 				m.CreateTasks(fn, ids...),
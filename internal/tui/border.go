@@ -50,8 +50,8 @@ func borderize(content string, active bool, embeddedText map[BorderPosition]stri
 			false: lipgloss.Border(lipgloss.NormalBorder()),
 		}
 		color = map[bool]lipgloss.TerminalColor{
-			true:  Blue,
-			false: InactivePreviewBorder,
+			true:  ActiveBorder,
+			false: InactiveBorder,
 		}
 		border = thickness[active]
 		style  = lipgloss.NewStyle().Foreground(color[active])
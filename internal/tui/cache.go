@@ -26,6 +26,10 @@ func (c *Cache) Put(page Page, model ChildModel) {
 	c.cache[page] = model
 }
 
+func (c *Cache) Delete(page Page) {
+	delete(c.cache, page)
+}
+
 func (c *Cache) UpdateAll(msg tea.Msg) []tea.Cmd {
 	cmds := make([]tea.Cmd, len(c.cache))
 	var i int
@@ -2,73 +2,78 @@ package tui
 
 import "github.com/charmbracelet/lipgloss"
 
-const (
-	Black           = lipgloss.Color("#000000")
-	DarkRed         = lipgloss.Color("#FF0000")
-	Red             = lipgloss.Color("#FF5353")
-	Purple          = lipgloss.Color("135")
-	Orange          = lipgloss.Color("214")
-	BurntOrange     = lipgloss.Color("214")
-	Yellow          = lipgloss.Color("#DBBD70")
-	Green           = lipgloss.Color("34")
-	Turquoise       = lipgloss.Color("86")
-	DarkGreen       = lipgloss.Color("#325451")
-	LightGreen      = lipgloss.Color("47")
-	GreenBlue       = lipgloss.Color("#00A095")
-	DeepBlue        = lipgloss.Color("39")
-	LightBlue       = lipgloss.Color("81")
-	LightishBlue    = lipgloss.Color("75")
-	Blue            = lipgloss.Color("63")
-	Violet          = lipgloss.Color("13")
-	Grey            = lipgloss.Color("#737373")
-	LightGrey       = lipgloss.Color("245")
-	LighterGrey     = lipgloss.Color("250")
-	EvenLighterGrey = lipgloss.Color("253")
-	DarkGrey        = lipgloss.Color("#606362")
-	White           = lipgloss.Color("#ffffff")
-	OffWhite        = lipgloss.Color("#a8a7a5")
-	HotPink         = lipgloss.Color("200")
-)
-
+// Colors with which the TUI is rendered. They default to those of the dark
+// theme and are set according to the user's choice of theme upon startup.
 var (
-	DebugLogLevel = Blue
-	InfoLogLevel  = lipgloss.AdaptiveColor{Dark: string(Turquoise), Light: string(Green)}
-	ErrorLogLevel = Red
-	WarnLogLevel  = Yellow
+	Black           = darkTheme.Black
+	Red             = darkTheme.Red
+	Orange          = darkTheme.Orange
+	BurntOrange     = darkTheme.BurntOrange
+	Green           = darkTheme.Green
+	LightGreen      = darkTheme.LightGreen
+	GreenBlue       = darkTheme.GreenBlue
+	Blue            = darkTheme.Blue
+	Grey            = darkTheme.Grey
+	LightGrey       = darkTheme.LightGrey
+	LighterGrey     = darkTheme.LighterGrey
+	EvenLighterGrey = darkTheme.EvenLighterGrey
+	DarkGrey        = darkTheme.DarkGrey
+	White           = darkTheme.White
+	HotPink         = darkTheme.HotPink
 
-	LogRecordAttributeKey = lipgloss.AdaptiveColor{Dark: string(LightGrey), Light: string(LightGrey)}
+	DebugLogLevel = darkTheme.DebugLogLevel
+	InfoLogLevel  = darkTheme.InfoLogLevel
+	ErrorLogLevel = darkTheme.ErrorLogLevel
+	WarnLogLevel  = darkTheme.WarnLogLevel
 
-	HelpKey = lipgloss.AdaptiveColor{
-		Dark:  "ff",
-		Light: "",
-	}
-	HelpDesc = lipgloss.AdaptiveColor{
-		Dark:  "248",
-		Light: "246",
-	}
+	LogRecordAttributeKey = darkTheme.LogRecordAttributeKey
 
-	InactivePreviewBorder = lipgloss.AdaptiveColor{
-		Dark:  "244",
-		Light: "250",
-	}
+	HelpKey  = darkTheme.HelpKey
+	HelpDesc = darkTheme.HelpDesc
 
-	CurrentBackground            = Grey
-	CurrentForeground            = White
-	SelectedBackground           = lipgloss.Color("110")
-	SelectedForeground           = Black
-	CurrentAndSelectedBackground = lipgloss.Color("117")
-	CurrentAndSelectedForeground = Black
+	ActiveBorder   = darkTheme.ActiveBorder
+	InactiveBorder = darkTheme.InactiveBorder
 
-	TitleColor = lipgloss.AdaptiveColor{
-		Dark:  "",
-		Light: "",
-	}
+	CurrentBackground            = darkTheme.CurrentBackground
+	CurrentForeground            = darkTheme.CurrentForeground
+	SelectedBackground           = darkTheme.SelectedBackground
+	SelectedForeground           = darkTheme.SelectedForeground
+	CurrentAndSelectedBackground = darkTheme.CurrentAndSelectedBackground
+	CurrentAndSelectedForeground = darkTheme.CurrentAndSelectedForeground
 
-	GroupReportBackgroundColor = EvenLighterGrey
-	TaskSummaryBackgroundColor = EvenLighterGrey
+	GroupReportBackground = darkTheme.GroupReportBackground
 
-	ScrollPercentageBackground = lipgloss.AdaptiveColor{
-		Dark:  string(DarkGrey),
-		Light: string(EvenLighterGrey),
-	}
+	ModuleColor    = darkTheme.ModuleColor
+	WorkspaceColor = darkTheme.WorkspaceColor
+
+	PendingStatus = darkTheme.PendingStatus
+	QueuedStatus  = darkTheme.QueuedStatus
+	RunningStatus = darkTheme.RunningStatus
+	ExitedStatus  = darkTheme.ExitedStatus
+	ErroredStatus = darkTheme.ErroredStatus
+
+	JSONKey    = darkTheme.JSONKey
+	JSONString = darkTheme.JSONString
+	JSONBool   = darkTheme.JSONBool
+	JSONNumber = darkTheme.JSONNumber
+	JSONNull   = darkTheme.JSONNull
 )
+
+// RowStyle returns the style with which to render a row in a table or tree,
+// according to whether it is the current row and whether it is selected.
+func RowStyle(current, selected bool) lipgloss.Style {
+	style := Regular
+	switch {
+	case current && selected:
+		style = style.Background(CurrentAndSelectedBackground).Foreground(CurrentAndSelectedForeground)
+	case current:
+		style = style.Background(CurrentBackground).Foreground(CurrentForeground)
+	case selected:
+		style = style.Background(SelectedBackground).Foreground(SelectedForeground)
+	}
+	if noColor {
+		// Without colors, distinguish rows with text attributes instead.
+		style = style.Underline(current).Bold(selected)
+	}
+	return style
+}
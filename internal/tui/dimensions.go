@@ -5,6 +5,11 @@ const (
 	MinHeight = 24
 	// Height of prompt including borders
 	PromptHeight = 3
+	// Height of command palette including borders
+	PaletteHeight = 11
+	// PaletteResults is the number of actions listed in the command palette,
+	// below its search input.
+	PaletteResults = PaletteHeight - 3
 	// FooterHeight is the height of the footer at the bottom of the TUI.
 	FooterHeight = 1
 	// Height of help widget, including borders
@@ -27,6 +32,9 @@ func init() {
 	if (minPaneHeight*2)+PromptHeight+HelpWidgetHeight > MinContentHeight {
 		panic("mininum heights of panes, prompt, footer, and help cannot exceed overall minimum height")
 	}
+	if (minPaneHeight*2)+PaletteHeight > MinContentHeight {
+		panic("mininum heights of panes and palette cannot exceed overall minimum height")
+	}
 	if minPaneWidth*2 > MinContentWidth {
 		panic("minimum width of panes must be no more than half of the minimum content width")
 	}
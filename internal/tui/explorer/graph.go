@@ -0,0 +1,330 @@
+package explorer
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/leg100/pug/internal"
+	"github.com/leg100/pug/internal/module"
+	"github.com/leg100/pug/internal/resource"
+	"github.com/leg100/pug/internal/tui"
+	"github.com/leg100/pug/internal/tui/keys"
+)
+
+// maxGraphWidth is the maximum width of the graph column, beyond which the
+// column listing dependencies begins.
+const maxGraphWidth = 60
+
+type GraphMaker struct {
+	Modules *module.Service
+	Helpers *tui.Helpers
+}
+
+func (mm *GraphMaker) Make(id resource.ID, width, height int) (tui.ChildModel, error) {
+	m := &graphModel{
+		Helpers:  mm.Helpers,
+		graph:    mm.Modules.Graph(),
+		selected: make(map[resource.ID]struct{}),
+		width:    width,
+		height:   height,
+	}
+	m.common = &tui.ActionHandler{
+		Helpers:     mm.Helpers,
+		IDRetriever: m,
+	}
+	return m, nil
+}
+
+// graphModel renders the module dependency graph as a list of modules ordered
+// by layer and indented according to their layer, each module followed by the
+// modules it depends upon. Edges are not drawn. The modules upstream and
+// downstream of the current module are highlighted.
+type graphModel struct {
+	*tui.Helpers
+
+	common   *tui.ActionHandler
+	graph    *module.Graph
+	cursor   int
+	start    int
+	selected map[resource.ID]struct{}
+	// upstream and downstream are the modules respectively upstream and
+	// downstream of the current module.
+	upstream, downstream map[resource.ID]struct{}
+	width, height        int
+}
+
+func (m *graphModel) Init() tea.Cmd {
+	m.highlight()
+	return nil
+}
+
+func (m *graphModel) Update(msg tea.Msg) tea.Cmd {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, keys.Navigation.LineUp):
+			m.moveCursor(-1)
+		case key.Matches(msg, keys.Navigation.LineDown):
+			m.moveCursor(1)
+		case key.Matches(msg, keys.Navigation.PageUp):
+			m.moveCursor(-m.height)
+		case key.Matches(msg, keys.Navigation.PageDown):
+			m.moveCursor(m.height)
+		case key.Matches(msg, keys.Navigation.HalfPageUp):
+			m.moveCursor(-m.height / 2)
+		case key.Matches(msg, keys.Navigation.HalfPageDown):
+			m.moveCursor(m.height / 2)
+		case key.Matches(msg, keys.Navigation.GotoTop):
+			m.moveCursor(-m.cursor)
+		case key.Matches(msg, keys.Navigation.GotoBottom):
+			m.moveCursor(len(m.graph.Nodes))
+		case key.Matches(msg, keys.Global.Select):
+			if node, ok := m.current(); ok {
+				if _, ok := m.selected[node.Module.ID]; ok {
+					delete(m.selected, node.Module.ID)
+				} else {
+					m.selected[node.Module.ID] = struct{}{}
+				}
+			}
+		case key.Matches(msg, keys.Global.SelectAll):
+			for _, node := range m.graph.Nodes {
+				m.selected[node.Module.ID] = struct{}{}
+			}
+		case key.Matches(msg, keys.Global.SelectClear):
+			clear(m.selected)
+		case key.Matches(msg, graphKeys.SelectUpstream):
+			return m.selectWith(m.graph.Upstream)
+		case key.Matches(msg, graphKeys.SelectDownstream):
+			return m.selectWith(m.graph.Downstream)
+		default:
+			return m.common.Update(msg)
+		}
+	case resource.Event[*module.Module]:
+		m.rebuild()
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		m.moveCursor(0)
+	}
+	return nil
+}
+
+// selectWith replaces the selection with the current module together with the
+// modules retrieved by the given func.
+func (m *graphModel) selectWith(fn func(resource.ID) []resource.ID) tea.Cmd {
+	node, ok := m.current()
+	if !ok {
+		return tui.ReportError(errors.New("no module selected"))
+	}
+	clear(m.selected)
+	m.selected[node.Module.ID] = struct{}{}
+	for _, id := range fn(node.Module.ID) {
+		m.selected[id] = struct{}{}
+	}
+	return nil
+}
+
+// rebuild rebuilds the graph, retaining the current module and any selected
+// modules that still exist.
+func (m *graphModel) rebuild() {
+	var currentID resource.ID
+	if node, ok := m.current(); ok {
+		currentID = node.Module.ID
+	}
+	m.graph = m.Modules.Graph()
+	for id := range m.selected {
+		if _, ok := m.graph.Node(id); !ok {
+			delete(m.selected, id)
+		}
+	}
+	for i, node := range m.graph.Nodes {
+		if node.Module.ID == currentID {
+			m.cursor = i
+			break
+		}
+	}
+	m.moveCursor(0)
+}
+
+func (m *graphModel) current() (*module.GraphNode, bool) {
+	if m.cursor < 0 || m.cursor >= len(m.graph.Nodes) {
+		return nil, false
+	}
+	return m.graph.Nodes[m.cursor], true
+}
+
+// moveCursor moves the cursor by n lines, scrolling the visible lines if
+// necessary.
+func (m *graphModel) moveCursor(n int) {
+	m.cursor = clamp(m.cursor+n, 0, max(0, len(m.graph.Nodes)-1))
+	switch {
+	case m.cursor < m.start:
+		m.start = m.cursor
+	case m.cursor >= m.start+m.height:
+		m.start = m.cursor - m.height + 1
+	}
+	m.start = clamp(m.start, 0, max(0, len(m.graph.Nodes)-m.height))
+	m.highlight()
+}
+
+// highlight determines the modules upstream and downstream of the current
+// module.
+func (m *graphModel) highlight() {
+	m.upstream = make(map[resource.ID]struct{})
+	m.downstream = make(map[resource.ID]struct{})
+	node, ok := m.current()
+	if !ok {
+		return
+	}
+	for _, id := range m.graph.Upstream(node.Module.ID) {
+		m.upstream[id] = struct{}{}
+	}
+	for _, id := range m.graph.Downstream(node.Module.ID) {
+		m.downstream[id] = struct{}{}
+	}
+}
+
+func (m *graphModel) View() string {
+	if len(m.graph.Nodes) == 0 {
+		return "No modules found"
+	}
+	// Render the graph column of each node, determining the width of the
+	// column.
+	graphs := make([]string, len(m.graph.Nodes))
+	var graphWidth int
+	for i, node := range m.graph.Nodes {
+		graphs[i] = strings.Repeat("  ", node.Layer) + "● " + node.Module.Path
+		graphWidth = max(graphWidth, lipgloss.Width(graphs[i]))
+	}
+	graphWidth = min(graphWidth, maxGraphWidth)
+
+	var (
+		numVisible = clamp(m.height, 0, len(m.graph.Nodes)-m.start)
+		lines      = make([]string, numVisible)
+		rowStyle   = lipgloss.NewStyle().
+				Width(m.width - tui.ScrollbarWidth).
+				MaxWidth(m.width - tui.ScrollbarWidth).
+				Inline(true)
+	)
+	for i := range lines {
+		var (
+			idx      = m.start + i
+			node     = m.graph.Nodes[idx]
+			marker   = "  "
+			style    = tui.Regular
+			current  = idx == m.cursor
+			_, isSel = m.selected[node.Module.ID]
+		)
+		if _, ok := m.upstream[node.Module.ID]; ok {
+			marker = "↑ "
+			style = style.Foreground(tui.Blue)
+		} else if _, ok := m.downstream[node.Module.ID]; ok {
+			marker = "↓ "
+			style = style.Foreground(tui.Orange)
+		}
+		graph := graphs[idx]
+		if lipgloss.Width(graph) > graphWidth {
+			graph = ansi.Truncate(graph, graphWidth, "…")
+		}
+		line := marker + style.Render(graph) + strings.Repeat(" ", graphWidth-lipgloss.Width(graph))
+		if node.InCycle {
+			line += tui.Regular.Foreground(tui.Red).Render(" ⟳ cycle")
+		}
+		if len(node.Dependencies) > 0 {
+			deps := make([]string, len(node.Dependencies))
+			for j, dep := range node.Dependencies {
+				deps[j] = dep.Path
+			}
+			line += tui.Regular.Foreground(tui.LightGrey).Render("  depends on " + strings.Join(deps, ", "))
+		}
+		line = rowStyle.Render(line)
+		// If current row or selected rows, strip colors and apply background
+		// color
+		if current || isSel {
+			line = tui.RowStyle(current, isSel).Render(internal.StripAnsi(line))
+		}
+		lines[i] = line
+	}
+	scrollbar := tui.Scrollbar(m.height, len(m.graph.Nodes), numVisible, m.start)
+	return lipgloss.JoinHorizontal(lipgloss.Left,
+		strings.Join(lines, "\n"),
+		scrollbar,
+	)
+}
+
+func (m *graphModel) BorderText() map[tui.BorderPosition]string {
+	text := map[tui.BorderPosition]string{
+		tui.TopLeftBorder: tui.Bold.Render("dependency graph"),
+		tui.TopMiddleBorder: fmt.Sprintf(
+			"%s%s",
+			tui.ModuleIcon(),
+			tui.ModuleStyle.Render(fmt.Sprintf("%d", len(m.graph.Nodes))),
+		),
+	}
+	if n := len(m.graph.Cycles); n > 0 {
+		cycles := make([]string, n)
+		for i, cycle := range m.graph.Cycles {
+			paths := make([]string, len(cycle))
+			for j, mod := range cycle {
+				paths[j] = mod.Path
+			}
+			cycles[i] = strings.Join(paths, " ⇄ ")
+		}
+		text[tui.BottomMiddleBorder] = tui.Regular.Foreground(tui.Red).Render(
+			fmt.Sprintf("%d cycles: %s", n, strings.Join(cycles, "; ")),
+		)
+	}
+	return text
+}
+
+// GetModuleIDs returns the IDs of the selected modules, in graph order, or if
+// no modules are selected, the ID of the current module.
+func (m *graphModel) GetModuleIDs() ([]resource.ID, error) {
+	var ids []resource.ID
+	for _, node := range m.graph.Nodes {
+		if _, ok := m.selected[node.Module.ID]; ok {
+			ids = append(ids, node.Module.ID)
+		}
+	}
+	if len(ids) > 0 {
+		return ids, nil
+	}
+	node, ok := m.current()
+	if !ok {
+		return nil, errors.New("no module selected")
+	}
+	return []resource.ID{node.Module.ID}, nil
+}
+
+// GetWorkspaceIDs returns the IDs of the current workspaces of the modules
+// returned by GetModuleIDs. An error is returned if any module does not have a
+// current workspace.
+func (m *graphModel) GetWorkspaceIDs() ([]resource.ID, error) {
+	ids, err := m.GetModuleIDs()
+	if err != nil {
+		return nil, err
+	}
+	for i, moduleID := range ids {
+		mod, err := m.Modules.Get(moduleID)
+		if err != nil {
+			return nil, err
+		}
+		if mod.CurrentWorkspaceID == nil {
+			return nil, errors.New("modules must have a current workspace")
+		}
+		ids[i] = mod.CurrentWorkspaceID
+	}
+	return ids, nil
+}
+
+func (m *graphModel) HelpBindings() []key.Binding {
+	return append(m.common.HelpBindings(),
+		graphKeys.SelectUpstream,
+		graphKeys.SelectDownstream,
+	)
+}
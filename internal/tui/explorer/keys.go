@@ -1,12 +1,16 @@
 package explorer
 
-import "github.com/charmbracelet/bubbles/key"
+import (
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/leg100/pug/internal/tui/keys"
+)
 
 type keyMap struct {
 	Enter               key.Binding
 	SetCurrentWorkspace key.Binding
 	ReloadModules       key.Binding
 	ReloadWorkspaces    key.Binding
+	Compare             key.Binding
 }
 
 var localKeys = keyMap{
@@ -26,4 +30,29 @@ var localKeys = keyMap{
 		key.WithKeys("ctrl+w"),
 		key.WithHelp("ctrl+w", "reload workspaces"),
 	),
+	Compare: key.NewBinding(
+		key.WithKeys("="),
+		key.WithHelp("=", "compare workspaces"),
+	),
+}
+
+type graphKeyMap struct {
+	SelectUpstream   key.Binding
+	SelectDownstream key.Binding
+}
+
+var graphKeys = graphKeyMap{
+	SelectUpstream: key.NewBinding(
+		key.WithKeys("K"),
+		key.WithHelp("K", "select module and upstream"),
+	),
+	SelectDownstream: key.NewBinding(
+		key.WithKeys("J"),
+		key.WithHelp("J", "select module and downstream"),
+	),
+}
+
+func init() {
+	keys.Register("explorer", &localKeys, "explorer")
+	keys.Register("graph", &graphKeys, "graph")
 }
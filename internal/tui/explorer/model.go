@@ -1,8 +1,10 @@
 package explorer
 
 import (
+	"cmp"
 	"errors"
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/cursor"
@@ -75,6 +77,9 @@ const (
 	queueBuildTree
 )
 
+// Height of filter widget
+const filterHeight = 2
+
 func (m *model) Init() tea.Cmd {
 	return tea.Batch(
 		m.buildTree,
@@ -142,9 +147,43 @@ func (m *model) Update(msg tea.Msg) tea.Cmd {
 			return m.CreateTasks(m.Workspaces.Reload, ids...)
 		case key.Matches(msg, localKeys.ReloadModules):
 			return reload(false, m.Modules)
+		case key.Matches(msg, localKeys.Compare):
+			ids, err := m.GetWorkspaceIDs()
+			if err != nil {
+				return tui.ReportError(err)
+			}
+			if len(ids) != 2 {
+				return tui.ReportError(errors.New("select exactly two workspaces to compare"))
+			}
+			// Sort workspaces in the order they were created, to ensure a
+			// consistent order regardless of the order they were selected.
+			slices.SortFunc(ids, func(a, b resource.ID) int {
+				return cmp.Compare(a.(resource.MonotonicID).Serial, b.(resource.MonotonicID).Serial)
+			})
+			id := state.CompareID{A: ids[0], B: ids[1]}
+			return tui.NavigateTo(tui.CompareKind, tui.WithParent(id))
 		default:
 			return m.common.Update(msg)
 		}
+	case tea.MouseMsg:
+		if msg.Action != tea.MouseActionPress {
+			return nil
+		}
+		switch msg.Button {
+		case tea.MouseButtonLeft:
+			// Move the cursor to the clicked node.
+			line := msg.Y
+			if m.filterVisible() {
+				line -= filterHeight
+			}
+			if line >= 0 && line < m.treeHeight() && m.tracker.start+line < len(m.tracker.nodes) {
+				m.tracker.moveCursor(m.tracker.start+line-m.tracker.cursorIndex, m.treeHeight())
+			}
+		case tea.MouseButtonWheelUp:
+			m.tracker.moveCursor(-1, m.treeHeight())
+		case tea.MouseButtonWheelDown:
+			m.tracker.moveCursor(1, m.treeHeight())
+		}
 	case builtTreeMsg:
 		m.tree = msg.tree
 		m.renderedTree = msg.rendered
@@ -200,9 +239,6 @@ func (m *model) Update(msg tea.Msg) tea.Cmd {
 }
 
 func (m model) treeHeight() int {
-	// Height of filter widget
-	const filterHeight = 2
-
 	if m.filterVisible() {
 		return max(0, m.height-filterHeight)
 	}
@@ -232,29 +268,14 @@ func (m model) View() string {
 		// Style node according to whether it is the cursor node, selected, or
 		// both
 		var (
-			background lipgloss.Color
-			foreground lipgloss.Color
-			current    = node.ID() == m.tracker.cursorNode.ID()
-			selected   = m.tracker.isSelected(node)
+			current  = node.ID() == m.tracker.cursorNode.ID()
+			selected = m.tracker.isSelected(node)
 		)
-		if current && selected {
-			background = tui.CurrentAndSelectedBackground
-			foreground = tui.CurrentAndSelectedForeground
-		} else if current {
-			background = tui.CurrentBackground
-			foreground = tui.CurrentForeground
-		} else if selected {
-			background = tui.SelectedBackground
-			foreground = tui.SelectedForeground
-		}
 		renderedRow := treeStyle.Render(visibleLines[i])
 		// If current row or selected rows, strip colors and apply background color
 		if current || selected {
 			renderedRow = internal.StripAnsi(renderedRow)
-			renderedRow = lipgloss.NewStyle().
-				Foreground(foreground).
-				Background(background).
-				Render(renderedRow)
+			renderedRow = tui.RowStyle(current, selected).Render(renderedRow)
 		}
 		visibleLines[i] = renderedRow
 	}
@@ -361,5 +382,6 @@ func (m model) HelpBindings() []key.Binding {
 		bindings = append(bindings, localKeys.SetCurrentWorkspace)
 		bindings = append(bindings, keys.Common.Delete)
 	}
+	bindings = append(bindings, localKeys.Compare)
 	return bindings
 }
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
+	"time"
 
 	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
@@ -30,6 +31,7 @@ type Helpers struct {
 	Plans      *plan.Service
 	Tasks      *task.Service
 	States     *state.Service
+	History    *task.History
 	Logger     logging.Interface
 	Workdir    internal.Workdir
 }
@@ -199,22 +201,24 @@ func WorkspaceNameWithIcon(name string, squareBrackets bool) string {
 
 // TaskStatus provides a rendered colored task status.
 func (h *Helpers) TaskStatus(t *task.Task, table bool) string {
-	var color lipgloss.Color
+	return Regular.Foreground(TaskStatusColor(t.State)).Render(string(t.State))
+}
 
-	switch t.State {
+// TaskStatusColor returns the color with which to render a task status.
+func TaskStatusColor(status task.Status) lipgloss.Color {
+	switch status {
 	case task.Pending:
-		color = Grey
+		return PendingStatus
 	case task.Queued:
-		color = Orange
+		return QueuedStatus
 	case task.Running:
-		color = Blue
+		return RunningStatus
 	case task.Exited:
-		color = GreenBlue
+		return ExitedStatus
 	case task.Errored:
-		color = Red
+		return ErroredStatus
 	}
-
-	return Regular.Foreground(color).Render(string(t.State))
+	return ""
 }
 
 // TaskSummary renders a summary of the task's outcome.
@@ -283,7 +287,7 @@ func (h *Helpers) CostSummary(report workspace.CostSummary, inherit lipgloss.Sty
 func (h *Helpers) GroupReport(group *task.Group, table bool) string {
 	var inherit lipgloss.Style
 	if !table {
-		inherit = Padded.Background(GroupReportBackgroundColor)
+		inherit = Padded.Background(GroupReportBackground)
 	}
 	slash := Regular.Inherit(inherit).Foreground(Grey).Render("/")
 	exited := Regular.Inherit(inherit).Foreground(Green).Render(fmt.Sprintf("%d", group.Exited()))
@@ -297,7 +301,36 @@ func (h *Helpers) GroupReport(group *task.Group, table bool) string {
 	if table {
 		return s
 	}
-	return Padded.Background(GroupReportBackgroundColor).Render(s)
+	return Padded.Background(GroupReportBackground).Render(s)
+}
+
+// TaskETA renders an estimate of the time remaining until a task finishes,
+// flagging the task if it has been running for much longer than usual. An
+// empty string is returned if there is no estimate.
+func (h *Helpers) TaskETA(t *task.Task) string {
+	eta, ok := h.History.TaskETA(t)
+	if !ok {
+		return ""
+	}
+	if eta.Overdue {
+		return Regular.Foreground(Red).Render("⚠ overdue")
+	}
+	return "~" + FormatDuration(eta.Remaining)
+}
+
+// GroupETA renders an estimate of the time remaining until all of a task
+// group's tasks finish. An empty string is returned if there is no estimate.
+func (h *Helpers) GroupETA(group *task.Group) string {
+	remaining, ok := h.History.GroupETA(group)
+	if !ok {
+		return ""
+	}
+	return "~" + FormatDuration(remaining)
+}
+
+// FormatDuration formats a duration to the nearest second.
+func FormatDuration(d time.Duration) string {
+	return d.Round(time.Second).String()
 }
 
 // CreateTasks repeatedly invokes fn with each id in ids, creating a task for
@@ -324,18 +357,37 @@ func (h *Helpers) CreateTasks(fn task.SpecFunc, ids ...resource.ID) tea.Cmd {
 			}
 			return NewNavigationMsg(TaskKind, WithParent(task.ID))
 		default:
-			specs := make([]task.Spec, 0, len(ids))
-			for _, id := range ids {
-				spec, err := fn(id)
-				if err != nil {
-					h.Logger.Error("creating task spec", "error", err, "id", id)
-					continue
-				}
-				specs = append(specs, spec)
-			}
-			return h.createTaskGroup(specs...)
+			return h.createTaskGroup(h.createSpecs(fn, ids...)...)
+		}
+	}
+}
+
+// PreviewTasks repeatedly invokes fn with each id in ids, previewing the task
+// group that would be created, and sends the user to the preview's page, from
+// which the user can either confirm or abort creation of the task group.
+func (h *Helpers) PreviewTasks(fn task.SpecFunc, ids ...resource.ID) tea.Cmd {
+	return func() tea.Msg {
+		preview, err := h.Tasks.PreviewGroup(h.createSpecs(fn, ids...)...)
+		if err != nil {
+			return ErrorMsg(fmt.Errorf("previewing task group: %w", err))
+		}
+		return NewNavigationMsg(TaskGroupPreviewKind, WithParent(preview.Group.ID))
+	}
+}
+
+// createSpecs invokes fn with each id in ids, logging and skipping any that
+// fail.
+func (h *Helpers) createSpecs(fn task.SpecFunc, ids ...resource.ID) []task.Spec {
+	specs := make([]task.Spec, 0, len(ids))
+	for _, id := range ids {
+		spec, err := fn(id)
+		if err != nil {
+			h.Logger.Error("creating task spec", "error", err, "id", id)
+			continue
 		}
+		specs = append(specs, spec)
 	}
+	return specs
 }
 
 func (h *Helpers) CreateTasksWithSpecs(specs ...task.Spec) tea.Cmd {
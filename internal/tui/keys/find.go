@@ -0,0 +1,37 @@
+package keys
+
+import (
+	"github.com/charmbracelet/bubbles/key"
+)
+
+type find struct {
+	NextMatch   key.Binding
+	PrevMatch   key.Binding
+	NextError   key.Binding
+	NextWarning key.Binding
+	NextChange  key.Binding
+}
+
+// Find is a key map of keys for finding text in task output.
+var Find = find{
+	NextMatch: key.NewBinding(
+		key.WithKeys("n"),
+		key.WithHelp("n", "next match"),
+	),
+	PrevMatch: key.NewBinding(
+		key.WithKeys("N"),
+		key.WithHelp("N", "previous match"),
+	),
+	NextError: key.NewBinding(
+		key.WithKeys("!"),
+		key.WithHelp("!", "next error"),
+	),
+	NextWarning: key.NewBinding(
+		key.WithKeys("W"),
+		key.WithHelp("W", "next warning"),
+	),
+	NextChange: key.NewBinding(
+		key.WithKeys("#"),
+		key.WithHelp("#", "next resource change"),
+	),
+}
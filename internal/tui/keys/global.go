@@ -9,6 +9,10 @@ type global struct {
 	Tasks            key.Binding
 	TaskGroups       key.Binding
 	Logs             key.Binding
+	Search           key.Binding
+	ModuleGraph      key.Binding
+	Top              key.Binding
+	Palette          key.Binding
 	Select           key.Binding
 	SelectAll        key.Binding
 	SelectClear      key.Binding
@@ -42,6 +46,22 @@ var Global = global{
 		key.WithKeys("l"),
 		key.WithHelp("l", "logs"),
 	),
+	Search: key.NewBinding(
+		key.WithKeys("ctrl+f"),
+		key.WithHelp("ctrl+f", "search state"),
+	),
+	ModuleGraph: key.NewBinding(
+		key.WithKeys("M"),
+		key.WithHelp("M", "module dependency graph"),
+	),
+	Top: key.NewBinding(
+		key.WithKeys("%"),
+		key.WithHelp("%", "task resource usage"),
+	),
+	Palette: key.NewBinding(
+		key.WithKeys(":", "ctrl+p"),
+		key.WithHelp(":", "command palette"),
+	),
 	Select: key.NewBinding(
 		key.WithKeys(" "),
 		key.WithHelp("<space>", "select"),
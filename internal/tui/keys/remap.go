@@ -0,0 +1,172 @@
+package keys
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/leg100/pug/internal"
+)
+
+// keyMap is a named key map whose bindings can be remapped by the user.
+type keyMap struct {
+	name string
+	// ptr is a pointer to a struct of key.Binding fields.
+	ptr any
+	// panes are the names of the panes in which the key map's bindings are
+	// available. If empty then they're available in every pane.
+	panes []string
+}
+
+// keyMaps are the registered key maps, in order of registration.
+var keyMaps = []keyMap{
+	{name: "global", ptr: &Global},
+	{name: "navigation", ptr: &Navigation},
+	{name: "common", ptr: &Common},
+	{name: "filter", ptr: &Filter, panes: []string{filterPane}},
+}
+
+// filterPane is the pseudo-pane in which the user edits a filter.
+const filterPane = "filter"
+
+// Register registers a key map, permitting the user to remap its bindings.
+// The keymap must be a pointer to a struct of key.Binding fields. Panes names
+// the panes in which the bindings are available, within which remapped keys
+// must not conflict.
+func Register(name string, keymap any, panes ...string) {
+	keyMaps = append(keyMaps, keyMap{name: name, ptr: keymap, panes: panes})
+}
+
+// Remap remaps the keys of actions, where an action is named after its key map
+// and binding, e.g. global.autoscroll for the Autoscroll binding in the Global
+// key map. An action mapped to no keys, or to "none", is disabled. An error is
+// returned if an action is unknown or if a remapped key conflicts with another
+// key in the same pane.
+func Remap(remaps map[string][]string) error {
+	actions := make(map[string]*key.Binding)
+	defaults := make(map[*key.Binding][]string)
+	for _, km := range keyMaps {
+		for name, b := range km.bindings() {
+			actions[name] = b
+			defaults[b] = b.Keys()
+		}
+	}
+	for _, name := range sortedKeys(remaps) {
+		b, ok := actions[name]
+		if !ok {
+			return fmt.Errorf("remapping keys: unknown action: %s", name)
+		}
+		var keys []string
+		for _, k := range remaps[name] {
+			if k != "" && k != "none" {
+				keys = append(keys, k)
+			}
+		}
+		if len(keys) == 0 {
+			b.SetEnabled(false)
+			continue
+		}
+		b.SetKeys(keys...)
+		b.SetHelp(helpKeys(keys), b.Help().Desc)
+	}
+	return checkConflicts(defaults)
+}
+
+// checkConflicts checks that no two enabled bindings available in the same
+// pane share a key, unless they shared it before they were remapped: some
+// bindings deliberately override others, e.g. enter in the tasks pane.
+func checkConflicts(defaults map[*key.Binding][]string) error {
+	var errs []error
+	for _, pane := range panes() {
+		type action struct {
+			name    string
+			binding *key.Binding
+		}
+		byKey := make(map[string][]action)
+		for _, km := range keyMaps {
+			if len(km.panes) > 0 && !slices.Contains(km.panes, pane) {
+				continue
+			}
+			if len(km.panes) == 0 && pane == filterPane {
+				// Only the filter keys are handled whilst filtering.
+				continue
+			}
+			for name, b := range km.bindings() {
+				if !b.Enabled() {
+					continue
+				}
+				for _, k := range b.Keys() {
+					byKey[k] = append(byKey[k], action{name: name, binding: b})
+				}
+			}
+		}
+		for _, k := range sortedKeys(byKey) {
+			for i, a := range byKey[k] {
+				for _, b := range byKey[k][i+1:] {
+					if slices.Contains(defaults[a.binding], k) && slices.Contains(defaults[b.binding], k) {
+						continue
+					}
+					errs = append(errs, fmt.Errorf("key %q in %s pane is mapped to both %s and %s", k, pane, a.name, b.name))
+				}
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("remapping keys: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// bindings iterates over the key map's bindings along with the names of their
+// actions.
+func (km keyMap) bindings() func(yield func(string, *key.Binding) bool) {
+	return func(yield func(string, *key.Binding) bool) {
+		v := reflect.ValueOf(km.ptr).Elem()
+		for i := 0; i < v.NumField(); i++ {
+			name := km.name + "." + internal.KebabCase(v.Type().Field(i).Name)
+			if !yield(name, v.Field(i).Addr().Interface().(*key.Binding)) {
+				return
+			}
+		}
+	}
+}
+
+// panes returns the names of all panes in which bindings are available.
+func panes() (names []string) {
+	for _, km := range keyMaps {
+		for _, pane := range km.panes {
+			if !slices.Contains(names, pane) {
+				names = append(names, pane)
+			}
+		}
+	}
+	return names
+}
+
+// helpKeys renders keys for display in help.
+func helpKeys(keys []string) string {
+	help := make([]string, len(keys))
+	for i, k := range keys {
+		switch k {
+		case " ":
+			help[i] = "<space>"
+		case "ctrl+@":
+			help[i] = "ctrl+<space>"
+		default:
+			help[i] = k
+		}
+	}
+	return strings.Join(help, "/")
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,94 @@
+package keys
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRemap(t *testing.T) {
+	type testKeyMap struct {
+		TargetedPlan key.Binding
+		Compare      key.Binding
+	}
+
+	// setup registers a key map for a test pane, restoring the original key
+	// maps once the test finishes.
+	setup := func(t *testing.T) *testKeyMap {
+		global, navigation, common, filter := Global, Navigation, Common, Filter
+		registered := slices.Clone(keyMaps)
+		t.Cleanup(func() {
+			Global, Navigation, Common, Filter = global, navigation, common, filter
+			keyMaps = registered
+		})
+		km := &testKeyMap{
+			// Deliberately overrides the common plan key.
+			TargetedPlan: key.NewBinding(key.WithKeys("p"), key.WithHelp("p", "targeted plan")),
+			Compare:      key.NewBinding(key.WithKeys("="), key.WithHelp("=", "compare")),
+		}
+		Register("test", km, "test")
+		return km
+	}
+
+	t.Run("no remaps", func(t *testing.T) {
+		setup(t)
+
+		require.NoError(t, Remap(nil))
+	})
+
+	t.Run("remap", func(t *testing.T) {
+		km := setup(t)
+
+		err := Remap(map[string][]string{
+			"global.autoscroll": {"ctrl+y"},
+			"test.compare":      {"C", " "},
+			"global.select":     {"S"},
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"ctrl+y"}, Global.Autoscroll.Keys())
+		assert.Equal(t, key.Help{Key: "ctrl+y", Desc: "toggle autoscroll"}, Global.Autoscroll.Help())
+		assert.Equal(t, []string{"C", " "}, km.Compare.Keys())
+		assert.Equal(t, "C/<space>", km.Compare.Help().Key)
+	})
+
+	t.Run("disable", func(t *testing.T) {
+		km := setup(t)
+
+		err := Remap(map[string][]string{
+			"common.destroy":     {"none"},
+			"test.targeted-plan": {""},
+		})
+		require.NoError(t, err)
+
+		assert.False(t, Common.Destroy.Enabled())
+		assert.False(t, km.TargetedPlan.Enabled())
+	})
+
+	t.Run("unknown action", func(t *testing.T) {
+		setup(t)
+
+		err := Remap(map[string][]string{"global.nonexistent": {"x"}})
+		assert.EqualError(t, err, "remapping keys: unknown action: global.nonexistent")
+	})
+
+	t.Run("conflict", func(t *testing.T) {
+		setup(t)
+
+		err := Remap(map[string][]string{"global.autoscroll": {"="}})
+		assert.EqualError(t, err, `remapping keys: key "=" in test pane is mapped to both global.autoscroll and test.compare`)
+	})
+
+	t.Run("conflict resolved by disabling", func(t *testing.T) {
+		setup(t)
+
+		err := Remap(map[string][]string{
+			"global.autoscroll": {"="},
+			"test.compare":      {"none"},
+		})
+		assert.NoError(t, err)
+	})
+}
@@ -14,4 +14,15 @@ const (
 	LogListKind
 	LogKind
 	ExplorerKind
+	SnapshotListKind
+	SnapshotKind
+	StateHistoryKind
+	StateDiffKind
+	CompareKind
+	SearchKind
+	ModuleGraphKind
+	TaskGroupPreviewKind
+	TaskGroupTimelineKind
+	TaskTopKind
+	TaskTailKind
 )
@@ -17,11 +17,22 @@ func _() {
 	_ = x[LogListKind-6]
 	_ = x[LogKind-7]
 	_ = x[ExplorerKind-8]
+	_ = x[SnapshotListKind-9]
+	_ = x[SnapshotKind-10]
+	_ = x[StateHistoryKind-11]
+	_ = x[StateDiffKind-12]
+	_ = x[CompareKind-13]
+	_ = x[SearchKind-14]
+	_ = x[ModuleGraphKind-15]
+	_ = x[TaskGroupPreviewKind-16]
+	_ = x[TaskGroupTimelineKind-17]
+	_ = x[TaskTopKind-18]
+	_ = x[TaskTailKind-19]
 }
 
-const _Kind_name = "TaskListKindTaskKindTaskGroupListKindTaskGroupKindResourceListKindResourceKindLogListKindLogKindExplorerKind"
+const _Kind_name = "TaskListKindTaskKindTaskGroupListKindTaskGroupKindResourceListKindResourceKindLogListKindLogKindExplorerKindSnapshotListKindSnapshotKindStateHistoryKindStateDiffKindCompareKindSearchKindModuleGraphKindTaskGroupPreviewKindTaskGroupTimelineKindTaskTopKindTaskTailKind"
 
-var _Kind_index = [...]uint8{0, 12, 20, 37, 50, 66, 78, 89, 96, 108}
+var _Kind_index = [...]uint16{0, 12, 20, 37, 50, 66, 78, 89, 96, 108, 124, 136, 152, 165, 176, 186, 201, 221, 242, 253, 265}
 
 func (i Kind) String() string {
 	if i < 0 || i >= Kind(len(_Kind_index)-1) {
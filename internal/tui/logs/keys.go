@@ -1,6 +1,9 @@
 package logs
 
-import "github.com/charmbracelet/bubbles/key"
+import (
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/leg100/pug/internal/tui/keys"
+)
 
 type keyMap struct {
 	Enter key.Binding
@@ -12,3 +15,7 @@ var localKeys = keyMap{
 		key.WithHelp("enter", "view message"),
 	),
 }
+
+func init() {
+	keys.Register("logs", &localKeys, "logs")
+}
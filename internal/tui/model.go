@@ -31,3 +31,9 @@ type Page struct {
 type ModelHelpBindings interface {
 	HelpBindings() []key.Binding
 }
+
+// Closer is implemented by models that release resources once they are no
+// longer shown in any pane. A closed model is discarded and is not shown again.
+type Closer interface {
+	Close()
+}
@@ -0,0 +1,232 @@
+package tui
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// paletteKeys are the keys handled by the command palette.
+var paletteKeys = struct {
+	Run   key.Binding
+	Close key.Binding
+	Up    key.Binding
+	Down  key.Binding
+}{
+	Run:   key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "run action")),
+	Close: key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "close palette")),
+	Up:    key.NewBinding(key.WithKeys("up", "ctrl+p"), key.WithHelp("↑", "up")),
+	Down:  key.NewBinding(key.WithKeys("down", "ctrl+n"), key.WithHelp("↓", "down")),
+}
+
+// Palette is a widget that fuzzy searches actions, i.e. key bindings, by
+// their description and key. Running an action sends its key, as if the user
+// had pressed the key.
+type Palette struct {
+	model    textinput.Model
+	bindings []key.Binding
+	matches  []paletteMatch
+	cursor   int
+}
+
+// paletteMatch is an action matching the user's query.
+type paletteMatch struct {
+	binding key.Binding
+	score   int
+	// positions of characters in the description matching the query.
+	positions []int
+}
+
+// NewPalette constructs a palette for searching the bindings, skipping those
+// that are disabled.
+func NewPalette(bindings []key.Binding) (*Palette, tea.Cmd) {
+	model := textinput.New()
+	model.Prompt = ": "
+	model.Placeholder = "search actions"
+	model.PlaceholderStyle = lipgloss.NewStyle().Faint(true)
+	blink := model.Focus()
+
+	p := Palette{model: model}
+	for _, b := range bindings {
+		if b.Enabled() && len(b.Keys()) > 0 {
+			p.bindings = append(p.bindings, b)
+		}
+	}
+	p.filter()
+	return &p, blink
+}
+
+// HandleKey handles the user key press, and returns a command to be run, and
+// whether the palette should be closed.
+func (p *Palette) HandleKey(msg tea.KeyMsg) (closePalette bool, cmd tea.Cmd) {
+	switch {
+	case key.Matches(msg, paletteKeys.Run):
+		if len(p.matches) == 0 {
+			return false, nil
+		}
+		binding := p.matches[p.cursor].binding
+		return true, CmdHandler(KeyMsg(binding.Keys()[0]))
+	case key.Matches(msg, paletteKeys.Close):
+		return true, nil
+	case key.Matches(msg, paletteKeys.Up):
+		p.cursor = max(0, p.cursor-1)
+	case key.Matches(msg, paletteKeys.Down):
+		p.cursor = max(0, min(len(p.matches)-1, p.cursor+1))
+	default:
+		p.model, cmd = p.model.Update(msg)
+		p.filter()
+	}
+	return false, cmd
+}
+
+// HandleBlink handles the bubbletea blink message.
+func (p *Palette) HandleBlink(msg tea.Msg) (cmd tea.Cmd) {
+	p.model, cmd = p.model.Update(msg)
+	return
+}
+
+// filter matches the bindings against the user's query, ordering them by
+// how well they match.
+func (p *Palette) filter() {
+	query := p.model.Value()
+	p.matches = p.matches[:0]
+	for _, b := range p.bindings {
+		score, positions, ok := fuzzyMatch(query, b.Help().Desc)
+		// Also match against the binding's key, e.g. so that a user
+		// can find out what the 'p' key does.
+		if keyScore, _, keyOK := fuzzyMatch(query, b.Help().Key); keyOK && (!ok || keyScore > score) {
+			score, positions, ok = keyScore, nil, true
+		}
+		if ok {
+			p.matches = append(p.matches, paletteMatch{binding: b, score: score, positions: positions})
+		}
+	}
+	// Stable sort to retain the original order of equally matching bindings.
+	slices.SortStableFunc(p.matches, func(a, b paletteMatch) int {
+		return b.score - a.score
+	})
+	p.cursor = 0
+}
+
+func (p *Palette) View(width int) string {
+	border := ThickBorder.BorderForeground(Blue).Padding(0, 1)
+	contentWidth := max(0, width-border.GetHorizontalFrameSize())
+	p.model.Width = max(0, contentWidth-lipgloss.Width(p.model.Prompt)-1)
+
+	rows := []string{
+		Regular.Inline(true).MaxWidth(contentWidth).Render(p.model.View()),
+	}
+	// Scroll results to keep the cursor in view.
+	offset := max(0, p.cursor-PaletteResults+1)
+	for i := offset; i < min(len(p.matches), offset+PaletteResults); i++ {
+		rows = append(rows, p.renderMatch(p.matches[i], i == p.cursor, contentWidth))
+	}
+	if len(p.matches) == 0 {
+		rows = append(rows, Regular.Faint(true).Render("no matching actions"))
+	}
+	content := lipgloss.NewStyle().
+		Height(PaletteHeight - border.GetVerticalFrameSize()).
+		Render(strings.Join(rows, "\n"))
+	return border.Width(width - border.GetHorizontalBorderSize()).Render(content)
+}
+
+func (p *Palette) renderMatch(m paletteMatch, current bool, width int) string {
+	var (
+		help      = m.binding.Help()
+		descStyle = Regular.Foreground(HelpDesc)
+		keyStyle  = Bold.Foreground(HelpKey)
+		markStyle = Bold.Foreground(HotPink)
+	)
+	if current {
+		row := RowStyle(true, false)
+		descStyle = row
+		keyStyle = row.Bold(true)
+		markStyle = row.Bold(true).Foreground(HotPink)
+	}
+	var desc strings.Builder
+	for i, r := range []rune(help.Desc) {
+		if slices.Contains(m.positions, i) {
+			desc.WriteString(markStyle.Render(string(r)))
+		} else {
+			desc.WriteString(descStyle.Render(string(r)))
+		}
+	}
+	keyWidth := lipgloss.Width(help.Key)
+	gap := max(1, width-lipgloss.Width(help.Desc)-keyWidth)
+	row := desc.String() + descStyle.Render(strings.Repeat(" ", gap)) + keyStyle.Render(help.Key)
+	return Regular.Inline(true).MaxWidth(width).Render(row)
+}
+
+func (p *Palette) HelpBindings() []key.Binding {
+	return []key.Binding{
+		paletteKeys.Run,
+		paletteKeys.Close,
+		paletteKeys.Up,
+		paletteKeys.Down,
+	}
+}
+
+// fuzzyMatch matches the query against the target, case-insensitively,
+// requiring each character of the query to appear in the target in the same
+// order. The score rewards characters matching consecutively and at the start
+// of words. The positions of the matching characters are also returned.
+func fuzzyMatch(query, target string) (score int, positions []int, ok bool) {
+	if query == "" {
+		return 0, nil, true
+	}
+	var (
+		q    = []rune(strings.ToLower(query))
+		t    = []rune(strings.ToLower(target))
+		last = -1
+	)
+	for i, qi := 0, 0; i < len(t) && qi < len(q); i++ {
+		if t[i] != q[qi] {
+			continue
+		}
+		score++
+		if last >= 0 && i == last+1 {
+			score += 4
+		}
+		if i == 0 || !unicode.IsLetter(t[i-1]) && !unicode.IsDigit(t[i-1]) {
+			score += 3
+		}
+		positions = append(positions, i)
+		last = i
+		qi++
+	}
+	if len(positions) < len(q) {
+		return 0, nil, false
+	}
+	// Prefer shorter targets, i.e. those with fewer unmatched characters.
+	score -= (len(t) - len(q)) / 4
+	return score, positions, true
+}
+
+// keyTypes maps the names of keys, e.g. enter, to their types.
+var keyTypes = func() map[string]tea.KeyType {
+	m := make(map[string]tea.KeyType)
+	for k := tea.KeyType(-256); k < 256; k++ {
+		if name := k.String(); name != "" {
+			m[name] = k
+		}
+	}
+	return m
+}()
+
+// KeyMsg constructs the message sent when the key with the given name, e.g.
+// ctrl+c or enter, is pressed.
+func KeyMsg(name string) tea.KeyMsg {
+	var alt bool
+	if rest, ok := strings.CutPrefix(name, "alt+"); ok && rest != "" {
+		alt, name = true, rest
+	}
+	if typ, ok := keyTypes[name]; ok && typ != tea.KeyRunes {
+		return tea.KeyMsg{Type: typ, Alt: alt}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(name), Alt: alt}
+}
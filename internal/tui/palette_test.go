@@ -0,0 +1,98 @@
+package tui
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/leg100/pug/internal/tui/keys"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyMsg(t *testing.T) {
+	var bindings []key.Binding
+	bindings = append(bindings, keys.KeyMapToSlice(keys.Global)...)
+	bindings = append(bindings, keys.KeyMapToSlice(keys.Common)...)
+	bindings = append(bindings, keys.KeyMapToSlice(keys.Navigation)...)
+	bindings = append(bindings, key.NewBinding(key.WithKeys("alt+x", "f5", "shift+tab")))
+
+	for _, b := range bindings {
+		for _, k := range b.Keys() {
+			t.Run(k, func(t *testing.T) {
+				msg := KeyMsg(k)
+				assert.Equal(t, k, msg.String())
+				assert.True(t, key.Matches(msg, b))
+			})
+		}
+	}
+}
+
+func TestFuzzyMatch(t *testing.T) {
+	tests := []struct {
+		query, target string
+		wantPositions []int
+		wantOK        bool
+	}{
+		{"", "plan", nil, true},
+		{"pln", "plan", []int{0, 1, 3}, true},
+		{"PLAN", "auto plan", []int{5, 6, 7, 8}, true},
+		{"npl", "plan", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query+"/"+tt.target, func(t *testing.T) {
+			_, positions, ok := fuzzyMatch(tt.query, tt.target)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.wantPositions, positions)
+		})
+	}
+}
+
+func TestPalette(t *testing.T) {
+	var (
+		plan      = key.NewBinding(key.WithKeys("p"), key.WithHelp("p", "plan"))
+		apply     = key.NewBinding(key.WithKeys("a"), key.WithHelp("a", "apply"))
+		autoApply = key.NewBinding(key.WithKeys("ctrl+a"), key.WithHelp("ctrl+a", "auto apply"))
+		disabled  = key.NewBinding(key.WithKeys("d"), key.WithHelp("d", "destroy"), key.WithDisabled())
+	)
+	palette, _ := NewPalette([]key.Binding{plan, autoApply, apply, disabled})
+
+	typeQuery := func(query string) {
+		for _, r := range query {
+			closed, _ := palette.HandleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+			require.False(t, closed)
+		}
+	}
+	descs := func() (got []string) {
+		for _, m := range palette.matches {
+			got = append(got, m.binding.Help().Desc)
+		}
+		return got
+	}
+
+	t.Run("list enabled actions", func(t *testing.T) {
+		assert.Equal(t, []string{"plan", "auto apply", "apply"}, descs())
+	})
+
+	t.Run("render", func(t *testing.T) {
+		view := palette.View(80)
+		assert.Equal(t, PaletteHeight, lipgloss.Height(view))
+		assert.Equal(t, 80, lipgloss.Width(view))
+	})
+
+	t.Run("order by closest match", func(t *testing.T) {
+		typeQuery("apply")
+		assert.Equal(t, []string{"apply", "auto apply"}, descs())
+	})
+
+	t.Run("run selected action", func(t *testing.T) {
+		closed, _ := palette.HandleKey(tea.KeyMsg{Type: tea.KeyDown})
+		require.False(t, closed)
+
+		closed, cmd := palette.HandleKey(tea.KeyMsg{Type: tea.KeyEnter})
+		assert.True(t, closed)
+		require.NotNil(t, cmd)
+		assert.Equal(t, tea.KeyMsg{Type: tea.KeyCtrlA}, cmd())
+	})
+}
@@ -4,6 +4,8 @@ import (
 	"errors"
 	"fmt"
 	"slices"
+	"sync"
+	"time"
 
 	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
@@ -45,8 +47,39 @@ type PaneManager struct {
 	topRightHeight int
 	// history tracks previously visited models for the top right pane.
 	history []pane
+	// open is the set of pages currently visible in panes. Unlike panes, it
+	// is safe for concurrent access.
+	open   map[Page]struct{}
+	openMu sync.Mutex
+	// dragging is the split currently being dragged with the mouse, if any.
+	dragging split
+	// lastClick is the last mouse click, for detecting double-clicks.
+	lastClick click
 }
 
+// split is a border between panes that can be dragged to resize them.
+type split int
+
+const (
+	noSplit split = iota
+	// verticalSplit separates the left pane from the right panes.
+	verticalSplit
+	// horizontalSplit separates the top right pane from the bottom right
+	// pane.
+	horizontalSplit
+)
+
+// click is a mouse click within a pane.
+type click struct {
+	at       time.Time
+	position Position
+	x, y     int
+}
+
+// doubleClickInterval is the maximum interval between two clicks for them to
+// be considered a double-click.
+const doubleClickInterval = 500 * time.Millisecond
+
 type pane struct {
 	model ChildModel
 	page  Page
@@ -70,13 +103,18 @@ func NewPaneManager(makers map[Kind]Maker) *PaneManager {
 }
 
 func (p *PaneManager) Init() tea.Cmd {
-	return p.setPane(NavigationMsg{
+	cmd := p.setPane(NavigationMsg{
 		Position: LeftPane,
 		Page:     Page{Kind: ExplorerKind},
 	})
+	p.updateOpen()
+	return cmd
 }
 
 func (p *PaneManager) Update(msg tea.Msg) tea.Cmd {
+	shown := maps.Values(p.panes)
+	defer p.closeHidden(shown)
+
 	var cmds []tea.Cmd
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
@@ -125,6 +163,8 @@ func (p *PaneManager) Update(msg tea.Msg) tea.Cmd {
 			// Send remaining keys to focused pane
 			cmds = append(cmds, p.updateModel(p.focused, msg))
 		}
+	case tea.MouseMsg:
+		cmds = append(cmds, p.handleMouse(msg))
 	case tea.WindowSizeMsg:
 		p.width = msg.Width
 		p.height = msg.Height
@@ -154,9 +194,55 @@ func (p *PaneManager) Update(msg tea.Msg) tea.Cmd {
 			}
 		}
 	}
+	p.updateOpen()
 	return tea.Batch(cmds...)
 }
 
+// IsOpen determines whether a page is currently visible in a pane. It is safe
+// to call from outside of the bubbletea event loop.
+func (p *PaneManager) IsOpen(page Page) bool {
+	p.openMu.Lock()
+	defer p.openMu.Unlock()
+
+	_, ok := p.open[page]
+	return ok
+}
+
+// updateOpen updates the set of pages currently visible in panes.
+func (p *PaneManager) updateOpen() {
+	open := make(map[Page]struct{}, len(p.panes))
+	for _, pane := range p.panes {
+		open[pane.page] = struct{}{}
+	}
+
+	p.openMu.Lock()
+	defer p.openMu.Unlock()
+
+	p.open = open
+}
+
+// closeHidden closes models that were shown in panes but no longer are, if
+// they implement Closer. Closed models are removed from the cache and from
+// history, so that they are not shown again.
+func (p *PaneManager) closeHidden(shown []pane) {
+	for _, prev := range shown {
+		closer, ok := prev.model.(Closer)
+		if !ok {
+			continue
+		}
+		if slices.ContainsFunc(maps.Values(p.panes), func(current pane) bool {
+			return current.page == prev.page
+		}) {
+			continue
+		}
+		closer.Close()
+		p.cache.Delete(prev.page)
+		p.history = slices.DeleteFunc(p.history, func(h pane) bool {
+			return h.page == prev.page
+		})
+	}
+}
+
 // FocusedModel retrieves the model of the focused pane.
 func (p *PaneManager) FocusedModel() ChildModel {
 	return p.panes[p.focused].model
@@ -307,6 +393,111 @@ func (m *PaneManager) paneHeight(position Position) int {
 	return m.height
 }
 
+// handleMouse handles a mouse event, the coordinates of which are relative to
+// the top left corner of the panes. A left click focuses the clicked pane, and
+// dragging the split between panes resizes them. Clicks and wheel events are
+// forwarded to the pane beneath the mouse with coordinates relative to the
+// pane's content, and a double-click sends the enter key to the pane, which
+// typically opens the row or node clicked upon.
+func (m *PaneManager) handleMouse(msg tea.MouseMsg) tea.Cmd {
+	switch msg.Action {
+	case tea.MouseActionRelease:
+		m.dragging = noSplit
+		return nil
+	case tea.MouseActionMotion:
+		switch m.dragging {
+		case verticalSplit:
+			m.leftPaneWidth = clamp(msg.X+1, minPaneWidth, m.width-minPaneWidth)
+			m.updateChildSizes()
+		case horizontalSplit:
+			m.topRightHeight = clamp(msg.Y+1, minPaneHeight, m.height-minPaneHeight)
+			m.updateChildSizes()
+		}
+		return nil
+	}
+	if msg.Button == tea.MouseButtonLeft {
+		if split := m.splitAt(msg.X, msg.Y); split != noSplit {
+			m.dragging = split
+			return nil
+		}
+	}
+	position, ok := m.paneAt(msg.X, msg.Y)
+	if !ok {
+		return nil
+	}
+	x, y := m.paneOrigin(position)
+	// Translate coordinates to be relative to the pane's content, within its
+	// borders.
+	local := msg
+	local.X, local.Y = msg.X-x-1, msg.Y-y-1
+	switch msg.Button {
+	case tea.MouseButtonWheelUp, tea.MouseButtonWheelDown:
+		return m.updateModel(position, local)
+	case tea.MouseButtonLeft:
+		m.focusPane(position)
+		cmd := m.updateModel(position, local)
+		current := click{at: time.Now(), position: position, x: local.X, y: local.Y}
+		previous := m.lastClick
+		m.lastClick = current
+		if previous.position == current.position && previous.y == current.y && current.at.Sub(previous.at) < doubleClickInterval {
+			// Prevent a third click from being considered another
+			// double-click.
+			m.lastClick = click{}
+			return tea.Batch(cmd, m.updateModel(position, tea.KeyMsg{Type: tea.KeyEnter}))
+		}
+		return cmd
+	}
+	return nil
+}
+
+// paneAt returns the position of the pane at the given coordinates.
+func (m *PaneManager) paneAt(x, y int) (Position, bool) {
+	for position := range m.panes {
+		left, top := m.paneOrigin(position)
+		if x >= left && x < left+m.paneWidth(position) && y >= top && y < top+m.paneHeight(position) {
+			return position, true
+		}
+	}
+	return 0, false
+}
+
+// paneOrigin returns the coordinates of the top left corner of the pane at
+// the given position.
+func (m *PaneManager) paneOrigin(position Position) (x, y int) {
+	if position == LeftPane {
+		return 0, 0
+	}
+	if _, ok := m.panes[LeftPane]; ok {
+		x = m.paneWidth(LeftPane)
+	}
+	if position == BottomRightPane {
+		if _, ok := m.panes[TopRightPane]; ok {
+			y = m.paneHeight(TopRightPane)
+		}
+	}
+	return x, y
+}
+
+// splitAt returns the split at the given coordinates, i.e. where the borders
+// of two adjacent panes meet.
+func (m *PaneManager) splitAt(x, y int) split {
+	_, left := m.panes[LeftPane]
+	_, topRight := m.panes[TopRightPane]
+	_, bottomRight := m.panes[BottomRightPane]
+	if left && (topRight || bottomRight) {
+		if border := m.paneWidth(LeftPane); x == border-1 || x == border {
+			return verticalSplit
+		}
+	}
+	if topRight && bottomRight {
+		rightX, _ := m.paneOrigin(TopRightPane)
+		if border := m.paneHeight(TopRightPane); x >= rightX && (y == border-1 || y == border) {
+			return horizontalSplit
+		}
+	}
+	return noSplit
+}
+
 func (m *PaneManager) View() string {
 	return lipgloss.JoinHorizontal(lipgloss.Top,
 		removeEmptyStrings(
@@ -0,0 +1,213 @@
+package tui
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/leg100/pug/internal/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeModel records the mouse and key messages it receives.
+type fakeModel struct {
+	received []tea.Msg
+}
+
+func (m *fakeModel) Init() tea.Cmd { return nil }
+
+func (m *fakeModel) Update(msg tea.Msg) tea.Cmd {
+	switch msg.(type) {
+	case tea.MouseMsg, tea.KeyMsg:
+		m.received = append(m.received, msg)
+	}
+	return nil
+}
+
+func (m *fakeModel) View() string { return "" }
+
+type fakeMaker struct{}
+
+func (fakeMaker) Make(resource.ID, int, int) (ChildModel, error) {
+	return &fakeModel{}, nil
+}
+
+// setupPaneManager sets up a pane manager with all three panes open, with the
+// left pane 40 cells wide, and the top right pane 15 cells high.
+func setupPaneManager(t *testing.T) *PaneManager {
+	pm := NewPaneManager(map[Kind]Maker{
+		ExplorerKind: fakeMaker{},
+		TaskListKind: fakeMaker{},
+		TaskKind:     fakeMaker{},
+	})
+	pm.Update(tea.WindowSizeMsg{Width: 100, Height: 40})
+	pm.Init()
+	pm.Update(NavigationMsg{Page: Page{Kind: TaskListKind}, Position: TopRightPane})
+	pm.Update(NavigationMsg{Page: Page{Kind: TaskKind}, Position: BottomRightPane})
+	pm.focusPane(LeftPane)
+	return pm
+}
+
+func received(t *testing.T, pm *PaneManager, position Position) []tea.Msg {
+	t.Helper()
+
+	model, ok := pm.panes[position].model.(*fakeModel)
+	require.True(t, ok)
+	return model.received
+}
+
+func TestPaneManager_Mouse(t *testing.T) {
+	click := func(x, y int) tea.MouseMsg {
+		return tea.MouseMsg{X: x, Y: y, Button: tea.MouseButtonLeft, Action: tea.MouseActionPress}
+	}
+
+	t.Run("click focuses pane", func(t *testing.T) {
+		pm := setupPaneManager(t)
+
+		pm.Update(click(50, 20))
+
+		assert.Equal(t, BottomRightPane, pm.focused)
+		// Coordinates are relative to the content of the pane, within its
+		// borders.
+		assert.Equal(t, []tea.Msg{click(9, 4)}, received(t, pm, BottomRightPane))
+	})
+
+	t.Run("double-click sends enter", func(t *testing.T) {
+		pm := setupPaneManager(t)
+
+		pm.Update(click(10, 5))
+		pm.Update(click(10, 5))
+
+		assert.Equal(t, []tea.Msg{
+			click(9, 4),
+			click(9, 4),
+			tea.KeyMsg{Type: tea.KeyEnter},
+		}, received(t, pm, LeftPane))
+	})
+
+	t.Run("clicks on different rows are not a double-click", func(t *testing.T) {
+		pm := setupPaneManager(t)
+
+		pm.Update(click(10, 5))
+		pm.Update(click(10, 6))
+
+		assert.Equal(t, []tea.Msg{click(9, 4), click(9, 5)}, received(t, pm, LeftPane))
+	})
+
+	t.Run("wheel scrolls pane without focusing it", func(t *testing.T) {
+		pm := setupPaneManager(t)
+
+		pm.Update(tea.MouseMsg{X: 50, Y: 5, Button: tea.MouseButtonWheelDown, Action: tea.MouseActionPress})
+
+		assert.Equal(t, LeftPane, pm.focused)
+		assert.Equal(t, []tea.Msg{
+			tea.MouseMsg{X: 9, Y: 4, Button: tea.MouseButtonWheelDown, Action: tea.MouseActionPress},
+		}, received(t, pm, TopRightPane))
+	})
+
+	t.Run("drag vertical split", func(t *testing.T) {
+		pm := setupPaneManager(t)
+
+		pm.Update(click(39, 5))
+		pm.Update(tea.MouseMsg{X: 59, Y: 5, Button: tea.MouseButtonLeft, Action: tea.MouseActionMotion})
+		pm.Update(tea.MouseMsg{X: 59, Y: 5, Action: tea.MouseActionRelease})
+
+		assert.Equal(t, 60, pm.leftPaneWidth)
+		assert.Equal(t, noSplit, pm.dragging)
+		// Dragging a split does not click on either pane.
+		assert.Empty(t, received(t, pm, LeftPane))
+		assert.Empty(t, received(t, pm, TopRightPane))
+	})
+
+	t.Run("drag horizontal split", func(t *testing.T) {
+		pm := setupPaneManager(t)
+
+		pm.Update(click(60, 15))
+		pm.Update(tea.MouseMsg{X: 60, Y: 24, Button: tea.MouseButtonLeft, Action: tea.MouseActionMotion})
+
+		assert.Equal(t, 25, pm.topRightHeight)
+	})
+
+	t.Run("drag split beyond minimum pane size", func(t *testing.T) {
+		pm := setupPaneManager(t)
+
+		pm.Update(click(40, 5))
+		pm.Update(tea.MouseMsg{X: 2, Y: 5, Button: tea.MouseButtonLeft, Action: tea.MouseActionMotion})
+
+		assert.Equal(t, minPaneWidth, pm.leftPaneWidth)
+	})
+}
+
+// fakeCloser records whether it has been closed.
+type fakeCloser struct {
+	fakeModel
+	closed bool
+}
+
+func (m *fakeCloser) Close() { m.closed = true }
+
+type fakeCloserMaker struct {
+	made *fakeCloser
+}
+
+func (mm *fakeCloserMaker) Make(resource.ID, int, int) (ChildModel, error) {
+	mm.made = &fakeCloser{}
+	return mm.made, nil
+}
+
+func TestPaneManager_Close(t *testing.T) {
+	setup := func(t *testing.T) (*PaneManager, *fakeCloserMaker) {
+		maker := &fakeCloserMaker{}
+		pm := NewPaneManager(map[Kind]Maker{
+			ExplorerKind:         fakeMaker{},
+			TaskListKind:         fakeMaker{},
+			TaskGroupPreviewKind: maker,
+		})
+		pm.Update(tea.WindowSizeMsg{Width: 100, Height: 40})
+		pm.Init()
+		pm.Update(NavigationMsg{Page: Page{Kind: TaskListKind}, Position: TopRightPane})
+		pm.Update(NavigationMsg{Page: Page{Kind: TaskGroupPreviewKind}, Position: TopRightPane})
+		require.NotNil(t, maker.made)
+		return pm, maker
+	}
+	preview := Page{Kind: TaskGroupPreviewKind}
+
+	t.Run("close pane", func(t *testing.T) {
+		pm, maker := setup(t)
+
+		pm.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("X")})
+
+		assert.True(t, maker.made.closed)
+		assert.Nil(t, pm.cache.Get(preview))
+		assert.False(t, pm.IsOpen(preview))
+	})
+
+	t.Run("go back", func(t *testing.T) {
+		pm, maker := setup(t)
+
+		pm.Update(tea.KeyMsg{Type: tea.KeyEsc})
+
+		assert.True(t, maker.made.closed)
+		assert.Nil(t, pm.cache.Get(preview))
+	})
+
+	t.Run("replace pane", func(t *testing.T) {
+		pm, maker := setup(t)
+
+		pm.Update(NavigationMsg{Page: Page{Kind: TaskListKind}, Position: TopRightPane})
+
+		assert.True(t, maker.made.closed)
+		// Going back skips the closed model.
+		pm.Update(tea.KeyMsg{Type: tea.KeyEsc})
+		assert.Equal(t, Page{Kind: TaskListKind}, pm.panes[TopRightPane].page)
+	})
+
+	t.Run("remain open", func(t *testing.T) {
+		pm, maker := setup(t)
+
+		pm.Update(NavigationMsg{Page: Page{Kind: ExplorerKind}, Position: LeftPane})
+
+		assert.False(t, maker.made.closed)
+		assert.NotNil(t, pm.cache.Get(preview))
+	})
+}
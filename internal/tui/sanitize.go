@@ -9,33 +9,16 @@ import (
 // strings are wrapped in a pane where there is more than one vertical pane.
 func SanitizeColors(b []byte) []byte {
 	var (
-		ansi         bool
-		buf          = new(bytes.Buffer)
-		lastcolorseq = new(bytes.Buffer)
+		buf     = new(bytes.Buffer)
+		tracker colorTracker
 	)
 	for _, c := range b {
-		if c == '\x1B' {
-			ansi = true
-			lastcolorseq.Reset()
-			_ = lastcolorseq.WriteByte(c)
-		} else if ansi {
-			_ = lastcolorseq.WriteByte(c)
-			if isTerminator(c) {
-				ansi = false
-				if bytes.HasSuffix(lastcolorseq.Bytes(), []byte("[0m")) {
-					// reset sequence
-					lastcolorseq.Reset()
-				} else if c != 'm' {
-					// not a color code sequence
-					lastcolorseq.Reset()
-				}
-			}
-		} else if c == '\n' && lastcolorseq.Len() > 0 {
+		if !tracker.track(c) && c == '\n' && tracker.lastcolorseq.Len() > 0 {
 			// reset color sequence before adding new line
 			buf.Write([]byte{'\x1B', '[', '0', 'm'})
 			buf.WriteByte(c)
 			// re-start color sequence on new line
-			buf.Write(lastcolorseq.Bytes())
+			buf.Write(tracker.lastcolorseq.Bytes())
 			continue
 		}
 
@@ -44,6 +27,50 @@ func SanitizeColors(b []byte) []byte {
 	return buf.Bytes()
 }
 
+// lastColor returns the color sequence in effect at the end of b, given the
+// color sequence seq in effect at its start. It is empty if no color is in
+// effect.
+func lastColor(b, seq []byte) []byte {
+	var tracker colorTracker
+	tracker.lastcolorseq.Write(seq)
+	for _, c := range b {
+		tracker.track(c)
+	}
+	return bytes.Clone(tracker.lastcolorseq.Bytes())
+}
+
+// colorTracker tracks the last ANSI color sequence in a stream of bytes.
+type colorTracker struct {
+	ansi         bool
+	lastcolorseq bytes.Buffer
+}
+
+// track updates the tracker with the next byte, reporting whether it belongs
+// to an ANSI escape sequence.
+func (t *colorTracker) track(c byte) bool {
+	if c == '\x1B' {
+		t.ansi = true
+		t.lastcolorseq.Reset()
+		_ = t.lastcolorseq.WriteByte(c)
+		return true
+	}
+	if !t.ansi {
+		return false
+	}
+	_ = t.lastcolorseq.WriteByte(c)
+	if isTerminator(c) {
+		t.ansi = false
+		if bytes.HasSuffix(t.lastcolorseq.Bytes(), []byte("[0m")) {
+			// reset sequence
+			t.lastcolorseq.Reset()
+		} else if c != 'm' {
+			// not a color code sequence
+			t.lastcolorseq.Reset()
+		}
+	}
+	return true
+}
+
 func isTerminator(c byte) bool {
 	return (c >= 0x40 && c <= 0x5a) || (c >= 0x61 && c <= 0x7a)
 }
@@ -8,11 +8,9 @@ var (
 	Padded  = Regular.Padding(0, 1)
 
 	Border      = Regular.Border(lipgloss.NormalBorder())
-	ThickBorder = Regular.Border(lipgloss.ThickBorder()).BorderForeground(Violet)
+	ThickBorder = Regular.Border(lipgloss.ThickBorder())
 
-	ModuleStyle = Regular.Foreground(lipgloss.AdaptiveColor{
-		Dark:  string(LightishBlue),
-		Light: "27",
-	})
-	WorkspaceStyle = Regular.Foreground(Purple)
+	// ModuleStyle and WorkspaceStyle are set along with the theme.
+	ModuleStyle    = Regular.Foreground(ModuleColor)
+	WorkspaceStyle = Regular.Foreground(WorkspaceColor)
 )
@@ -201,6 +201,25 @@ func (m Model[V]) Update(msg tea.Msg) (Model[V], tea.Cmd) {
 		case key.Matches(msg, keys.Global.SelectRange):
 			m.SelectRange()
 		}
+	case tea.MouseMsg:
+		if msg.Action != tea.MouseActionPress {
+			break
+		}
+		switch msg.Button {
+		case tea.MouseButtonLeft:
+			// Make the clicked row the current row.
+			row := msg.Y - headerHeight
+			if m.filterVisible() {
+				row -= filterHeight
+			}
+			if row >= 0 && row < m.visibleRows() {
+				m.moveCurrentRow(m.start + row - m.currentRowIndex)
+			}
+		case tea.MouseButtonWheelUp:
+			m.MoveUp(1)
+		case tea.MouseButtonWheelDown:
+			m.MoveDown(1)
+		}
 	case BulkInsertMsg[V]:
 		m.AddItems(msg...)
 	case resource.Event[V]:
@@ -446,9 +465,9 @@ func (m *Model[V]) AddItems(items ...V) {
 }
 
 func (m *Model[V]) removeItem(item V) {
-	delete(m.rendered, item)
-	delete(m.items, item)
-	delete(m.selected, item)
+	delete(m.rendered, item.GetID())
+	delete(m.items, item.GetID())
+	delete(m.selected, item.GetID())
 	for i, row := range m.rows {
 		if row.GetID() == item.GetID() {
 			// TODO: this might well produce a memory leak. See note:
@@ -468,6 +487,12 @@ func (m *Model[V]) removeItem(item V) {
 	}
 }
 
+// SetSortFunc sets the func used to sort rows, re-sorting existing rows.
+func (m *Model[V]) SetSortFunc(sortFunc func(V, V) int) {
+	m.sortFunc = sortFunc
+	m.setRows(maps.Values(m.items)...)
+}
+
 func (m *Model[V]) setRows(items ...V) {
 	selected := make(map[resource.ID]V)
 	m.rows = make([]V, 0, len(items))
@@ -582,26 +607,8 @@ func (m Model[V]) headersView() string {
 func (m *Model[V]) renderRow(rowIdx int) string {
 	row := m.rows[rowIdx]
 
-	var (
-		background lipgloss.Color
-		foreground lipgloss.Color
-		current    bool
-		selected   bool
-	)
-	if _, ok := m.selected[row.GetID()]; ok {
-		selected = true
-	}
-	current = rowIdx == m.currentRowIndex
-	if current && selected {
-		background = tui.CurrentAndSelectedBackground
-		foreground = tui.CurrentAndSelectedForeground
-	} else if current {
-		background = tui.CurrentBackground
-		foreground = tui.CurrentForeground
-	} else if selected {
-		background = tui.SelectedBackground
-		foreground = tui.SelectedForeground
-	}
+	_, selected := m.selected[row.GetID()]
+	current := rowIdx == m.currentRowIndex
 
 	cells := m.rendered[row.GetID()]
 	styledCells := make([]string, len(m.cols))
@@ -637,10 +644,7 @@ func (m *Model[V]) renderRow(rowIdx int) string {
 	// If current row or selected rows, strip colors and apply background color
 	if current || selected {
 		renderedRow = internal.StripAnsi(renderedRow)
-		renderedRow = lipgloss.NewStyle().
-			Foreground(foreground).
-			Background(background).
-			Render(renderedRow)
+		renderedRow = tui.RowStyle(current, selected).Render(renderedRow)
 	}
 	return renderedRow
 }
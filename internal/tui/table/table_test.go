@@ -4,6 +4,7 @@ import (
 	"slices"
 	"testing"
 
+	tea "github.com/charmbracelet/bubbletea"
 	"github.com/leg100/pug/internal/resource"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -159,3 +160,46 @@ func sortStrings(i, j resource.ID) int {
 	}
 	return 1
 }
+
+func TestTable_DeleteItem(t *testing.T) {
+	tbl := setupTest()
+	tbl.ToggleSelection()
+
+	// Delete the current and selected row.
+	tbl, _ = tbl.Update(resource.Event[*testResource]{
+		Type:    resource.DeletedEvent,
+		Payload: &resource0,
+	})
+
+	assert.NotContains(t, tbl.items, resource0.ID)
+	assert.NotContains(t, tbl.rendered, resource0.ID)
+	assert.NotContains(t, tbl.selected, resource0.ID)
+	assert.NotContains(t, tbl.rows, &resource0)
+	assert.Len(t, tbl.rows, 5)
+}
+
+func TestTable_Mouse(t *testing.T) {
+	tbl := setupTest()
+	tbl, _ = tbl.Update(tea.WindowSizeMsg{Width: 100, Height: 4})
+
+	// Click on the third row beneath the header.
+	tbl, _ = tbl.Update(tea.MouseMsg{Y: 3, Button: tea.MouseButtonLeft, Action: tea.MouseActionPress})
+	got, ok := tbl.CurrentRow()
+	require.True(t, ok)
+	assert.Equal(t, &resource2, got)
+
+	// Clicking on the header does nothing.
+	tbl, _ = tbl.Update(tea.MouseMsg{Y: 0, Button: tea.MouseButtonLeft, Action: tea.MouseActionPress})
+	got, _ = tbl.CurrentRow()
+	assert.Equal(t, &resource2, got)
+
+	// Scroll down, beyond the visible rows.
+	tbl, _ = tbl.Update(tea.MouseMsg{Button: tea.MouseButtonWheelDown, Action: tea.MouseActionPress})
+	got, _ = tbl.CurrentRow()
+	assert.Equal(t, &resource3, got)
+	assert.Equal(t, 1, tbl.start)
+
+	tbl, _ = tbl.Update(tea.MouseMsg{Button: tea.MouseButtonWheelUp, Action: tea.MouseActionPress})
+	got, _ = tbl.CurrentRow()
+	assert.Equal(t, &resource2, got)
+}
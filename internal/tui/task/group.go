@@ -3,6 +3,7 @@ package task
 import (
 	"fmt"
 
+	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/leg100/pug/internal/plan"
 	"github.com/leg100/pug/internal/resource"
@@ -81,6 +82,13 @@ func (m *groupModel) Update(msg tea.Msg) tea.Cmd {
 	var cmds []tea.Cmd
 
 	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, groupKeys.Timeline):
+			return tui.NavigateTo(tui.TaskGroupTimelineKind, tui.WithParent(m.group.ID))
+		case key.Matches(msg, tailKeys.Tail):
+			return tui.NavigateTo(tui.TaskTailKind, tui.WithParent(m.group.ID))
+		}
 	case table.BulkInsertMsg[*task.Task]:
 		if m.skip(([]*task.Task)(msg)...) {
 			return nil
@@ -109,8 +117,12 @@ func (m *groupModel) skip(tasks ...*task.Task) bool {
 	return false
 }
 
+func (m groupModel) HelpBindings() []key.Binding {
+	return append(m.List.HelpBindings(), groupKeys.Timeline)
+}
+
 func (m groupModel) BorderText() map[tui.BorderPosition]string {
-	return map[tui.BorderPosition]string{
+	text := map[tui.BorderPosition]string{
 		tui.TopLeftBorder: fmt.Sprintf(
 			"%s %s",
 			tui.Bold.Render(m.group.String()),
@@ -118,4 +130,8 @@ func (m groupModel) BorderText() map[tui.BorderPosition]string {
 		),
 		tui.TopMiddleBorder: m.Metadata(),
 	}
+	if eta := m.GroupETA(m.group); eta != "" {
+		text[tui.BottomMiddleBorder] = fmt.Sprintf("ETA %s", eta)
+	}
+	return text
 }
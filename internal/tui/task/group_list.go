@@ -85,6 +85,10 @@ func (m *groupList) Update(msg tea.Msg) tea.Cmd {
 			if row, ok := m.table.CurrentRow(); ok {
 				return tui.NavigateTo(tui.TaskGroupKind, tui.WithParent(row.ID))
 			}
+		case key.Matches(msg, tailKeys.Tail):
+			if row, ok := m.table.CurrentRow(); ok {
+				return tui.NavigateTo(tui.TaskTailKind, tui.WithParent(row.ID))
+			}
 		}
 	}
 	// Handle keyboard and mouse events in the table widget
@@ -106,5 +110,5 @@ func (m groupList) BorderText() map[tui.BorderPosition]string {
 }
 
 func (m groupList) HelpBindings() (bindings []key.Binding) {
-	return []key.Binding{}
+	return []key.Binding{tailKeys.Tail}
 }
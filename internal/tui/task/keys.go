@@ -1,6 +1,9 @@
 package task
 
-import "github.com/charmbracelet/bubbles/key"
+import (
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/leg100/pug/internal/tui/keys"
+)
 
 type keyMap struct {
 	ToggleInfo key.Binding
@@ -23,6 +26,17 @@ var localKeys = keyMap{
 	),
 }
 
+type groupKeyMap struct {
+	Timeline key.Binding
+}
+
+var groupKeys = groupKeyMap{
+	Timeline: key.NewBinding(
+		key.WithKeys("L"),
+		key.WithHelp("L", "timeline"),
+	),
+}
+
 type groupListKeyMap struct {
 	Enter key.Binding
 }
@@ -33,3 +47,56 @@ var groupListKeys = groupListKeyMap{
 		key.WithHelp("enter", "view group"),
 	),
 }
+
+type previewKeyMap struct {
+	Confirm key.Binding
+	Abort   key.Binding
+}
+
+var previewKeys = previewKeyMap{
+	Confirm: key.NewBinding(
+		key.WithKeys("y"),
+		key.WithHelp("y", "confirm"),
+	),
+	Abort: key.NewBinding(
+		key.WithKeys("n"),
+		key.WithHelp("n", "abort"),
+	),
+}
+
+type topKeyMap struct {
+	Sort key.Binding
+}
+
+var topKeys = topKeyMap{
+	Sort: key.NewBinding(
+		key.WithKeys("O"),
+		key.WithHelp("O", "cycle sort order"),
+	),
+}
+
+type tailKeyMap struct {
+	Tail  key.Binding
+	Pause key.Binding
+}
+
+var tailKeys = tailKeyMap{
+	Tail: key.NewBinding(
+		key.WithKeys("F"),
+		key.WithHelp("F", "tail running tasks"),
+	),
+	Pause: key.NewBinding(
+		key.WithKeys("P"),
+		key.WithHelp("P", "pause/resume"),
+	),
+}
+
+func init() {
+	keys.Register("tasks", &localKeys, "tasks")
+	keys.Register("find", &keys.Find, "tasks")
+	keys.Register("task-group", &groupKeys, "tasks")
+	keys.Register("task-groups", &groupListKeys, "task-groups")
+	keys.Register("preview", &previewKeys, "preview")
+	keys.Register("top", &topKeys, "top")
+	keys.Register("tail", &tailKeys, "tasks", "task-groups", "tail")
+}
@@ -26,6 +26,11 @@ var (
 		Title: "STATUS",
 		Width: task.MaxStatusLen,
 	}
+	etaColumn = table.Column{
+		Key:   "eta",
+		Title: "ETA",
+		Width: 9,
+	}
 	ageColumn = table.Column{
 		Key:   "age",
 		Title: "AGE",
@@ -68,6 +73,9 @@ func (mm *ListMaker) Make(_ resource.ID, width, height int) (tui.ChildModel, err
 		commandColumn,
 		statusColumn,
 		table.SummaryColumn,
+		cpuColumn,
+		memColumn,
+		etaColumn,
 		ageColumn,
 	}
 	renderer := func(t *task.Task) table.RenderedRow {
@@ -75,6 +83,9 @@ func (mm *ListMaker) Make(_ resource.ID, width, height int) (tui.ChildModel, err
 			table.ModuleColumn.Key:    mm.Helpers.TaskModulePath(t),
 			table.WorkspaceColumn.Key: mm.Helpers.TaskWorkspaceName(t),
 			commandColumn.Key:         t.String(),
+			cpuColumn.Key:             renderCPU(t),
+			memColumn.Key:             renderMemory(t),
+			etaColumn.Key:             mm.Helpers.TaskETA(t),
 			ageColumn.Key:             tui.Ago(time.Now(), t.Created),
 			statusColumn.Key:          mm.Helpers.TaskStatus(t, true),
 			table.SummaryColumn.Key:   mm.Helpers.TaskSummary(t, true),
@@ -138,6 +149,8 @@ func (m *List) Update(msg tea.Msg) tea.Cmd {
 				fmt.Sprintf("Apply %d plans?", len(ids)),
 				m.CreateTasks(m.plans.ApplyPlan, ids...),
 			)
+		case key.Matches(msg, tailKeys.Tail):
+			return tui.NavigateTo(tui.TaskTailKind)
 		case key.Matches(msg, keys.Common.Retry):
 			rows := m.SelectedOrCurrent()
 			specs := make([]task.Spec, len(rows))
@@ -171,6 +184,7 @@ func (m List) HelpBindings() []key.Binding {
 		keys.Common.Cancel,
 		keys.Common.State,
 		keys.Common.Retry,
+		tailKeys.Tail,
 	}
 	if _, err := m.allPlans(); err == nil {
 		bindings = append(bindings, localKeys.ApplyPlan)
@@ -59,10 +59,11 @@ func (mm *Maker) make(id resource.ID, width, height int, border bool) (tui.Child
 	m.setHeight(height)
 
 	m.viewport = tui.NewViewport(tui.ViewportOptions{
-		JSON:    m.task.JSON,
-		Width:   m.viewportWidth(),
-		Height:  m.height,
-		Spinner: m.spinner,
+		JSON:       m.task.JSON,
+		Width:      m.viewportWidth(),
+		Height:     m.height,
+		Spinner:    m.spinner,
+		Searchable: true,
 	})
 	m.common = &tui.ActionHandler{
 		Helpers:     mm.Helpers,
@@ -193,8 +194,10 @@ func (m *Model) View() string {
 
 	if m.config.showInfo {
 		var (
-			args = "-"
-			envs = "-"
+			args     = "-"
+			envs     = "-"
+			estimate = "-"
+			usage    = "-"
 		)
 		if len(m.task.Args) > 0 {
 			args = strings.Join(m.task.Args, "\n")
@@ -202,6 +205,20 @@ func (m *Model) View() string {
 		if len(m.task.AdditionalEnv) > 0 {
 			envs = strings.Join(m.task.AdditionalEnv, "\n")
 		}
+		if d, ok := m.History.Estimate(m.task); ok {
+			estimate = tui.FormatDuration(d)
+			if eta := m.TaskETA(m.task); eta != "" {
+				estimate += fmt.Sprintf(" (ETA %s)", eta)
+			}
+		}
+
+		if current, ok := m.task.Usage(); ok {
+			peak, _ := m.task.PeakUsage()
+			usage = fmt.Sprintf("Peak: %s", renderUsage(peak))
+			if m.task.State == task.Running {
+				usage = fmt.Sprintf("Current: %s\n%s", renderUsage(current), usage)
+			}
+		}
 
 		// Show info to the left of the viewport.
 		content := lipgloss.JoinVertical(lipgloss.Top,
@@ -223,6 +240,15 @@ func (m *Model) View() string {
 			fmt.Sprintf("Autoscroll: %s", boolToOnOff(!m.config.disableAutoscroll)),
 			"",
 			fmt.Sprintf("Dependencies: %v", m.task.DependsOn),
+			"",
+			tui.Bold.Render("Usual duration"),
+			estimate,
+			"",
+			tui.Bold.Render("Resource usage"),
+			usage,
+			"",
+			tui.Bold.Render("Output"),
+			renderOutputUsage(m.task.OutputUsage()),
 		)
 
 		// Word wrap task info to ensure it wraps "cleanly".
@@ -245,6 +271,29 @@ func (m *Model) View() string {
 	return lipgloss.JoinHorizontal(lipgloss.Left, components...)
 }
 
+// renderOutputUsage renders the memory and disk space used by task output.
+func renderOutputUsage(usage task.BufferUsage) string {
+	s := fmt.Sprintf("%s in memory", formatBytes(usage.Memory))
+	if usage.Disk > 0 {
+		s += fmt.Sprintf(", %s on disk", formatBytes(usage.Disk))
+	}
+	return s
+}
+
+// formatBytes formats a number of bytes in human-readable form, e.g. 1.5 MiB.
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
 func boolToOnOff(b bool) string {
 	if b {
 		return "on"
@@ -280,6 +329,7 @@ func (m Model) HelpBindings() []key.Binding {
 		keys.Common.Retry,
 		localKeys.ToggleInfo,
 	}
+	bindings = append(bindings, keys.KeyMapToSlice(keys.Find)...)
 	if err := plan.IsApplyable(m.task); err == nil {
 		bindings = append(bindings, localKeys.ApplyPlan)
 	}
@@ -0,0 +1,189 @@
+package task
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/leg100/pug/internal/resource"
+	"github.com/leg100/pug/internal/task"
+	"github.com/leg100/pug/internal/tui"
+)
+
+// PreviewMaker makes task group preview models
+type PreviewMaker struct {
+	Tasks   *task.Service
+	Helpers *tui.Helpers
+}
+
+func (mm *PreviewMaker) Make(id resource.ID, width, height int) (tui.ChildModel, error) {
+	preview, err := mm.Tasks.GetPreview(id)
+	if err != nil {
+		return nil, err
+	}
+	m := &previewModel{
+		Helpers: mm.Helpers,
+		preview: preview,
+		viewport: tui.NewViewport(tui.ViewportOptions{
+			Width:  width,
+			Height: height,
+		}),
+	}
+	if err := m.viewport.SetContent([]byte(m.render())); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// previewModel shows a preview of a task group: the stages in which its tasks
+// would run, and the command line and environment of each task. The user
+// either confirms creation of the task group or aborts.
+type previewModel struct {
+	*tui.Helpers
+
+	preview  *task.Preview
+	viewport tui.Viewport
+	// done is true once the user has either confirmed or aborted.
+	done bool
+}
+
+func (m *previewModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m *previewModel) Update(msg tea.Msg) tea.Cmd {
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, previewKeys.Confirm):
+			if m.done {
+				return tui.ReportError(errors.New("task group preview has already been confirmed or aborted"))
+			}
+			m.done = true
+			return func() tea.Msg {
+				group, err := m.Tasks.ConfirmPreview(m.preview.Group.ID)
+				if err != nil {
+					return tui.ErrorMsg(fmt.Errorf("creating task group: %w", err))
+				}
+				return tui.NewNavigationMsg(tui.TaskGroupKind, tui.WithParent(group.ID))
+			}
+		case key.Matches(msg, previewKeys.Abort):
+			if m.done {
+				return nil
+			}
+			m.done = true
+			m.Tasks.DiscardPreview(m.preview.Group.ID)
+			return tui.ReportInfo("canceled operation")
+		}
+	case tea.WindowSizeMsg:
+		m.viewport.SetDimensions(msg.Width, msg.Height)
+		return nil
+	}
+
+	// Handle keyboard and mouse events in the viewport
+	m.viewport, cmd = m.viewport.Update(msg)
+	return cmd
+}
+
+// Close discards the preview if the user closes its pane without either
+// confirming or aborting.
+func (m *previewModel) Close() {
+	if m.done {
+		return
+	}
+	m.done = true
+	m.Tasks.DiscardPreview(m.preview.Group.ID)
+}
+
+func (m *previewModel) View() string {
+	return m.viewport.View()
+}
+
+// render renders the preview's tasks, stage by stage.
+func (m *previewModel) render() string {
+	var b strings.Builder
+	for stage := range m.preview.Stages {
+		tasks := m.preview.Stage(stage)
+		fmt.Fprintf(&b, "%s %s\n\n",
+			tui.Bold.Render(fmt.Sprintf("Stage %d", stage+1)),
+			tui.Regular.Foreground(tui.LightGrey).Render(fmt.Sprintf("(%d tasks in parallel)", len(tasks))),
+		)
+		for _, t := range tasks {
+			fmt.Fprintf(&b, "  %s %s %s\n",
+				tui.Bold.Render(t.String()),
+				m.TaskModulePathWithIcon(t.Task),
+				m.TaskWorkspaceNameWithIcon(t.Task),
+			)
+			fmt.Fprintf(&b, "    $ %s\n", commandLine(t.Program, t.Args))
+			if t.AdditionalExecution != nil {
+				fmt.Fprintf(&b, "    $ %s\n", commandLine(t.AdditionalExecution.Program, t.AdditionalExecution.Args))
+			}
+			for _, env := range t.AdditionalEnv {
+				fmt.Fprintf(&b, "    %s\n", tui.Regular.Foreground(tui.LightGrey).Render(env))
+			}
+			for _, dep := range t.Dependencies {
+				fmt.Fprintf(&b, "    %s %s %s %s\n",
+					tui.Regular.Foreground(tui.Orange).Render("waits on"),
+					dep.String(),
+					m.TaskModulePathWithIcon(dep),
+					m.TaskWorkspaceNameWithIcon(dep),
+				)
+			}
+			for _, blocker := range t.BlockedBy {
+				fmt.Fprintf(&b, "    %s %s %s %s %s\n",
+					tui.Regular.Foreground(tui.Red).Render("blocked by"),
+					blocker.State,
+					blocker.String(),
+					tui.Regular.Foreground(tui.LightGrey).Render(blocker.ID.String()),
+					m.TaskWorkspaceNameWithIcon(blocker),
+				)
+			}
+			b.WriteString("\n")
+		}
+	}
+	if n := len(m.preview.Group.CreateErrors); n > 0 {
+		fmt.Fprintf(&b, "%s\n", tui.Regular.Foreground(tui.Red).Render(
+			fmt.Sprintf("%d tasks failed to be created: see logs", n),
+		))
+	}
+	return b.String()
+}
+
+// commandLine renders a program and its arguments, quoting arguments that
+// contain whitespace.
+func commandLine(program string, args []string) string {
+	parts := []string{program}
+	for _, arg := range args {
+		if strings.ContainsAny(arg, " \t\n") {
+			arg = fmt.Sprintf("%q", arg)
+		}
+		parts = append(parts, arg)
+	}
+	return strings.Join(parts, " ")
+}
+
+func (m *previewModel) BorderText() map[tui.BorderPosition]string {
+	return map[tui.BorderPosition]string{
+		tui.TopLeftBorder: fmt.Sprintf(
+			"%s %s",
+			tui.Bold.Render("preview"),
+			m.preview.Group.String(),
+		),
+		tui.TopMiddleBorder: fmt.Sprintf(
+			"%d tasks in %d stages",
+			len(m.preview.Tasks),
+			m.preview.Stages,
+		),
+	}
+}
+
+func (m *previewModel) HelpBindings() []key.Binding {
+	return []key.Binding{
+		previewKeys.Confirm,
+		previewKeys.Abort,
+	}
+}
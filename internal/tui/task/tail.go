@@ -0,0 +1,410 @@
+package task
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/google/uuid"
+	"github.com/leg100/pug/internal/resource"
+	"github.com/leg100/pug/internal/task"
+	"github.com/leg100/pug/internal/tui"
+)
+
+const (
+	// maxTailLines is the maximum number of lines the tail retains; once
+	// exceeded, the oldest lines are discarded.
+	maxTailLines = 10000
+	// tailFilterHeight is the height of the filter widget, including the line
+	// beneath it.
+	tailFilterHeight = 2
+)
+
+// tailColors returns the colors with which tasks are labelled, in turn.
+func tailColors() []lipgloss.Color {
+	return []lipgloss.Color{
+		tui.ModuleColor,
+		tui.WorkspaceColor,
+		tui.Green,
+		tui.Orange,
+		tui.Blue,
+		tui.HotPink,
+		tui.GreenBlue,
+		tui.Red,
+	}
+}
+
+// TailMaker makes models that interleave the output of running tasks, either
+// those belonging to a task group, or all running tasks.
+type TailMaker struct {
+	Tasks   *task.Service
+	Spinner *spinner.Model
+	Helpers *tui.Helpers
+	Config  *Config
+}
+
+func (mm *TailMaker) Make(id resource.ID, width, height int) (tui.ChildModel, error) {
+	var group *task.Group
+	if id != nil {
+		var err error
+		group, err = mm.Tasks.GetGroup(id)
+		if err != nil {
+			return nil, err
+		}
+	}
+	filter := textinput.New()
+	filter.Prompt = "Filter: "
+
+	m := &tailModel{
+		Helpers: mm.Helpers,
+		id:      uuid.New(),
+		tasks:   mm.Tasks,
+		group:   group,
+		spinner: mm.Spinner,
+		config:  mm.Config,
+		tailer:  newTailer(),
+		tailed:  make(map[resource.MonotonicID]tailedTask),
+		filter:  filter,
+		width:   width,
+		height:  height,
+	}
+	m.viewport = m.newViewport()
+	return m, nil
+}
+
+// tailModel interleaves the output lines of running tasks, prefixing each line
+// with a colored label identifying its task, akin to `docker compose logs`.
+type tailModel struct {
+	*tui.Helpers
+
+	id    uuid.UUID
+	tasks *task.Service
+	// group is the task group whose tasks are tailed, or nil to tail all
+	// tasks.
+	group   *task.Group
+	spinner *spinner.Model
+	config  *Config
+
+	tailer *tailer
+	// tailed are the tasks being tailed.
+	tailed     map[resource.MonotonicID]tailedTask
+	labelWidth int
+
+	// lines are the lines retained thus far, of which the first shown lines
+	// have been sent to the viewport.
+	lines []tailLine
+	shown int
+	// paused is true if the user has paused the tail. Output continues to be
+	// retained whilst paused.
+	paused bool
+
+	filter   textinput.Model
+	viewport tui.Viewport
+
+	width, height int
+}
+
+type tailedTask struct {
+	label string
+	style lipgloss.Style
+}
+
+// tailMsg informs the tail model with the given ID that lines are available
+// to be taken.
+type tailMsg struct {
+	modelID uuid.UUID
+}
+
+func (m *tailModel) Init() tea.Cmd {
+	for _, t := range m.tasks.List(task.ListOptions{Status: []task.Status{task.Running}}) {
+		m.tail(t)
+	}
+	return m.waitForLines
+}
+
+func (m *tailModel) Update(msg tea.Msg) tea.Cmd {
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if key.Matches(msg, tailKeys.Pause) {
+			m.paused = !m.paused
+			if !m.paused {
+				m.refresh()
+			}
+			return nil
+		}
+	case resource.Event[*task.Task]:
+		// Start tailing tasks as they start running. A task may have finished
+		// by the time its event is received, having never been seen running,
+		// in which case its output is tailed nonetheless.
+		if msg.Type == resource.UpdatedEvent && m.tailable(msg.Payload) {
+			m.tail(msg.Payload)
+		}
+		return nil
+	case tailMsg:
+		if msg.modelID != m.id {
+			return nil
+		}
+		m.lines = append(m.lines, m.tailer.take()...)
+		if len(m.lines) > maxTailLines {
+			// Discard oldest lines, discarding more than strictly necessary
+			// to avoid re-rendering the viewport on every subsequent line.
+			discard := len(m.lines) - maxTailLines*3/4
+			m.lines = m.lines[discard:]
+			m.shown = max(0, m.shown-discard)
+			m.rerender()
+		}
+		if !m.paused {
+			m.refresh()
+		}
+		return m.waitForLines
+	case tui.FilterFocusReqMsg:
+		// Focus the filter widget and start blinking the cursor.
+		blink := m.filter.Focus()
+		m.setViewportDimensions()
+		return blink
+	case tui.FilterBlurMsg:
+		m.filter.Blur()
+		m.setViewportDimensions()
+		return nil
+	case tui.FilterCloseMsg:
+		m.filter.Blur()
+		m.filter.SetValue("")
+		m.rerender()
+		return nil
+	case tui.FilterKeyMsg:
+		// Unwrap key and send to filter widget, and filter lines accordingly.
+		m.filter, cmd = m.filter.Update(tea.KeyMsg(msg))
+		m.rerender()
+		return cmd
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		m.setViewportDimensions()
+		return nil
+	default:
+		// Send any other messages to the filter if it is focused.
+		if m.filter.Focused() {
+			m.filter, cmd = m.filter.Update(msg)
+			return cmd
+		}
+	}
+	// Handle keyboard and mouse events in the viewport
+	m.viewport, cmd = m.viewport.Update(msg)
+	return cmd
+}
+
+// tail starts tailing the task, unless it is already being tailed or it is
+// not in the scope of the model.
+func (m *tailModel) tail(t *task.Task) {
+	if m.group != nil && !m.group.IncludesTask(t.ID) {
+		return
+	}
+	if _, ok := m.tailed[t.ID]; ok {
+		return
+	}
+	colors := tailColors()
+	label := taskLabel(m.Helpers, m.group, t)
+	m.tailed[t.ID] = tailedTask{
+		label: label,
+		style: tui.Regular.Foreground(colors[len(m.tailed)%len(colors)]),
+	}
+	m.labelWidth = max(m.labelWidth, lipgloss.Width(label))
+	m.tailer.tail(t.ID, t.NewStreamer())
+}
+
+// tailable determines whether the task is running or has finished with output
+// to be tailed.
+func (m *tailModel) tailable(t *task.Task) bool {
+	switch state := t.CurrentState(); {
+	case state == task.Running:
+		return true
+	case state.IsFinal():
+		return t.OutputUsage() != task.BufferUsage{}
+	default:
+		return false
+	}
+}
+
+func (m *tailModel) waitForLines() tea.Msg {
+	<-m.tailer.avail
+	return tailMsg{modelID: m.id}
+}
+
+// refresh sends lines yet to be shown to the viewport.
+func (m *tailModel) refresh() {
+	m.appendLines(m.lines[m.shown:])
+	m.shown = len(m.lines)
+}
+
+// rerender replaces the viewport, sending it the lines shown thus far, e.g.
+// after the filter has changed.
+func (m *tailModel) rerender() {
+	m.viewport = m.newViewport()
+	m.appendLines(m.lines[:m.shown])
+}
+
+func (m *tailModel) appendLines(lines []tailLine) {
+	var b bytes.Buffer
+	for _, line := range lines {
+		m.render(&b, line)
+	}
+	if b.Len() == 0 {
+		return
+	}
+	// Not checking for error because it is only ever returned for JSON
+	// content.
+	_ = m.viewport.AppendContent(b.Bytes(), false, !m.config.disableAutoscroll)
+}
+
+// render renders a line, prefixed with the label of its task, if the label
+// matches the filter.
+func (m *tailModel) render(b *bytes.Buffer, line tailLine) {
+	tailed := m.tailed[line.taskID]
+	if filter := m.filter.Value(); filter != "" {
+		if !strings.Contains(strings.ToLower(tailed.label), strings.ToLower(filter)) {
+			return
+		}
+	}
+	label := tailed.label + strings.Repeat(" ", max(0, m.labelWidth-lipgloss.Width(tailed.label)))
+	b.WriteString(tailed.style.Render(label + " |"))
+	b.WriteString(" ")
+	b.WriteString(line.text)
+	b.WriteString("\n")
+}
+
+func (m *tailModel) newViewport() tui.Viewport {
+	return tui.NewViewport(tui.ViewportOptions{
+		Width:   m.width,
+		Height:  m.viewportHeight(),
+		Spinner: m.spinner,
+	})
+}
+
+func (m *tailModel) setViewportDimensions() {
+	m.viewport.SetDimensions(m.width, m.viewportHeight())
+}
+
+func (m *tailModel) viewportHeight() int {
+	if m.filterVisible() {
+		return max(0, m.height-tailFilterHeight)
+	}
+	return m.height
+}
+
+func (m *tailModel) filterVisible() bool {
+	// Filter is visible if it's either in focus, or it has a non-empty value.
+	return m.filter.Focused() || m.filter.Value() != ""
+}
+
+func (m *tailModel) View() string {
+	if m.filterVisible() {
+		return lipgloss.JoinVertical(lipgloss.Left,
+			tui.Regular.Margin(0, 1).Render(m.filter.View()),
+			strings.Repeat("─", m.width),
+			m.viewport.View(),
+		)
+	}
+	return m.viewport.View()
+}
+
+func (m *tailModel) BorderText() map[tui.BorderPosition]string {
+	title := tui.Bold.Render("tail")
+	if m.group != nil {
+		title += " " + m.group.String()
+	}
+	text := map[tui.BorderPosition]string{
+		tui.TopLeftBorder:   title,
+		tui.TopMiddleBorder: fmt.Sprintf("%d tasks", len(m.tailed)),
+	}
+	if m.paused {
+		text[tui.TopRightBorder] = fmt.Sprintf("paused (%d new lines)", len(m.lines)-m.shown)
+	}
+	return text
+}
+
+func (m *tailModel) HelpBindings() []key.Binding {
+	return []key.Binding{tailKeys.Pause}
+}
+
+// tailLine is a line of output from a task.
+type tailLine struct {
+	taskID resource.MonotonicID
+	text   string
+}
+
+// tailer drains the output of tasks in goroutines, splitting it into lines
+// which are then taken by the tail model. Output is drained regardless of
+// whether the lines are taken, so that a slow or paused tail model never holds
+// up a task's other streamers.
+type tailer struct {
+	// avail is sent a value when lines are available to be taken.
+	avail chan struct{}
+
+	lines []tailLine
+	mu    sync.Mutex
+}
+
+func newTailer() *tailer {
+	return &tailer{avail: make(chan struct{}, 1)}
+}
+
+// tail drains the stream of output from a task.
+func (t *tailer) tail(taskID resource.MonotonicID, stream <-chan []byte) {
+	go func() {
+		// partial is a line yet to be terminated.
+		var partial []byte
+		for chunk := range stream {
+			partial = append(partial, chunk...)
+			i := bytes.LastIndexByte(partial, '\n')
+			if i < 0 {
+				continue
+			}
+			t.add(taskID, partial[:i])
+			partial = append([]byte(nil), partial[i+1:]...)
+		}
+		if len(partial) > 0 {
+			t.add(taskID, partial)
+		}
+	}()
+}
+
+func (t *tailer) add(taskID resource.MonotonicID, output []byte) {
+	t.mu.Lock()
+	for _, line := range strings.Split(string(output), "\n") {
+		t.lines = append(t.lines, tailLine{
+			taskID: taskID,
+			text:   strings.TrimSuffix(line, "\r"),
+		})
+	}
+	// Discard the oldest lines should the model not take them, e.g. whilst
+	// the model is no longer listening for lines.
+	if len(t.lines) > maxTailLines {
+		t.lines = slices.Delete(t.lines, 0, len(t.lines)-maxTailLines)
+	}
+	t.mu.Unlock()
+
+	// Let the model know there are lines available to be taken.
+	select {
+	case t.avail <- struct{}{}:
+	default:
+	}
+}
+
+// take takes the lines added since the lines were last taken.
+func (t *tailer) take() []tailLine {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	lines := t.lines
+	t.lines = nil
+	return lines
+}
@@ -0,0 +1,158 @@
+package task
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/google/uuid"
+	"github.com/leg100/pug/internal/resource"
+	"github.com/leg100/pug/internal/tui"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTailer(t *testing.T) {
+	var (
+		tailer = newTailer()
+		id1    = resource.NewMonotonicID(resource.Task)
+		id2    = resource.NewMonotonicID(resource.Task)
+		out1   = make(chan []byte)
+		out2   = make(chan []byte)
+	)
+	tailer.tail(id1, out1)
+	tailer.tail(id2, out2)
+
+	// Lines are split across chunks, and the last line is unterminated.
+	out1 <- []byte("hello\nwor")
+	out1 <- []byte("ld\r\n")
+	out2 <- []byte("goodbye")
+	close(out1)
+	close(out2)
+
+	var got []tailLine
+	require.Eventually(t, func() bool {
+		got = append(got, tailer.take()...)
+		return len(got) == 3
+	}, time.Second, 10*time.Millisecond)
+
+	assert.ElementsMatch(t, []tailLine{
+		{taskID: id1, text: "hello"},
+		{taskID: id1, text: "world"},
+		{taskID: id2, text: "goodbye"},
+	}, got)
+}
+
+func TestTailer_DiscardOldestLines(t *testing.T) {
+	tailer := newTailer()
+	id := resource.NewMonotonicID(resource.Task)
+
+	// Lines are added without being taken.
+	tailer.add(id, []byte(strings.Repeat("old\n", maxTailLines)+"new"))
+
+	got := tailer.take()
+	require.Len(t, got, maxTailLines)
+	assert.Equal(t, "new", got[len(got)-1].text)
+}
+
+func TestTailModel(t *testing.T) {
+	var (
+		apply = resource.NewMonotonicID(resource.Task)
+		plan  = resource.NewMonotonicID(resource.Task)
+	)
+	setup := func(t *testing.T) *tailModel {
+		m := &tailModel{
+			id:     uuid.New(),
+			config: &Config{},
+			tailer: newTailer(),
+			tailed: map[resource.MonotonicID]tailedTask{
+				apply: {label: "modules/a default apply", style: tui.Regular},
+				plan:  {label: "modules/b dev plan", style: tui.Regular},
+			},
+			labelWidth: len("modules/a default apply"),
+			filter:     textinput.New(),
+			width:      100,
+			height:     20,
+		}
+		m.viewport = m.newViewport()
+		return m
+	}
+	// send sends lines to the model as if output by the task.
+	send := func(m *tailModel, id resource.MonotonicID, lines ...string) {
+		m.tailer.add(id, []byte(strings.Join(lines, "\n")))
+		m.Update(tailMsg{modelID: m.id})
+	}
+	view := func(m *tailModel) string {
+		return ansi.Strip(m.View())
+	}
+
+	t.Run("interleave output", func(t *testing.T) {
+		m := setup(t)
+
+		send(m, apply, "Applying...")
+		send(m, plan, "Planning...")
+
+		assert.Contains(t, view(m), "modules/a default apply | Applying...")
+		assert.Contains(t, view(m), "modules/b dev plan      | Planning...")
+	})
+
+	t.Run("ignore other models' messages", func(t *testing.T) {
+		m := setup(t)
+
+		m.tailer.add(apply, []byte("Applying..."))
+		m.Update(tailMsg{modelID: uuid.New()})
+
+		assert.Empty(t, m.lines)
+	})
+
+	t.Run("pause", func(t *testing.T) {
+		m := setup(t)
+		send(m, apply, "before")
+
+		m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("P")})
+		send(m, apply, "during")
+
+		assert.Contains(t, view(m), "before")
+		assert.NotContains(t, view(m), "during")
+		assert.Equal(t, "paused (1 new lines)", m.BorderText()[tui.TopRightBorder])
+
+		// Resume
+		m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("P")})
+
+		assert.Contains(t, view(m), "during")
+		assert.NotContains(t, m.BorderText(), tui.TopRightBorder)
+	})
+
+	t.Run("filter", func(t *testing.T) {
+		m := setup(t)
+		send(m, apply, "Applying...")
+		send(m, plan, "Planning...")
+
+		m.Update(tui.FilterFocusReqMsg{})
+		for _, r := range "DEV" {
+			m.Update(tui.FilterKeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		}
+
+		assert.NotContains(t, view(m), "Applying...")
+		assert.Contains(t, view(m), "Planning...")
+
+		m.Update(tui.FilterCloseMsg{})
+
+		assert.Contains(t, view(m), "Applying...")
+		assert.Contains(t, view(m), "Planning...")
+	})
+
+	t.Run("discard oldest lines", func(t *testing.T) {
+		m := setup(t)
+
+		send(m, apply, slices.Repeat([]string{"line"}, maxTailLines)...)
+		send(m, apply, "line")
+
+		assert.Len(t, m.lines, maxTailLines*3/4)
+		assert.Equal(t, len(m.lines), m.shown)
+	})
+}
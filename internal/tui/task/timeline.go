@@ -0,0 +1,280 @@
+package task
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/leg100/pug/internal/resource"
+	"github.com/leg100/pug/internal/task"
+	"github.com/leg100/pug/internal/tui"
+	"github.com/leg100/pug/internal/tui/keys"
+)
+
+const (
+	// maxTimelineLabelWidth is the maximum width of the column labelling
+	// each task.
+	maxTimelineLabelWidth = 40
+	// timelineDurationWidth is the width of the column reporting how long
+	// each task has been running.
+	timelineDurationWidth = 10
+)
+
+// timelineStatuses are the statuses rendered on the timeline, in the order in
+// which a task passes through them.
+var timelineStatuses = []struct {
+	status task.Status
+	glyph  string
+}{
+	{task.Pending, "░"},
+	{task.Queued, "▒"},
+	{task.Running, "█"},
+}
+
+// TimelineMaker makes task group timeline models
+type TimelineMaker struct {
+	Tasks   *task.Service
+	Helpers *tui.Helpers
+}
+
+func (mm *TimelineMaker) Make(id resource.ID, width, height int) (tui.ChildModel, error) {
+	group, err := mm.Tasks.GetGroup(id)
+	if err != nil {
+		return nil, err
+	}
+	m := &timelineModel{
+		Helpers: mm.Helpers,
+		group:   group,
+		width:   width,
+		height:  height,
+	}
+	return m, nil
+}
+
+// timelineModel renders a timeline of a task group's tasks, showing when each
+// task was pending, queued and running on a shared time axis.
+type timelineModel struct {
+	*tui.Helpers
+
+	group         *task.Group
+	start         int
+	width, height int
+}
+
+func (m *timelineModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m *timelineModel) Update(msg tea.Msg) tea.Cmd {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, keys.Navigation.LineUp):
+			m.scroll(-1)
+		case key.Matches(msg, keys.Navigation.LineDown):
+			m.scroll(1)
+		case key.Matches(msg, keys.Navigation.PageUp):
+			m.scroll(-m.visibleRows())
+		case key.Matches(msg, keys.Navigation.PageDown):
+			m.scroll(m.visibleRows())
+		case key.Matches(msg, keys.Navigation.HalfPageUp):
+			m.scroll(-m.visibleRows() / 2)
+		case key.Matches(msg, keys.Navigation.HalfPageDown):
+			m.scroll(m.visibleRows() / 2)
+		case key.Matches(msg, keys.Navigation.GotoTop):
+			m.scroll(-m.start)
+		case key.Matches(msg, keys.Navigation.GotoBottom):
+			m.scroll(len(m.group.Tasks))
+		}
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		m.scroll(0)
+	}
+	return nil
+}
+
+// visibleRows is the number of task rows that fit beneath the time axis.
+func (m *timelineModel) visibleRows() int {
+	return max(0, m.height-1)
+}
+
+func (m *timelineModel) scroll(n int) {
+	m.start = max(0, min(m.start+n, len(m.group.Tasks)-m.visibleRows()))
+}
+
+func (m *timelineModel) View() string {
+	if len(m.group.Tasks) == 0 {
+		return "No tasks found"
+	}
+	var (
+		now                = time.Now()
+		axisStart, axisEnd = timelineBounds(m.group.Tasks, now)
+		labels             = make([]string, len(m.group.Tasks))
+		labelWidth         int
+	)
+	for i, t := range m.group.Tasks {
+		labels[i] = taskLabel(m.Helpers, m.group, t)
+		labelWidth = max(labelWidth, lipgloss.Width(labels[i]))
+	}
+	labelWidth = min(labelWidth, maxTimelineLabelWidth)
+	barWidth := max(1, m.width-tui.ScrollbarWidth-labelWidth-timelineDurationWidth-2)
+	scale := axisEnd.Sub(axisStart) / time.Duration(barWidth)
+
+	// Render time axis, labelling the start and end of the timeline.
+	var (
+		left  = "0s"
+		right = formatTimelineDuration(axisEnd.Sub(axisStart))
+		axis  = left + strings.Repeat("─", max(0, barWidth-len(left)-len(right))) + right
+		lines = []string{
+			strings.Repeat(" ", labelWidth+1) + tui.Regular.Foreground(tui.LightGrey).Render(axis),
+		}
+		numVisible = min(m.visibleRows(), len(m.group.Tasks)-m.start)
+	)
+	for i := m.start; i < m.start+numVisible; i++ {
+		t := m.group.Tasks[i]
+		label := labels[i]
+		if lipgloss.Width(label) > labelWidth {
+			label = ansi.Truncate(label, labelWidth, "…")
+		}
+		label += strings.Repeat(" ", labelWidth-lipgloss.Width(label))
+		lines = append(lines, fmt.Sprintf("%s %s %s",
+			label,
+			timelineBar(t, axisStart, scale, barWidth, now),
+			m.outcome(t),
+		))
+	}
+	scrollbar := tui.Scrollbar(m.visibleRows(), len(m.group.Tasks), numVisible, m.start)
+	return lipgloss.JoinHorizontal(lipgloss.Top,
+		lipgloss.NewStyle().Width(m.width-tui.ScrollbarWidth).Render(strings.Join(lines, "\n")),
+		"\n"+scrollbar,
+	)
+}
+
+// taskLabel labels a task with its module and workspace, and its command
+// unless the task belongs to the given group and the group consists of only
+// that command.
+func taskLabel(helpers *tui.Helpers, group *task.Group, t *task.Task) string {
+	parts := []string{helpers.TaskModulePath(t)}
+	if ws := helpers.TaskWorkspaceName(t); ws != "" {
+		parts = append(parts, ws)
+	}
+	if group == nil || group.Command != t.String() {
+		parts = append(parts, t.String())
+	}
+	return strings.Join(parts, " ")
+}
+
+// outcome renders how long the task has been running, colored according to
+// its status.
+func (m *timelineModel) outcome(t *task.Task) string {
+	var s string
+	if _, _, ok := t.Span(task.Running); ok {
+		s = formatTimelineDuration(t.Elapsed(task.Running))
+	}
+	switch t.State {
+	case task.Exited:
+		s += " ✓"
+	case task.Errored:
+		s += " ✗"
+	case task.Canceled:
+		s += " ⊘"
+	}
+	return tui.Regular.Foreground(tui.TaskStatusColor(t.State)).Render(s)
+}
+
+// timelineBounds returns the start and end of the timeline for the tasks: the
+// timeline starts when the first task was created, and ends either now, if any
+// task is yet to finish, or when the last task finished.
+func timelineBounds(tasks []*task.Task, now time.Time) (start, end time.Time) {
+	for _, t := range tasks {
+		if start.IsZero() || t.Created.Before(start) {
+			start = t.Created
+		}
+		finished := now
+		if t.State.IsFinal() {
+			finished = t.Updated
+		}
+		if finished.After(end) {
+			end = finished
+		}
+	}
+	// Ensure the timeline spans at least a second.
+	if end.Sub(start) < time.Second {
+		end = start.Add(time.Second)
+	}
+	return start, end
+}
+
+// timelineBar renders a task's statuses as a bar along the timeline, where
+// each cell of the bar spans the given scale. Spans that have yet to end are
+// rendered up until now.
+func timelineBar(t *task.Task, start time.Time, scale time.Duration, width int, now time.Time) string {
+	// Assign each cell an index into timelineStatuses, or -1 for none. Later
+	// statuses take precedence over earlier statuses in the same cell.
+	cells := make([]int, width)
+	for i := range cells {
+		cells[i] = -1
+	}
+	for i, ts := range timelineStatuses {
+		from, to, ok := t.Span(ts.status)
+		if !ok {
+			continue
+		}
+		if to.IsZero() {
+			to = now
+		}
+		first := int(from.Sub(start) / scale)
+		last := int((to.Sub(start) + scale - 1) / scale)
+		// Ensure every span is visible, however short.
+		last = max(last, first+1)
+		for j := max(0, first); j < min(last, width); j++ {
+			cells[j] = i
+		}
+	}
+	// Render consecutive cells with the same status together.
+	var b strings.Builder
+	for i := 0; i < width; {
+		j := i
+		for j < width && cells[j] == cells[i] {
+			j++
+		}
+		if cells[i] < 0 {
+			b.WriteString(strings.Repeat(" ", j-i))
+		} else {
+			ts := timelineStatuses[cells[i]]
+			b.WriteString(tui.Regular.Foreground(tui.TaskStatusColor(ts.status)).Render(strings.Repeat(ts.glyph, j-i)))
+		}
+		i = j
+	}
+	return b.String()
+}
+
+// formatTimelineDuration formats a duration to the nearest second, or to the
+// nearest tenth of a second if less than ten seconds.
+func formatTimelineDuration(d time.Duration) string {
+	if d < 10*time.Second {
+		return d.Round(100 * time.Millisecond).String()
+	}
+	return d.Round(time.Second).String()
+}
+
+func (m *timelineModel) BorderText() map[tui.BorderPosition]string {
+	legend := make([]string, len(timelineStatuses))
+	for i, ts := range timelineStatuses {
+		legend[i] = tui.Regular.Foreground(tui.TaskStatusColor(ts.status)).Render(ts.glyph) + " " + string(ts.status)
+	}
+	return map[tui.BorderPosition]string{
+		tui.TopLeftBorder: fmt.Sprintf(
+			"%s %s %s",
+			tui.Bold.Render("timeline"),
+			m.group.String(),
+			m.GroupReport(m.group, true),
+		),
+		tui.BottomMiddleBorder: strings.Join(legend, "  "),
+	}
+}
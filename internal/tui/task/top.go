@@ -0,0 +1,194 @@
+package task
+
+import (
+	"cmp"
+	"fmt"
+	"strconv"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/leg100/pug/internal/resource"
+	"github.com/leg100/pug/internal/task"
+	"github.com/leg100/pug/internal/tui"
+	"github.com/leg100/pug/internal/tui/keys"
+	"github.com/leg100/pug/internal/tui/table"
+)
+
+var (
+	cpuColumn = table.Column{
+		Key:        "cpu",
+		Title:      "CPU",
+		Width:      6,
+		RightAlign: true,
+	}
+	memColumn = table.Column{
+		Key:        "mem",
+		Title:      "MEM",
+		Width:      10,
+		RightAlign: true,
+	}
+	procsColumn = table.Column{
+		Key:        "procs",
+		Title:      "PROCS",
+		Width:      5,
+		RightAlign: true,
+	}
+	runningColumn = table.Column{
+		Key:   "running",
+		Title: "RUNNING",
+		Width: 9,
+	}
+)
+
+// topSortOrders are the orders in which the top view sorts tasks, each in
+// descending order of a type of resource usage.
+var topSortOrders = []struct {
+	name  string
+	value func(task.Usage) float64
+}{
+	{"cpu", func(u task.Usage) float64 { return u.CPU }},
+	{"mem", func(u task.Usage) float64 { return float64(u.RSS) }},
+	{"procs", func(u task.Usage) float64 { return float64(u.Processes) }},
+}
+
+// TopMaker makes models that list running tasks by resource usage.
+type TopMaker struct {
+	Tasks   *task.Service
+	Helpers *tui.Helpers
+}
+
+func (mm *TopMaker) Make(_ resource.ID, width, height int) (tui.ChildModel, error) {
+	columns := []table.Column{
+		table.ModuleColumn,
+		table.WorkspaceColumn,
+		commandColumn,
+		cpuColumn,
+		memColumn,
+		procsColumn,
+		runningColumn,
+	}
+	renderer := func(t *task.Task) table.RenderedRow {
+		usage, _ := t.Usage()
+		return table.RenderedRow{
+			table.ModuleColumn.Key:    mm.Helpers.TaskModulePath(t),
+			table.WorkspaceColumn.Key: mm.Helpers.TaskWorkspaceName(t),
+			commandColumn.Key:         t.String(),
+			cpuColumn.Key:             renderCPU(t),
+			memColumn.Key:             renderMemory(t),
+			procsColumn.Key:           strconv.Itoa(usage.Processes),
+			runningColumn.Key:         tui.FormatDuration(t.Elapsed(task.Running)),
+		}
+	}
+	m := &topModel{
+		Helpers: mm.Helpers,
+		tasks:   mm.Tasks,
+	}
+	m.Model = table.New(columns, renderer, width, height,
+		table.WithSortFunc(m.sortFunc()),
+		table.WithPreview[*task.Task](tui.TaskKind),
+	)
+	return m, nil
+}
+
+// topModel lists running tasks, sorted by their resource usage, akin to the
+// top(1) program.
+type topModel struct {
+	table.Model[*task.Task]
+	*tui.Helpers
+
+	tasks *task.Service
+	// sortOrder is an index into topSortOrders.
+	sortOrder int
+}
+
+func (m *topModel) Init() tea.Cmd {
+	return func() tea.Msg {
+		tasks := m.tasks.List(task.ListOptions{Status: []task.Status{task.Running}})
+		return table.BulkInsertMsg[*task.Task](tasks)
+	}
+}
+
+func (m *topModel) Update(msg tea.Msg) tea.Cmd {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, topKeys.Sort):
+			m.sortOrder = (m.sortOrder + 1) % len(topSortOrders)
+			m.SetSortFunc(m.sortFunc())
+			return nil
+		case key.Matches(msg, keys.Common.Cancel):
+			rows := m.SelectedOrCurrent()
+			taskIDs := make([]resource.ID, len(rows))
+			for i, row := range rows {
+				taskIDs[i] = row.ID
+			}
+			return cancel(m.tasks, taskIDs...)
+		}
+	case resource.Event[*task.Task]:
+		// Only list running tasks, removing tasks once they're no longer
+		// running.
+		if msg.Payload.State != task.Running {
+			msg.Type = resource.DeletedEvent
+		}
+		var cmd tea.Cmd
+		m.Model, cmd = m.Model.Update(msg)
+		return cmd
+	}
+	var cmd tea.Cmd
+	m.Model, cmd = m.Model.Update(msg)
+	return cmd
+}
+
+// sortFunc returns a func that sorts tasks in descending order of the current
+// sort order, and then by ID.
+func (m *topModel) sortFunc() func(*task.Task, *task.Task) int {
+	value := topSortOrders[m.sortOrder].value
+	return func(i, j *task.Task) int {
+		iu, _ := i.Usage()
+		ju, _ := j.Usage()
+		if c := cmp.Compare(value(ju), value(iu)); c != 0 {
+			return c
+		}
+		return cmp.Compare(i.ID.Serial, j.ID.Serial)
+	}
+}
+
+func (m *topModel) BorderText() map[tui.BorderPosition]string {
+	return map[tui.BorderPosition]string{
+		tui.TopLeftBorder:   tui.Bold.Render("top"),
+		tui.TopMiddleBorder: m.Metadata(),
+		tui.TopRightBorder:  fmt.Sprintf("sort: %s", topSortOrders[m.sortOrder].name),
+	}
+}
+
+func (m *topModel) HelpBindings() []key.Binding {
+	return []key.Binding{
+		topKeys.Sort,
+		keys.Common.Cancel,
+	}
+}
+
+// renderCPU renders the CPU usage of a running task. An empty string is
+// returned if the task is not running or its usage has yet to be sampled.
+func renderCPU(t *task.Task) string {
+	usage, ok := t.Usage()
+	if !ok || t.State != task.Running {
+		return ""
+	}
+	return fmt.Sprintf("%.0f%%", usage.CPU)
+}
+
+// renderMemory renders the memory usage of a running task. An empty string is
+// returned if the task is not running or its usage has yet to be sampled.
+func renderMemory(t *task.Task) string {
+	usage, ok := t.Usage()
+	if !ok || t.State != task.Running {
+		return ""
+	}
+	return formatBytes(usage.RSS)
+}
+
+// renderUsage renders all types of resource usage.
+func renderUsage(usage task.Usage) string {
+	return fmt.Sprintf("CPU %.0f%%, MEM %s, %d processes", usage.CPU, formatBytes(usage.RSS), usage.Processes)
+}
@@ -0,0 +1,379 @@
+package tui
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strconv"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/fatih/color"
+	"github.com/hokaccha/go-prettyjson"
+	"github.com/leg100/pug/internal"
+)
+
+// Theme is a set of colors with which the TUI is rendered. Each color is
+// either an ANSI color number, a hex color, e.g. #ff5353, or empty to use the
+// terminal's default color.
+type Theme struct {
+	Black           lipgloss.Color
+	Red             lipgloss.Color
+	Orange          lipgloss.Color
+	BurntOrange     lipgloss.Color
+	Green           lipgloss.Color
+	LightGreen      lipgloss.Color
+	GreenBlue       lipgloss.Color
+	Blue            lipgloss.Color
+	Grey            lipgloss.Color
+	LightGrey       lipgloss.Color
+	LighterGrey     lipgloss.Color
+	EvenLighterGrey lipgloss.Color
+	DarkGrey        lipgloss.Color
+	White           lipgloss.Color
+	HotPink         lipgloss.Color
+
+	DebugLogLevel         lipgloss.Color
+	InfoLogLevel          lipgloss.Color
+	ErrorLogLevel         lipgloss.Color
+	WarnLogLevel          lipgloss.Color
+	LogRecordAttributeKey lipgloss.Color
+
+	HelpKey  lipgloss.Color
+	HelpDesc lipgloss.Color
+
+	ActiveBorder   lipgloss.Color
+	InactiveBorder lipgloss.Color
+
+	CurrentBackground            lipgloss.Color
+	CurrentForeground            lipgloss.Color
+	SelectedBackground           lipgloss.Color
+	SelectedForeground           lipgloss.Color
+	CurrentAndSelectedBackground lipgloss.Color
+	CurrentAndSelectedForeground lipgloss.Color
+
+	GroupReportBackground lipgloss.Color
+
+	ModuleColor    lipgloss.Color
+	WorkspaceColor lipgloss.Color
+
+	PendingStatus lipgloss.Color
+	QueuedStatus  lipgloss.Color
+	RunningStatus lipgloss.Color
+	ExitedStatus  lipgloss.Color
+	ErroredStatus lipgloss.Color
+
+	JSONKey    lipgloss.Color
+	JSONString lipgloss.Color
+	JSONBool   lipgloss.Color
+	JSONNumber lipgloss.Color
+	JSONNull   lipgloss.Color
+}
+
+var darkTheme = Theme{
+	Black:           "#000000",
+	Red:             "#FF5353",
+	Orange:          "214",
+	BurntOrange:     "214",
+	Green:           "34",
+	LightGreen:      "47",
+	GreenBlue:       "#00A095",
+	Blue:            "63",
+	Grey:            "#737373",
+	LightGrey:       "245",
+	LighterGrey:     "250",
+	EvenLighterGrey: "253",
+	DarkGrey:        "#606362",
+	White:           "#ffffff",
+	HotPink:         "200",
+
+	DebugLogLevel:         "63",
+	InfoLogLevel:          "86",
+	ErrorLogLevel:         "#FF5353",
+	WarnLogLevel:          "#DBBD70",
+	LogRecordAttributeKey: "245",
+
+	HelpDesc: "248",
+
+	ActiveBorder:   "63",
+	InactiveBorder: "244",
+
+	CurrentBackground:            "#737373",
+	CurrentForeground:            "#ffffff",
+	SelectedBackground:           "110",
+	SelectedForeground:           "#000000",
+	CurrentAndSelectedBackground: "117",
+	CurrentAndSelectedForeground: "#000000",
+
+	GroupReportBackground: "253",
+
+	ModuleColor:    "75",
+	WorkspaceColor: "135",
+
+	PendingStatus: "#737373",
+	QueuedStatus:  "214",
+	RunningStatus: "63",
+	ExitedStatus:  "#00A095",
+	ErroredStatus: "#FF5353",
+
+	JSONKey:    "4",
+	JSONString: "2",
+	JSONBool:   "3",
+	JSONNumber: "6",
+	JSONNull:   "8",
+}
+
+// lightTheme uses darker shades that remain legible on a light background.
+var lightTheme = Theme{
+	Black:           "#000000",
+	Red:             "160",
+	Orange:          "166",
+	BurntOrange:     "130",
+	Green:           "28",
+	LightGreen:      "114",
+	GreenBlue:       "30",
+	Blue:            "25",
+	Grey:            "#737373",
+	LightGrey:       "242",
+	LighterGrey:     "245",
+	EvenLighterGrey: "254",
+	DarkGrey:        "#606362",
+	White:           "#ffffff",
+	HotPink:         "162",
+
+	DebugLogLevel:         "25",
+	InfoLogLevel:          "28",
+	ErrorLogLevel:         "160",
+	WarnLogLevel:          "130",
+	LogRecordAttributeKey: "242",
+
+	HelpDesc: "242",
+
+	ActiveBorder:   "25",
+	InactiveBorder: "250",
+
+	CurrentBackground:            "#737373",
+	CurrentForeground:            "#ffffff",
+	SelectedBackground:           "110",
+	SelectedForeground:           "#000000",
+	CurrentAndSelectedBackground: "117",
+	CurrentAndSelectedForeground: "#000000",
+
+	GroupReportBackground: "254",
+
+	ModuleColor:    "27",
+	WorkspaceColor: "91",
+
+	PendingStatus: "242",
+	QueuedStatus:  "166",
+	RunningStatus: "25",
+	ExitedStatus:  "30",
+	ErroredStatus: "160",
+
+	JSONKey:    "25",
+	JSONString: "28",
+	JSONBool:   "130",
+	JSONNumber: "30",
+	JSONNull:   "242",
+}
+
+// highContrastTheme uses the bright ANSI colors on a dark background, which
+// also adapt to the terminal's own color scheme.
+var highContrastTheme = Theme{
+	Black:           "0",
+	Red:             "9",
+	Orange:          "11",
+	BurntOrange:     "11",
+	Green:           "10",
+	LightGreen:      "10",
+	GreenBlue:       "14",
+	Blue:            "12",
+	Grey:            "8",
+	LightGrey:       "15",
+	LighterGrey:     "15",
+	EvenLighterGrey: "15",
+	DarkGrey:        "0",
+	White:           "15",
+	HotPink:         "13",
+
+	DebugLogLevel:         "12",
+	InfoLogLevel:          "14",
+	ErrorLogLevel:         "9",
+	WarnLogLevel:          "11",
+	LogRecordAttributeKey: "15",
+
+	HelpKey:  "11",
+	HelpDesc: "15",
+
+	ActiveBorder:   "11",
+	InactiveBorder: "15",
+
+	CurrentBackground:            "15",
+	CurrentForeground:            "0",
+	SelectedBackground:           "11",
+	SelectedForeground:           "0",
+	CurrentAndSelectedBackground: "14",
+	CurrentAndSelectedForeground: "0",
+
+	GroupReportBackground: "0",
+
+	ModuleColor:    "14",
+	WorkspaceColor: "13",
+
+	PendingStatus: "15",
+	QueuedStatus:  "11",
+	RunningStatus: "12",
+	ExitedStatus:  "10",
+	ErroredStatus: "9",
+
+	JSONKey:    "12",
+	JSONString: "10",
+	JSONBool:   "11",
+	JSONNumber: "14",
+	JSONNull:   "15",
+}
+
+var themes = map[string]Theme{
+	"dark":          darkTheme,
+	"light":         lightTheme,
+	"high-contrast": highContrastTheme,
+}
+
+// noColor is true if the user has disabled colors.
+var noColor bool
+
+// SetTheme sets the colors with which the TUI is rendered. The name is that
+// of a built-in theme, or auto to pick the dark or light theme according to the
+// terminal's background. Colors overrides colors of the theme, keyed by the
+// kebab-cased name of the color, e.g. current-background. If disableColor is
+// true then rows are distinguished with text attributes rather than colors.
+func SetTheme(name string, colors map[string]string, disableColor bool) error {
+	if name == "auto" {
+		name = "light"
+		if lipgloss.HasDarkBackground() {
+			name = "dark"
+		}
+	}
+	theme, ok := themes[name]
+	if !ok {
+		return fmt.Errorf("unknown theme: %s", name)
+	}
+	fields := make(map[string]reflect.Value)
+	v := reflect.ValueOf(&theme).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		fields[internal.KebabCase(v.Type().Field(i).Name)] = v.Field(i)
+	}
+	for _, key := range slices.Sorted(maps.Keys(colors)) {
+		field, ok := fields[key]
+		if !ok {
+			return fmt.Errorf("unknown color: %s", key)
+		}
+		if !validColor(colors[key]) {
+			return fmt.Errorf("invalid color %q for %s: must be an ANSI color number or a hex color", colors[key], key)
+		}
+		field.Set(reflect.ValueOf(lipgloss.Color(colors[key])))
+	}
+	theme.apply()
+	noColor = disableColor
+	return nil
+}
+
+// validColor determines whether c is empty, an ANSI color number, or a hex
+// color.
+func validColor(c string) bool {
+	if c == "" {
+		return true
+	}
+	if n, err := strconv.Atoi(c); err == nil {
+		return n >= 0 && n <= 255
+	}
+	if len(c) == 7 && c[0] == '#' {
+		_, err := strconv.ParseUint(c[1:], 16, 32)
+		return err == nil
+	}
+	return false
+}
+
+func (t Theme) apply() {
+	Black = t.Black
+	Red = t.Red
+	Orange = t.Orange
+	BurntOrange = t.BurntOrange
+	Green = t.Green
+	LightGreen = t.LightGreen
+	GreenBlue = t.GreenBlue
+	Blue = t.Blue
+	Grey = t.Grey
+	LightGrey = t.LightGrey
+	LighterGrey = t.LighterGrey
+	EvenLighterGrey = t.EvenLighterGrey
+	DarkGrey = t.DarkGrey
+	White = t.White
+	HotPink = t.HotPink
+
+	DebugLogLevel = t.DebugLogLevel
+	InfoLogLevel = t.InfoLogLevel
+	ErrorLogLevel = t.ErrorLogLevel
+	WarnLogLevel = t.WarnLogLevel
+	LogRecordAttributeKey = t.LogRecordAttributeKey
+
+	HelpKey = t.HelpKey
+	HelpDesc = t.HelpDesc
+
+	ActiveBorder = t.ActiveBorder
+	InactiveBorder = t.InactiveBorder
+
+	CurrentBackground = t.CurrentBackground
+	CurrentForeground = t.CurrentForeground
+	SelectedBackground = t.SelectedBackground
+	SelectedForeground = t.SelectedForeground
+	CurrentAndSelectedBackground = t.CurrentAndSelectedBackground
+	CurrentAndSelectedForeground = t.CurrentAndSelectedForeground
+
+	GroupReportBackground = t.GroupReportBackground
+
+	ModuleColor = t.ModuleColor
+	WorkspaceColor = t.WorkspaceColor
+
+	PendingStatus = t.PendingStatus
+	QueuedStatus = t.QueuedStatus
+	RunningStatus = t.RunningStatus
+	ExitedStatus = t.ExitedStatus
+	ErroredStatus = t.ErroredStatus
+
+	JSONKey = t.JSONKey
+	JSONString = t.JSONString
+	JSONBool = t.JSONBool
+	JSONNumber = t.JSONNumber
+	JSONNull = t.JSONNull
+
+	ModuleStyle = Regular.Foreground(ModuleColor)
+	WorkspaceStyle = Regular.Foreground(WorkspaceColor)
+}
+
+// jsonFormatter returns a formatter that pretty prints JSON in the colors of
+// the theme.
+func jsonFormatter() *prettyjson.Formatter {
+	f := prettyjson.NewFormatter()
+	f.KeyColor = jsonColor(JSONKey)
+	f.StringColor = jsonColor(JSONString)
+	f.BoolColor = jsonColor(JSONBool)
+	f.NumberColor = jsonColor(JSONNumber)
+	f.NullColor = jsonColor(JSONNull)
+	f.DisabledColor = noColor
+	return f
+}
+
+// jsonColor converts a color into a bold color for the JSON formatter.
+func jsonColor(c lipgloss.Color) *color.Color {
+	attrs := []color.Attribute{color.Bold}
+	s := string(c)
+	if len(s) == 7 && s[0] == '#' {
+		if rgb, err := strconv.ParseUint(s[1:], 16, 32); err == nil {
+			attrs = append(attrs, 38, 2, color.Attribute(rgb>>16), color.Attribute(rgb>>8&0xff), color.Attribute(rgb&0xff))
+		}
+	} else if n, err := strconv.Atoi(s); err == nil {
+		attrs = append(attrs, 38, 5, color.Attribute(n))
+	}
+	return color.New(attrs...)
+}
@@ -0,0 +1,67 @@
+package tui
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetTheme(t *testing.T) {
+	t.Cleanup(func() {
+		darkTheme.apply()
+		noColor = false
+	})
+
+	t.Run("built-in theme with overrides", func(t *testing.T) {
+		err := SetTheme("light", map[string]string{
+			"current-background": "#005f87",
+			"json-key":           "21",
+		}, false)
+		require.NoError(t, err)
+
+		assert.Equal(t, lightTheme.Red, Red)
+		assert.Equal(t, lipgloss.Color("#005f87"), CurrentBackground)
+		assert.Equal(t, lipgloss.Color("21"), JSONKey)
+		assert.Equal(t, Regular.Foreground(lightTheme.ModuleColor), ModuleStyle)
+	})
+
+	t.Run("unknown theme", func(t *testing.T) {
+		err := SetTheme("solarized", nil, false)
+		assert.EqualError(t, err, "unknown theme: solarized")
+	})
+
+	t.Run("unknown color", func(t *testing.T) {
+		err := SetTheme("dark", map[string]string{"mauve": "1"}, false)
+		assert.EqualError(t, err, "unknown color: mauve")
+	})
+
+	t.Run("invalid color", func(t *testing.T) {
+		err := SetTheme("dark", map[string]string{"red": "crimson"}, false)
+		assert.EqualError(t, err, `invalid color "crimson" for red: must be an ANSI color number or a hex color`)
+	})
+}
+
+func TestRowStyle(t *testing.T) {
+	t.Cleanup(func() { noColor = false })
+
+	assert.Equal(t, CurrentBackground, RowStyle(true, false).GetBackground())
+	assert.Equal(t, SelectedBackground, RowStyle(false, true).GetBackground())
+	assert.Equal(t, CurrentAndSelectedBackground, RowStyle(true, true).GetBackground())
+
+	noColor = true
+
+	assert.True(t, RowStyle(true, false).GetUnderline())
+	assert.False(t, RowStyle(true, false).GetBold())
+	assert.True(t, RowStyle(false, true).GetBold())
+	assert.True(t, RowStyle(true, true).GetUnderline())
+	assert.True(t, RowStyle(true, true).GetBold())
+}
+
+func TestJSONColor(t *testing.T) {
+	assert.True(t, color.New(color.Bold, 38, 5, 63).Equals(jsonColor("63")))
+	assert.True(t, color.New(color.Bold, 38, 2, 0xff, 0x53, 0x53).Equals(jsonColor("#FF5353")))
+	assert.True(t, color.New(color.Bold).Equals(jsonColor("")))
+}
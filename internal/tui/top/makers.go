@@ -38,6 +38,10 @@ func makeMakers(
 			Workdir:          cfg.Workdir,
 			Helpers:          helpers,
 		},
+		tui.ModuleGraphKind: &explorer.GraphMaker{
+			Modules: app.Modules,
+			Helpers: helpers,
+		},
 		tui.TaskListKind: tasktui.NewListMaker(
 			app.Tasks,
 			app.Plans,
@@ -55,6 +59,24 @@ func makeMakers(
 			taskMaker,
 			helpers,
 		),
+		tui.TaskGroupPreviewKind: &tasktui.PreviewMaker{
+			Tasks:   app.Tasks,
+			Helpers: helpers,
+		},
+		tui.TaskGroupTimelineKind: &tasktui.TimelineMaker{
+			Tasks:   app.Tasks,
+			Helpers: helpers,
+		},
+		tui.TaskTopKind: &tasktui.TopMaker{
+			Tasks:   app.Tasks,
+			Helpers: helpers,
+		},
+		tui.TaskTailKind: &tasktui.TailMaker{
+			Tasks:   app.Tasks,
+			Spinner: spinner,
+			Helpers: helpers,
+			Config:  taskConfig,
+		},
 		tui.LogListKind: &logs.ListMaker{
 			Logger:        app.Logger,
 			Helpers:       helpers,
@@ -73,6 +95,32 @@ func makeMakers(
 			Plans:   app.Plans,
 			Helpers: helpers,
 		},
+		tui.SnapshotListKind: &workspacetui.SnapshotListMaker{
+			States:     app.States,
+			Workspaces: app.Workspaces,
+			Helpers:    helpers,
+		},
+		tui.SnapshotKind: &workspacetui.SnapshotMaker{
+			States:     app.States,
+			Workspaces: app.Workspaces,
+			Helpers:    helpers,
+		},
+		tui.StateHistoryKind: &workspacetui.HistoryListMaker{
+			States:     app.States,
+			Workspaces: app.Workspaces,
+			Helpers:    helpers,
+		},
+		tui.StateDiffKind: &workspacetui.StateDiffMaker{
+			States:     app.States,
+			Workspaces: app.Workspaces,
+		},
+		tui.CompareKind: &workspacetui.CompareMaker{
+			States: app.States,
+		},
+		tui.SearchKind: &workspacetui.SearchMaker{
+			States:     app.States,
+			Workspaces: app.Workspaces,
+		},
 	}
 	return makers
 }
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"slices"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/cursor"
@@ -16,6 +17,7 @@ import (
 	"github.com/leg100/pug/internal/app"
 	"github.com/leg100/pug/internal/module"
 	"github.com/leg100/pug/internal/resource"
+	"github.com/leg100/pug/internal/state"
 	"github.com/leg100/pug/internal/task"
 	"github.com/leg100/pug/internal/tui"
 	"github.com/leg100/pug/internal/tui/keys"
@@ -27,9 +29,10 @@ import (
 type mode int
 
 const (
-	normalMode mode = iota // default
-	promptMode             // confirm prompt is visible and taking input
-	filterMode             // filter is visible and taking input
+	normalMode  mode = iota // default
+	promptMode              // confirm prompt is visible and taking input
+	filterMode              // filter is visible and taking input
+	paletteMode             // command palette is visible and taking input
 )
 
 type model struct {
@@ -42,6 +45,7 @@ type model struct {
 	mode       mode
 	showHelp   bool
 	prompt     *tui.Prompt
+	palette    *tui.Palette
 	dump       *os.File
 	workdir    string
 	tasks      *task.Service
@@ -73,6 +77,7 @@ func newModel(cfg app.Config, app *app.App) (model, error) {
 		Plans:      app.Plans,
 		States:     app.States,
 		Tasks:      app.Tasks,
+		History:    app.History,
 		Logger:     app.Logger,
 		Workdir:    cfg.Workdir,
 	}
@@ -90,6 +95,14 @@ func newModel(cfg app.Config, app *app.App) (model, error) {
 		workdir:     cfg.Workdir.PrettyString(),
 		taskConfig:  taskConfig,
 	}
+	// Don't let tasks be pruned while they're open in a pane, either
+	// individually or as part of a task group.
+	app.Tasks.Protect(func(t *task.Task) bool {
+		if m.IsOpen(tui.Page{Kind: tui.TaskKind, ID: t.ID}) {
+			return true
+		}
+		return t.TaskGroupID != nil && m.IsOpen(tui.Page{Kind: tui.TaskGroupKind, ID: t.TaskGroupID})
+	})
 	return m, nil
 }
 
@@ -200,6 +213,21 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				cmd = m.FocusedModel().Update(tui.FilterKeyMsg(msg))
 				return m, cmd
 			}
+		case paletteMode:
+			if key.Matches(msg, keys.Global.Quit) {
+				// Allow user to quit app whilst the palette is open. In this
+				// case, close the palette and let the key handler below
+				// handle the quit action.
+				m.closePalette()
+				break
+			}
+			closePalette, cmd := m.palette.HandleKey(msg)
+			if closePalette {
+				m.closePalette()
+			}
+			// Running an action from the palette sends its key, which is
+			// then handled in normal mode.
+			return m, cmd
 		}
 
 		switch {
@@ -218,6 +246,17 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				Height: m.viewHeight(),
 				Width:  m.viewWidth(),
 			})
+		case key.Matches(msg, keys.Global.Palette):
+			// ':' opens the command palette, listing the actions available
+			// in the focused pane.
+			m.mode = paletteMode
+			var blink tea.Cmd
+			m.palette, blink = tui.NewPalette(m.paletteBindings())
+			_ = m.PaneManager.Update(tea.WindowSizeMsg{
+				Height: m.viewHeight(),
+				Width:  m.viewWidth(),
+			})
+			return m, blink
 		case key.Matches(msg, keys.Global.Filter):
 			// '/' enables filter mode if the current model indicates it
 			// supports it, which it does so by sending back a non-nil command.
@@ -234,6 +273,12 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, tui.NavigateTo(tui.LogListKind)
 		case key.Matches(msg, keys.Global.Tasks):
 			return m, tui.NavigateTo(tui.TaskListKind)
+		case key.Matches(msg, keys.Global.Search):
+			return m, searchPrompt()
+		case key.Matches(msg, keys.Global.ModuleGraph):
+			return m, tui.NavigateTo(tui.ModuleGraphKind)
+		case key.Matches(msg, keys.Global.Top):
+			return m, tui.NavigateTo(tui.TaskTopKind)
 		case key.Matches(msg, keys.Common.LastTask):
 			if m.lastTaskID != nil {
 				return m, tui.NavigateTo(tui.TaskKind, tui.WithParent(*m.lastTaskID))
@@ -249,6 +294,13 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			return m, nil
 		}
+	case tea.MouseMsg:
+		// Only handle the mouse when the panes are rendered from the top of
+		// the terminal, i.e. when neither the prompt nor the palette is open.
+		if m.mode == normalMode {
+			return m, m.PaneManager.Update(msg)
+		}
+		return m, nil
 	case tui.ErrorMsg:
 		m.err = error(msg)
 	case tui.InfoMsg:
@@ -263,9 +315,12 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case cursor.BlinkMsg:
 		// Send blink message to prompt if in prompt mode otherwise forward it
 		// to the active pane to handle.
-		if m.mode == promptMode {
+		switch m.mode {
+		case promptMode:
 			cmd = m.prompt.HandleBlink(msg)
-		} else {
+		case paletteMode:
+			cmd = m.palette.HandleBlink(msg)
+		default:
 			cmd = m.FocusedModel().Update(msg)
 		}
 		return m, cmd
@@ -280,9 +335,12 @@ func (m model) View() string {
 	// Start composing vertical stack of components that fill entire terminal.
 	var components []string
 
-	// Add prompt if in prompt mode.
-	if m.mode == promptMode {
+	// Add prompt if in prompt mode, or palette if in palette mode.
+	switch m.mode {
+	case promptMode:
 		components = append(components, m.prompt.View(m.width))
+	case paletteMode:
+		components = append(components, m.palette.View(m.width))
 	}
 	// Add panes
 	components = append(components, lipgloss.NewStyle().
@@ -291,11 +349,11 @@ func (m model) View() string {
 		Render(m.PaneManager.View()),
 	)
 	// Add help if enabled
-	if m.showHelp {
+	if m.helpVisible() {
 		components = append(components, m.help())
 	}
 	// Compose footer
-	footer := helpWidget
+	footer := helpWidget()
 	if m.err != nil {
 		footer += tui.Regular.Padding(0, 1).
 			Background(tui.Red).
@@ -315,7 +373,7 @@ func (m model) View() string {
 			Width(m.availableFooterMsgWidth()).
 			Render(m.info)
 	}
-	footer += versionWidget
+	footer += versionWidget()
 	// Add footer
 	components = append(components, tui.Regular.
 		Inline(true).
@@ -326,10 +384,13 @@ func (m model) View() string {
 	return strings.Join(components, "\n")
 }
 
-var (
-	helpWidget    = tui.Padded.Background(tui.Grey).Foreground(tui.White).Render("? help")
-	versionWidget = tui.Padded.Background(tui.DarkGrey).Foreground(tui.White).Render(version.Version)
-)
+func helpWidget() string {
+	return tui.Padded.Background(tui.Grey).Foreground(tui.White).Render("? help")
+}
+
+func versionWidget() string {
+	return tui.Padded.Background(tui.DarkGrey).Foreground(tui.White).Render(version.Version)
+}
 
 func (m model) reportShortTaskResult(tsk *task.Task) tea.Cmd {
 	if tsk.TaskGroupID != nil {
@@ -373,7 +434,7 @@ func (m model) reportShortTaskResult(tsk *task.Task) tea.Cmd {
 
 func (m model) availableFooterMsgWidth() int {
 	// -2 to accommodate padding
-	return max(0, m.width-lipgloss.Width(helpWidget)-lipgloss.Width(versionWidget))
+	return max(0, m.width-lipgloss.Width(helpWidget())-lipgloss.Width(versionWidget()))
 }
 
 // type taskCompletionMsg struct {
@@ -384,15 +445,48 @@ func (m model) availableFooterMsgWidth() int {
 // TODO: rename contentHeight
 func (m model) viewHeight() int {
 	vh := m.height - tui.FooterHeight
-	if m.mode == promptMode {
+	switch m.mode {
+	case promptMode:
 		vh -= tui.PromptHeight
+	case paletteMode:
+		vh -= tui.PaletteHeight
 	}
-	if m.showHelp {
+	if m.helpVisible() {
 		vh -= tui.HelpWidgetHeight
 	}
 	return max(tui.MinContentHeight, vh)
 }
 
+// closePalette closes the command palette, and sends a message to panes to
+// resize themselves to expand back into the space occupied by the palette.
+func (m *model) closePalette() {
+	m.mode = normalMode
+	_ = m.PaneManager.Update(tea.WindowSizeMsg{
+		Height: m.viewHeight(),
+		Width:  m.viewWidth(),
+	})
+}
+
+// helpVisible returns true if the help widget is visible. It is hidden whilst
+// the palette is open, to leave enough room for the panes.
+func (m model) helpVisible() bool {
+	return m.showHelp && m.mode != paletteMode
+}
+
+// paletteBindings returns the bindings for the actions listed in the command
+// palette: those available in the focused pane along with the global
+// bindings.
+func (m model) paletteBindings() []key.Binding {
+	var bindings []key.Binding
+	bindings = append(bindings, m.PaneManager.HelpBindings()...)
+	bindings = append(bindings, keys.KeyMapToSlice(keys.Global)...)
+	bindings = append(bindings, keys.KeyMapToSlice(keys.Navigation)...)
+	bindings = slices.DeleteFunc(bindings, func(b key.Binding) bool {
+		return slices.Equal(b.Keys(), keys.Global.Palette.Keys())
+	})
+	return removeDuplicateBindings(bindings)
+}
+
 // viewWidth retrieves the width available within the main view
 //
 // TODO: rename contentWidth
@@ -400,11 +494,6 @@ func (m model) viewWidth() int {
 	return max(tui.MinContentWidth, m.width)
 }
 
-var (
-	helpKeyStyle  = tui.Bold.Foreground(tui.HelpKey).Margin(0, 1, 0, 0)
-	helpDescStyle = tui.Regular.Foreground(tui.HelpDesc)
-)
-
 // help renders key bindings
 func (m model) help() string {
 	// Compile list of bindings to render
@@ -420,6 +509,10 @@ func (m model) help() string {
 	bindings = append(bindings, keys.KeyMapToSlice(keys.Global)...)
 	bindings = append(bindings, keys.KeyMapToSlice(keys.Navigation)...)
 	bindings = removeDuplicateBindings(bindings)
+	// Skip bindings the user has disabled.
+	bindings = slices.DeleteFunc(bindings, func(b key.Binding) bool {
+		return !b.Enabled()
+	})
 
 	// Enumerate through each group of bindings, populating a series of
 	// pairs of columns, one for keys, one for descriptions
@@ -431,8 +524,10 @@ func (m model) help() string {
 	)
 	for i := 0; i < len(bindings); i += rows {
 		var (
-			keys  []string
-			descs []string
+			keys          []string
+			descs         []string
+			helpKeyStyle  = tui.Bold.Foreground(tui.HelpKey).Margin(0, 1, 0, 0)
+			helpDescStyle = tui.Regular.Foreground(tui.HelpDesc)
 		)
 		for j := i; j < min(i+rows, len(bindings)); j++ {
 			keys = append(keys, helpKeyStyle.Render(bindings[j].Help().Key))
@@ -480,3 +575,21 @@ func removeDuplicateBindings(bindings []key.Binding) []key.Binding {
 	}
 	return bindings[:i]
 }
+
+// searchPrompt prompts the user for a query with which to search the
+// resources of all cached states.
+func searchPrompt() tea.Cmd {
+	return tui.CmdHandler(tui.PromptMsg{
+		Prompt:      "Search resources: ",
+		Placeholder: "text or /regex/",
+		Action: func(v string) tea.Cmd {
+			if v == "" {
+				return nil
+			}
+			query := state.ParseSearchQuery(v)
+			return tui.NavigateTo(tui.SearchKind, tui.WithParent(query))
+		},
+		Key:    key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "search")),
+		Cancel: key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "cancel")),
+	})
+}
@@ -7,34 +7,85 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/x/exp/teatest"
+	"github.com/leg100/pug/internal/api"
 	"github.com/leg100/pug/internal/app"
 	"github.com/leg100/pug/internal/resource"
+	"github.com/leg100/pug/internal/tui"
+	"github.com/leg100/pug/internal/tui/keys"
+	"github.com/leg100/pug/internal/web"
 	"github.com/stretchr/testify/require"
 )
 
 // Start starts the TUI and blocks until the user exits.
 func Start(cfg app.Config) error {
+	if err := keys.Remap(cfg.Keys); err != nil {
+		return err
+	}
+	if err := tui.SetTheme(cfg.Theme, cfg.Colors, cfg.NoColor); err != nil {
+		return err
+	}
+
 	app, err := app.New(cfg)
 	if err != nil {
 		return err
 	}
 	defer app.Cleanup()
 
+	// The web dashboard requires a token, which is shared with the API. If
+	// unset, generate it up front so that both use the same token.
+	token := cfg.API.Token
+	if token == "" && cfg.WebListen != "" {
+		if token, err = api.GenerateToken(cfg.DataDir); err != nil {
+			return err
+		}
+	}
+	if cfg.API.Listen != "" {
+		apiToken := token
+		if api.IsUnixSocket(cfg.API.Listen) {
+			// A unix socket only requires a token if explicitly set.
+			apiToken = cfg.API.Token
+		}
+		stop, err := api.Start(api.Options{
+			App:     app,
+			Listen:  cfg.API.Listen,
+			Token:   apiToken,
+			DataDir: cfg.DataDir,
+			Logger:  app.Logger,
+		})
+		if err != nil {
+			return err
+		}
+		defer stop()
+	}
+	if cfg.WebListen != "" {
+		stop, err := web.Start(web.Options{
+			App:    app,
+			Listen: cfg.WebListen,
+			Token:  token,
+			Logger: app.Logger,
+		})
+		if err != nil {
+			return err
+		}
+		defer stop()
+	}
+
 	m, err := newModel(cfg, app)
 	if err != nil {
 		return err
 	}
 
-	p := tea.NewProgram(m,
+	opts := []tea.ProgramOption{
 		// Use the full size of the terminal with its "alternate screen buffer"
 		tea.WithAltScreen(),
-		// Enabling mouse cell motion removes the ability to "blackboard" text
-		// with the mouse, which is useful for then copying text into the
-		// clipboard. Therefore we've decided to disable it and leave it
-		// commented out for posterity.
-		//
-		// tea.WithMouseCellMotion(),
-	)
+	}
+	// Enabling mouse cell motion removes the ability to "blackboard" text
+	// with the mouse, which is useful for then copying text into the
+	// clipboard. Therefore it is only enabled if the user opts in.
+	if cfg.Mouse {
+		opts = append(opts, tea.WithMouseCellMotion())
+	}
+	p := tea.NewProgram(m, opts...)
 
 	ch, unsub := setupSubscriptions(app, cfg)
 	defer unsub()
@@ -130,6 +181,16 @@ func setupSubscriptions(app *app.App, cfg app.Config) (chan tea.Msg, func()) {
 		}()
 
 	}
+	{
+		sub := app.States.SnapshotBroker.Subscribe(ctx)
+		wg.Add(1)
+		go func() {
+			for ev := range sub {
+				ch <- ev
+			}
+			wg.Done()
+		}()
+	}
 	{
 		sub := app.Plans.Subscribe(ctx)
 		wg.Add(1)
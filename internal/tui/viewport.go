@@ -1,15 +1,22 @@
 package tui
 
 import (
+	"bytes"
+	"cmp"
+	"errors"
 	"fmt"
+	"regexp"
+	"slices"
+	"strings"
 
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/charmbracelet/x/ansi"
-	"github.com/hokaccha/go-prettyjson"
+	"github.com/leg100/pug/internal"
 	"github.com/leg100/pug/internal/tui/keys"
 )
 
@@ -20,8 +27,53 @@ type Viewport struct {
 	content []byte
 	json    bool
 	spinner *spinner.Model
+	height  int
+
+	// lines are the lines of wrapped content, and plain are the same lines
+	// stripped of ANSI escape codes, against which searches are made.
+	lines []string
+	plain []string
+	// offset is the position in content of the start of its last line, which
+	// is re-wrapped when content is appended, and settled is the number of
+	// lines preceding it, which are not. color is the color sequence in effect
+	// at offset.
+	offset  int
+	settled int
+	color   []byte
+
+	// searchable is true if the user can search the content.
+	searchable bool
+	search     textinput.Model
+	// pattern is the compiled search, or nil if there is no search or it is
+	// invalid, in which case searchErr is non-nil.
+	pattern   *regexp.Regexp
+	searchErr error
+	matches   []match
+	// currentMatch is the index of the current match in matches.
+	currentMatch int
+	// marked is the index of the line last jumped to, or -1.
+	marked int
+}
+
+// match is a match of a search within a line of content.
+type match struct {
+	line       int
+	start, end int
 }
 
+// searchHeight is the height of the search bar, including the line beneath
+// it.
+const searchHeight = 2
+
+var (
+	errorLine   = regexp.MustCompile(`(?i)\berror\b`)
+	warningLine = regexp.MustCompile(`(?i)\bwarning\b`)
+	// changeLine matches the line announcing a resource change in a plan,
+	// e.g. "# aws_instance.web will be updated in-place", or the line
+	// announcing the change in an apply, e.g. "aws_instance.web: Creating...".
+	changeLine = regexp.MustCompile(`^\s*# \S+ (will|must) be |^\S+: (Creating|Modifying|Destroying)\.\.\.`)
+)
+
 type ViewportOptions struct {
 	Width      int
 	Height     int
@@ -29,13 +81,22 @@ type ViewportOptions struct {
 	Border     bool
 	Autoscroll bool
 	Spinner    *spinner.Model
+	// Searchable permits the user to search the content, using the filter
+	// keys to open a search bar.
+	Searchable bool
 }
 
 func NewViewport(opts ViewportOptions) Viewport {
+	search := textinput.New()
+	search.Prompt = "Search: "
+
 	m := Viewport{
-		viewport: viewport.New(0, 0),
-		json:     opts.JSON,
-		spinner:  opts.Spinner,
+		viewport:   viewport.New(0, 0),
+		json:       opts.JSON,
+		spinner:    opts.Spinner,
+		searchable: opts.Searchable,
+		search:     search,
+		marked:     -1,
 	}
 	m.SetDimensions(opts.Width, opts.Height)
 	return m
@@ -59,6 +120,52 @@ func (m Viewport) Update(msg tea.Msg) (Viewport, tea.Cmd) {
 		case key.Matches(msg, keys.Navigation.GotoBottom):
 			m.viewport.SetYOffset(m.viewport.TotalLineCount())
 		}
+		if m.searchable {
+			switch {
+			case key.Matches(msg, keys.Find.NextMatch):
+				m.moveMatch(1)
+				return m, nil
+			case key.Matches(msg, keys.Find.PrevMatch):
+				m.moveMatch(-1)
+				return m, nil
+			case key.Matches(msg, keys.Find.NextError):
+				return m, m.jumpTo(errorLine, "error")
+			case key.Matches(msg, keys.Find.NextWarning):
+				return m, m.jumpTo(warningLine, "warning")
+			case key.Matches(msg, keys.Find.NextChange):
+				return m, m.jumpTo(changeLine, "resource change")
+			}
+		}
+	case FilterFocusReqMsg:
+		if !m.searchable {
+			return m, nil
+		}
+		// Open the search bar and start blinking the cursor.
+		blink := m.search.Focus()
+		m.setHeight()
+		return m, blink
+	case FilterBlurMsg:
+		m.search.Blur()
+		m.setHeight()
+		return m, nil
+	case FilterCloseMsg:
+		m.search.Blur()
+		m.search.SetValue("")
+		m.setSearch()
+		m.setHeight()
+		return m, nil
+	case FilterKeyMsg:
+		// Unwrap key and send to search bar, searching incrementally as the
+		// user types.
+		m.search, cmd = m.search.Update(tea.KeyMsg(msg))
+		m.setSearch()
+		return m, cmd
+	default:
+		// Send any other messages to the search bar if it is focused.
+		if m.search.Focused() {
+			m.search, cmd = m.search.Update(msg)
+			cmds = append(cmds, cmd)
+		}
 	}
 
 	// Handle keyboard and mouse events in the viewport
@@ -89,7 +196,33 @@ func (m Viewport) View() string {
 		m.viewport.VisibleLineCount(),
 		m.viewport.YOffset,
 	)
-	return lipgloss.JoinHorizontal(lipgloss.Top, output, scrollbar)
+	output = lipgloss.JoinHorizontal(lipgloss.Top, output, scrollbar)
+	if m.searchVisible() {
+		bar := Regular.Margin(0, 1).Render(m.search.View() + "  " + m.searchStatus())
+		separator := strings.Repeat("─", m.viewport.Width+ScrollbarWidth)
+		return lipgloss.JoinVertical(lipgloss.Left, bar, separator, output)
+	}
+	return output
+}
+
+// searchStatus summarises the result of the search.
+func (m Viewport) searchStatus() string {
+	switch {
+	case m.searchErr != nil:
+		return Regular.Foreground(Red).Render("invalid regex")
+	case m.pattern == nil:
+		return ""
+	case len(m.matches) == 0:
+		return Regular.Foreground(LightGrey).Render("no matches")
+	default:
+		return Regular.Foreground(LightGrey).Render(fmt.Sprintf("%d/%d", m.currentMatch+1, len(m.matches)))
+	}
+}
+
+func (m Viewport) searchVisible() bool {
+	// Search bar is visible if it's either in focus, or it has a non-empty
+	// value.
+	return m.search.Focused() || m.search.Value() != ""
 }
 
 func (m *Viewport) SetDimensions(width, height int) {
@@ -97,12 +230,24 @@ func (m *Viewport) SetDimensions(width, height int) {
 	// If width has changed, re-wrap existing content.
 	rewrap := m.viewport.Width != width
 	m.viewport.Width = width
-	m.viewport.Height = height
+	m.height = height
+	m.setHeight()
 	if rewrap {
+		m.unsettle()
 		m.setContent()
 	}
 }
 
+// setHeight sets the height of the viewport, making room for the search bar
+// if it is visible.
+func (m *Viewport) setHeight() {
+	if m.searchVisible() {
+		m.viewport.Height = max(0, m.height-searchHeight)
+	} else {
+		m.viewport.Height = m.height
+	}
+}
+
 func (m *Viewport) AppendContent(content []byte, finished, autoScroll bool) (err error) {
 	m.content = append(m.content, content...)
 	if finished {
@@ -112,12 +257,13 @@ func (m *Viewport) AppendContent(content []byte, finished, autoScroll bool) (err
 			// Prettify JSON output from task. This can only be done once
 			// the task has finished and has produced complete and
 			// syntactically valid json object(s).
-			if b, fmterr := prettyjson.Format(m.content); fmterr != nil {
+			if b, fmterr := jsonFormatter().Format(m.content); fmterr != nil {
 				// In the event of an error, still set unprettified content
 				// below.
 				err = fmt.Errorf("pretty printing json content: %w", fmterr)
 			} else {
 				m.content = b
+				m.unsettle()
 			}
 		}
 	}
@@ -128,10 +274,176 @@ func (m *Viewport) AppendContent(content []byte, finished, autoScroll bool) (err
 	return err
 }
 
+// SetContent replaces the content of the viewport.
+func (m *Viewport) SetContent(content []byte) error {
+	m.content = nil
+	m.unsettle()
+	return m.AppendContent(content, true, false)
+}
+
+// setContent wraps the content that has yet to settle, i.e. the content from
+// the start of its last line onwards, so that appending content to a large
+// amount of output only processes what has been appended.
 func (m *Viewport) setContent() {
-	// Wrap content to the width of the viewport, whilst respecting ANSI escape
-	// codes (i.e. don't split codes across lines).
-	wrapped := ansi.Wrap(ansi.Wordwrap(string(m.content), m.viewport.Width, ""), m.viewport.Width, "")
+	from := m.settled
+	m.lines, m.plain = m.lines[:from], m.plain[:from]
+	rest := m.content[m.offset:]
+	if i := bytes.LastIndexByte(rest, '\n'); i >= 0 {
+		// Settle complete lines, dropping the line following the last newline,
+		// which is wrapped along with the remaining content below.
+		lines := m.wrap(rest[:i+1])
+		m.appendLines(lines[:len(lines)-1])
+		m.color = lastColor(rest[:i+1], m.color)
+		m.offset += i + 1
+		m.settled = len(m.lines)
+		rest = rest[i+1:]
+	}
+	m.appendLines(m.wrap(rest))
+	if m.marked >= len(m.lines) {
+		m.marked = -1
+	}
+	m.findMatches(from)
+	m.render()
+}
+
+// unsettle causes all content to be wrapped afresh, which is necessary when
+// the content is replaced or the width changes.
+func (m *Viewport) unsettle() {
+	m.offset, m.settled, m.color = 0, 0, nil
+}
+
+// wrap wraps content to the width of the viewport, whilst respecting ANSI
+// escape codes (i.e. don't split codes across lines), and splits it into
+// lines.
+func (m *Viewport) wrap(content []byte) []string {
+	content = append(bytes.Clone(m.color), content...)
+	wrapped := ansi.Wrap(ansi.Wordwrap(string(content), m.viewport.Width, ""), m.viewport.Width, "")
 	sanitized := SanitizeColors([]byte(wrapped))
-	m.viewport.SetContent(string(sanitized))
+	return strings.Split(string(sanitized), "\n")
+}
+
+func (m *Viewport) appendLines(lines []string) {
+	m.lines = append(m.lines, lines...)
+	for _, line := range lines {
+		m.plain = append(m.plain, ansi.Strip(line))
+	}
+}
+
+// setSearch compiles the search entered by the user and moves to the first
+// match at or beneath the top of the viewport. The search is case-insensitive
+// unless it contains an upper case character.
+func (m *Viewport) setSearch() {
+	m.pattern, m.searchErr = nil, nil
+	if value := m.search.Value(); value != "" {
+		m.pattern, m.searchErr = internal.CompileSmartCase(value)
+	}
+	m.findMatches(0)
+	m.currentMatch = 0
+	for i, match := range m.matches {
+		if match.line >= m.viewport.YOffset {
+			m.currentMatch = i
+			break
+		}
+	}
+	m.render()
+	if len(m.matches) > 0 {
+		m.scrollTo(m.matches[m.currentMatch].line)
+	}
+}
+
+// findMatches finds the matches of the search in the lines of content from
+// the given line onwards, replacing any matches previously found there.
+func (m *Viewport) findMatches(from int) {
+	n, _ := slices.BinarySearchFunc(m.matches, from, func(match match, line int) int {
+		return cmp.Compare(match.line, line)
+	})
+	m.matches = m.matches[:n]
+	if m.pattern == nil {
+		return
+	}
+	for i := from; i < len(m.plain); i++ {
+		for _, loc := range m.pattern.FindAllStringIndex(m.plain[i], -1) {
+			// Skip empty matches, e.g. ^, which cannot be highlighted.
+			if loc[1] > loc[0] {
+				m.matches = append(m.matches, match{line: i, start: loc[0], end: loc[1]})
+			}
+		}
+	}
+	m.currentMatch = min(m.currentMatch, max(0, len(m.matches)-1))
+}
+
+// moveMatch makes the match delta matches away the current match, wrapping
+// around at either end, and scrolls it into view.
+func (m *Viewport) moveMatch(delta int) {
+	if len(m.matches) == 0 {
+		return
+	}
+	m.currentMatch = (m.currentMatch + delta + len(m.matches)) % len(m.matches)
+	m.render()
+	m.scrollTo(m.matches[m.currentMatch].line)
+}
+
+// jumpTo marks the next line matching re and scrolls it into view, starting
+// from beneath the line last jumped to if it is still in view, or otherwise
+// from the top of the viewport, and wrapping around at the end.
+func (m *Viewport) jumpTo(re *regexp.Regexp, name string) tea.Cmd {
+	start := m.viewport.YOffset
+	if m.visible(m.marked) {
+		start = m.marked + 1
+	}
+	for i := range len(m.plain) {
+		line := (start + i) % len(m.plain)
+		if re.MatchString(m.plain[line]) {
+			m.marked = line
+			m.render()
+			m.scrollTo(line)
+			return nil
+		}
+	}
+	return ReportError(errors.New("no " + name + " found in output"))
+}
+
+// visible determines whether the line is in view.
+func (m *Viewport) visible(line int) bool {
+	return line >= m.viewport.YOffset && line < m.viewport.YOffset+m.viewport.Height
+}
+
+// scrollTo scrolls the line into the middle of the viewport, unless it is
+// already in view.
+func (m *Viewport) scrollTo(line int) {
+	if !m.visible(line) {
+		m.viewport.SetYOffset(max(0, line-m.viewport.Height/2))
+	}
+}
+
+// render sets the content of the viewport, highlighting matches of the search
+// and the line last jumped to. Highlighted lines are stripped of their colors.
+func (m *Viewport) render() {
+	if len(m.matches) == 0 && m.marked < 0 {
+		m.viewport.SetContent(strings.Join(m.lines, "\n"))
+		return
+	}
+	lines := make([]string, len(m.lines))
+	copy(lines, m.lines)
+	if m.marked >= 0 {
+		lines[m.marked] = RowStyle(true, false).Render(m.plain[m.marked])
+	}
+	for i := 0; i < len(m.matches); {
+		// Render all matches on the same line together.
+		var (
+			line    = m.matches[i].line
+			plain   = m.plain[line]
+			b       strings.Builder
+			printed int
+		)
+		for ; i < len(m.matches) && m.matches[i].line == line; i++ {
+			match := m.matches[i]
+			b.WriteString(plain[printed:match.start])
+			b.WriteString(RowStyle(i == m.currentMatch, true).Render(plain[match.start:match.end]))
+			printed = match.end
+		}
+		b.WriteString(plain[printed:])
+		lines[line] = b.String()
+	}
+	m.viewport.SetContent(strings.Join(lines, "\n"))
 }
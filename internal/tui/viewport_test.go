@@ -0,0 +1,185 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestViewport_Search(t *testing.T) {
+	setup := func(t *testing.T) Viewport {
+		var lines []string
+		for i := range 100 {
+			lines = append(lines, fmt.Sprintf("line %d", i))
+		}
+		lines[10] = "  # aws_instance.web will be updated in-place"
+		lines[50] = "│ Warning: Deprecated attribute"
+		lines[60] = "│ Error: Invalid reference"
+		lines[90] = "│ Error: Unsupported argument"
+		m := NewViewport(ViewportOptions{Width: 80, Height: 12, Searchable: true})
+		require.NoError(t, m.SetContent([]byte(strings.Join(lines, "\n"))))
+		return m
+	}
+	// typeSearch opens the search bar and types the search into it.
+	typeSearch := func(t *testing.T, m Viewport, search string) Viewport {
+		m, cmd := m.Update(FilterFocusReqMsg{})
+		require.NotNil(t, cmd)
+		for _, r := range search {
+			m, _ = m.Update(FilterKeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		}
+		return m
+	}
+	key := func(k string) tea.KeyMsg {
+		return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+	}
+
+	t.Run("not searchable", func(t *testing.T) {
+		m := NewViewport(ViewportOptions{Width: 80, Height: 12})
+
+		_, cmd := m.Update(FilterFocusReqMsg{})
+		assert.Nil(t, cmd)
+	})
+
+	t.Run("incremental search", func(t *testing.T) {
+		m := setup(t)
+
+		m = typeSearch(t, m, "line 1")
+		// line 1, line 10-19 (except line 10, which was replaced), and line
+		// 100 does not exist.
+		assert.Len(t, m.matches, 10)
+		assert.Equal(t, "1/10", stripped(m.searchStatus()))
+		// The search bar takes up room in the viewport.
+		assert.Equal(t, 10, m.viewport.Height)
+
+		m = typeSearch(t, m, "9")
+		assert.Len(t, m.matches, 1)
+		assert.Equal(t, 19, m.matches[0].line)
+	})
+
+	t.Run("smart case", func(t *testing.T) {
+		m := setup(t)
+
+		m = typeSearch(t, m, "error")
+		assert.Len(t, m.matches, 2)
+
+		m, _ = m.Update(FilterCloseMsg{})
+		m = typeSearch(t, m, "Error: Inv")
+		assert.Len(t, m.matches, 1)
+	})
+
+	t.Run("invalid regex", func(t *testing.T) {
+		m := setup(t)
+
+		m = typeSearch(t, m, "line (")
+		assert.Error(t, m.searchErr)
+		assert.Empty(t, m.matches)
+		assert.Equal(t, "invalid regex", stripped(m.searchStatus()))
+	})
+
+	t.Run("next and previous match", func(t *testing.T) {
+		m := setup(t)
+		m = typeSearch(t, m, "error")
+		m, _ = m.Update(FilterBlurMsg{})
+
+		// The first match is scrolled into view.
+		assert.Equal(t, 0, m.currentMatch)
+		assert.True(t, m.visible(60))
+
+		m, _ = m.Update(key("n"))
+		assert.Equal(t, 1, m.currentMatch)
+		assert.True(t, m.visible(90))
+		assert.Equal(t, "2/2", stripped(m.searchStatus()))
+
+		// Wrap around
+		m, _ = m.Update(key("n"))
+		assert.Equal(t, 0, m.currentMatch)
+
+		m, _ = m.Update(key("N"))
+		assert.Equal(t, 1, m.currentMatch)
+	})
+
+	t.Run("clear search", func(t *testing.T) {
+		m := setup(t)
+		m = typeSearch(t, m, "error")
+
+		m, _ = m.Update(FilterCloseMsg{})
+
+		assert.Nil(t, m.pattern)
+		assert.Empty(t, m.matches)
+		assert.Equal(t, 12, m.viewport.Height)
+	})
+
+	t.Run("jump to next error", func(t *testing.T) {
+		m := setup(t)
+
+		m, _ = m.Update(key("!"))
+		assert.Equal(t, 60, m.marked)
+		assert.True(t, m.visible(60))
+
+		m, _ = m.Update(key("!"))
+		assert.Equal(t, 90, m.marked)
+
+		// Wrap around
+		m, _ = m.Update(key("!"))
+		assert.Equal(t, 60, m.marked)
+	})
+
+	t.Run("jump to next warning", func(t *testing.T) {
+		m := setup(t)
+
+		m, _ = m.Update(key("W"))
+		assert.Equal(t, 50, m.marked)
+	})
+
+	t.Run("jump to next resource change", func(t *testing.T) {
+		m := setup(t)
+
+		m, _ = m.Update(key("#"))
+		assert.Equal(t, 10, m.marked)
+	})
+
+	t.Run("no line to jump to", func(t *testing.T) {
+		m := NewViewport(ViewportOptions{Width: 80, Height: 12, Searchable: true})
+		require.NoError(t, m.SetContent([]byte("nothing to see here")))
+
+		_, cmd := m.Update(key("!"))
+		require.NotNil(t, cmd)
+		assert.EqualError(t, cmd().(ErrorMsg), "no error found in output")
+	})
+}
+
+func TestViewport_AppendContent(t *testing.T) {
+	// Colored output with a long line that is wrapped, and a color that
+	// continues across lines.
+	content := []byte("\x1b[32mgreen\nstill green\x1b[0m\n" +
+		strings.Repeat("a long line ", 10) + "\n\x1b[31merror: ")
+	content = append(content, strings.Repeat("more output\n", 50)...)
+
+	want := NewViewport(ViewportOptions{Width: 40, Height: 12, Searchable: true})
+	want.search.SetValue("output|green")
+	want.setSearch()
+	require.NoError(t, want.AppendContent(content, false, false))
+
+	// Appending content in chunks, which split lines and escape codes, wraps
+	// the content as if it had been appended all at once.
+	got := NewViewport(ViewportOptions{Width: 40, Height: 12, Searchable: true})
+	got.search.SetValue("output|green")
+	got.setSearch()
+	for chunk := range slices.Chunk(content, 7) {
+		require.NoError(t, got.AppendContent(chunk, false, false))
+	}
+	assert.Equal(t, want.lines, got.lines)
+	assert.Equal(t, want.plain, got.plain)
+	assert.Equal(t, want.matches, got.matches)
+	assert.Len(t, got.matches, 52)
+}
+
+func stripped(s string) string {
+	return strings.TrimSpace(ansi.Strip(s))
+}
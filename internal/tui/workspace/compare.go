@@ -0,0 +1,145 @@
+package workspace
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/leg100/pug/internal/resource"
+	"github.com/leg100/pug/internal/state"
+	"github.com/leg100/pug/internal/tui"
+)
+
+type CompareMaker struct {
+	States *state.Service
+}
+
+func (mm *CompareMaker) Make(id resource.ID, width, height int) (tui.ChildModel, error) {
+	compareID, ok := id.(state.CompareID)
+	if !ok {
+		return nil, fmt.Errorf("invalid comparison ID: %v", id)
+	}
+	comparison, err := mm.States.Compare(compareID)
+	if err != nil {
+		return nil, err
+	}
+	m := &compareModel{
+		states:     mm.States,
+		id:         compareID,
+		comparison: comparison,
+		viewport: tui.NewViewport(tui.ViewportOptions{
+			Width:  width,
+			Height: height,
+		}),
+	}
+	if err := m.viewport.SetContent([]byte(renderComparison(comparison))); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// compareModel shows the differences between two workspaces.
+type compareModel struct {
+	states     *state.Service
+	id         state.CompareID
+	comparison *state.Comparison
+	viewport   tui.Viewport
+}
+
+func (m *compareModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m *compareModel) Update(msg tea.Msg) tea.Cmd {
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case resource.Event[*state.State]:
+		if msg.Payload.WorkspaceID != m.id.A && msg.Payload.WorkspaceID != m.id.B {
+			return nil
+		}
+		// State of one of the workspaces has changed so re-compare.
+		comparison, err := m.states.Compare(m.id)
+		if err != nil {
+			return tui.ReportError(fmt.Errorf("comparing workspaces: %w", err))
+		}
+		m.comparison = comparison
+		if err := m.viewport.SetContent([]byte(renderComparison(comparison))); err != nil {
+			return tui.ReportError(err)
+		}
+		return nil
+	case tea.WindowSizeMsg:
+		m.viewport.SetDimensions(msg.Width, msg.Height)
+		return nil
+	}
+
+	// Handle keyboard and mouse events in the viewport
+	m.viewport, cmd = m.viewport.Update(msg)
+	return cmd
+}
+
+func (m *compareModel) View() string {
+	return m.viewport.View()
+}
+
+func (m *compareModel) BorderText() map[tui.BorderPosition]string {
+	return map[tui.BorderPosition]string{
+		tui.TopLeftBorder: fmt.Sprintf(
+			"%s %s %s ↔ %s %s",
+			tui.Bold.Render("compare"),
+			tui.ModulePathWithIcon(m.comparison.A.ModulePath, true),
+			tui.WorkspaceNameWithIcon(m.comparison.A.Name, true),
+			tui.ModulePathWithIcon(m.comparison.B.ModulePath, true),
+			tui.WorkspaceNameWithIcon(m.comparison.B.Name, true),
+		),
+	}
+}
+
+// renderComparison renders the differences between two workspaces.
+func renderComparison(c *state.Comparison) string {
+	var (
+		b       strings.Builder
+		onlyA   = tui.Regular.Foreground(tui.Red)
+		onlyB   = tui.Regular.Foreground(tui.Green)
+		heading = func(format string, args ...any) {
+			if b.Len() > 0 {
+				b.WriteRune('\n')
+			}
+			b.WriteString(tui.Bold.Render(fmt.Sprintf(format, args...)))
+			b.WriteRune('\n')
+		}
+	)
+	for _, ws := range c.Unloaded {
+		heading("State not loaded for %s; resources not compared", ws)
+	}
+	if len(c.Resources.Removed) > 0 {
+		heading("Resources only in %s (%d)", c.A, len(c.Resources.Removed))
+		for _, addr := range c.Resources.Removed {
+			b.WriteString(onlyA.Render("- " + string(addr)))
+			b.WriteRune('\n')
+		}
+	}
+	if len(c.Resources.Added) > 0 {
+		heading("Resources only in %s (%d)", c.B, len(c.Resources.Added))
+		for _, addr := range c.Resources.Added {
+			b.WriteString(onlyB.Render("+ " + string(addr)))
+			b.WriteRune('\n')
+		}
+	}
+	if len(c.Resources.Changed) > 0 {
+		heading("Resources that differ (%d)", len(c.Resources.Changed))
+		for _, rdiff := range c.Resources.Changed {
+			renderResourceDiff(&b, rdiff)
+		}
+	}
+	if len(c.Variables) > 0 {
+		heading("Variables that differ (%d)", len(c.Variables))
+		for _, v := range c.Variables {
+			renderAttributeDiff(&b, "  ", v)
+		}
+	}
+	if b.Len() == 0 {
+		return fmt.Sprintf("No differences between %s and %s", c.A, c.B)
+	}
+	return b.String()
+}
@@ -0,0 +1,77 @@
+package workspace
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/leg100/pug/internal/state"
+	"github.com/leg100/pug/internal/tui"
+)
+
+// renderDiff renders a colored, line-by-line summary of a state diff.
+func renderDiff(diff state.Diff) string {
+	if diff.Empty() {
+		return "No changes"
+	}
+	var (
+		b       strings.Builder
+		added   = tui.Regular.Foreground(tui.Green)
+		removed = tui.Regular.Foreground(tui.Red)
+	)
+	for _, addr := range diff.Added {
+		b.WriteString(added.Render("+ " + string(addr)))
+		b.WriteRune('\n')
+	}
+	for _, addr := range diff.Removed {
+		b.WriteString(removed.Render("- " + string(addr)))
+		b.WriteRune('\n')
+	}
+	for _, rdiff := range diff.Changed {
+		renderResourceDiff(&b, rdiff)
+	}
+	return b.String()
+}
+
+// renderResourceDiff renders the attribute-level changes to a resource.
+func renderResourceDiff(b *strings.Builder, rdiff state.ResourceDiff) {
+	b.WriteString(tui.Regular.Foreground(tui.Blue).Render("~ " + string(rdiff.Address)))
+	b.WriteRune('\n')
+	if rdiff.TaintedBefore != rdiff.TaintedAfter {
+		fmt.Fprintf(b, "    tainted: %t → %t\n", rdiff.TaintedBefore, rdiff.TaintedAfter)
+	}
+	for _, attr := range rdiff.Attributes {
+		renderAttributeDiff(b, "    ", attr)
+	}
+}
+
+// renderAttributeDiff renders a change to an attribute value on a single line.
+func renderAttributeDiff(b *strings.Builder, indent string, attr state.AttributeDiff) {
+	fmt.Fprintf(b, "%s%s: %s → %s\n",
+		indent,
+		attr.Path,
+		tui.Regular.Foreground(tui.Red).Render(renderAttributeValue(attr.Before)),
+		tui.Regular.Foreground(tui.Green).Render(renderAttributeValue(attr.After)),
+	)
+}
+
+func renderAttributeValue(v any) string {
+	if v == nil {
+		return "null"
+	}
+	marshaled, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(marshaled)
+}
+
+// renderDiffSummary renders a colored summary of the number of resources added,
+// changed, and removed in a state diff.
+func renderDiffSummary(diff state.Diff) string {
+	added := tui.Regular.Foreground(tui.Green).Render(fmt.Sprintf("+%d", len(diff.Added)))
+	changed := tui.Regular.Foreground(tui.Blue).Render(fmt.Sprintf("~%d", len(diff.Changed)))
+	removed := tui.Regular.Foreground(tui.Red).Render(fmt.Sprintf("-%d", len(diff.Removed)))
+
+	return fmt.Sprintf("%s%s%s", added, changed, removed)
+}
@@ -0,0 +1,191 @@
+package workspace
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/leg100/pug/internal/resource"
+	"github.com/leg100/pug/internal/state"
+	"github.com/leg100/pug/internal/tui"
+	"github.com/leg100/pug/internal/tui/table"
+	"github.com/leg100/pug/internal/workspace"
+)
+
+var (
+	historySerialColumn = table.Column{
+		Key:   "serial",
+		Title: "SERIAL",
+		Width: 8,
+	}
+	historyResourcesColumn = table.Column{
+		Key:   "resources",
+		Title: "RESOURCES",
+		Width: len("RESOURCES"),
+	}
+	historyChangesColumn = table.Column{
+		Key:        "changes",
+		Title:      "CHANGES",
+		FlexFactor: 1,
+	}
+)
+
+type HistoryListMaker struct {
+	States     *state.Service
+	Workspaces *workspace.Service
+	Helpers    *tui.Helpers
+}
+
+func (mm *HistoryListMaker) Make(workspaceID resource.ID, width, height int) (tui.ChildModel, error) {
+	ws, err := mm.Workspaces.Get(workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	columns := []table.Column{
+		historySerialColumn,
+		historyResourcesColumn,
+		historyChangesColumn,
+	}
+	renderer := func(rev revision) table.RenderedRow {
+		return table.RenderedRow{
+			historySerialColumn.Key:    fmt.Sprintf("#%d", rev.state.Serial),
+			historyResourcesColumn.Key: fmt.Sprintf("%d", len(rev.state.Resources)),
+			historyChangesColumn.Key:   renderDiffSummary(rev.diff),
+		}
+	}
+	tbl := table.New(
+		columns,
+		renderer,
+		width,
+		height,
+		table.WithSortFunc(byNewestRevision),
+		table.WithPreview[revision](tui.StateDiffKind),
+	)
+	return &historyList{
+		Model:     tbl,
+		Helpers:   mm.Helpers,
+		states:    mm.States,
+		workspace: ws,
+	}, nil
+}
+
+// revision is a state in a workspace's history, along with the changes made
+// since the preceding state in the history.
+type revision struct {
+	id    state.DiffID
+	state *state.State
+	diff  state.Diff
+}
+
+func (r revision) GetID() resource.ID { return r.id }
+
+type historyList struct {
+	table.Model[revision]
+	*tui.Helpers
+
+	states    *state.Service
+	workspace *workspace.Workspace
+}
+
+func (m *historyList) Init() tea.Cmd {
+	m.setRevisions()
+	return nil
+}
+
+func (m *historyList) Update(msg tea.Msg) tea.Cmd {
+	var (
+		cmd  tea.Cmd
+		cmds []tea.Cmd
+	)
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, historyKeys.Enter):
+			revs := m.SelectedOrCurrent()
+			switch len(revs) {
+			case 1:
+				return tui.NavigateTo(tui.StateDiffKind, tui.WithParent(revs[0].id))
+			case 2:
+				before, after := revs[0].state, revs[1].state
+				if before.ID.Serial > after.ID.Serial {
+					before, after = after, before
+				}
+				id := state.DiffID{
+					WorkspaceID: m.workspace.ID,
+					Before:      before.ID,
+					After:       after.ID,
+				}
+				return tui.NavigateTo(tui.StateDiffKind, tui.WithParent(id))
+			default:
+				return tui.ReportError(errors.New("select no more than two states to compare"))
+			}
+		}
+	case resource.Event[*state.State]:
+		if msg.Payload.WorkspaceID != m.workspace.ID {
+			return nil
+		}
+		m.setRevisions()
+		return nil
+	}
+
+	// Handle keyboard and mouse events in the table widget
+	m.Model, cmd = m.Model.Update(msg)
+	cmds = append(cmds, cmd)
+
+	return tea.Batch(cmds...)
+}
+
+// setRevisions populates the table with the workspace's state history.
+func (m *historyList) setRevisions() {
+	states := m.states.History(m.workspace.ID)
+	revs := make([]revision, len(states))
+	for i, current := range states {
+		// History is newest first, so the preceding state is the next state in
+		// the list. The oldest state is compared with an empty state.
+		var previous *state.State
+		id := state.DiffID{WorkspaceID: m.workspace.ID, After: current.ID}
+		if i+1 < len(states) {
+			previous = states[i+1]
+			id.Before = previous.ID
+		}
+		revs[i] = revision{
+			id:    id,
+			state: current,
+			diff:  state.NewDiff(previous, current),
+		}
+	}
+	m.SetItems(revs...)
+}
+
+func (m *historyList) BorderText() map[tui.BorderPosition]string {
+	return map[tui.BorderPosition]string{
+		tui.TopLeftBorder: fmt.Sprintf(
+			"%s %s %s",
+			tui.Bold.Render("history"),
+			tui.ModulePathWithIcon(m.workspace.ModulePath, true),
+			tui.WorkspaceNameWithIcon(m.workspace.Name, true),
+		),
+		tui.TopMiddleBorder: m.Metadata(),
+	}
+}
+
+func (m *historyList) HelpBindings() []key.Binding {
+	return []key.Binding{
+		historyKeys.Enter,
+	}
+}
+
+// byNewestRevision sorts revisions by the order in which their states were
+// retrieved, newest first. Serials are not used because they may repeat, e.g.
+// after restoring a snapshot.
+func byNewestRevision(i, j revision) int {
+	switch {
+	case i.state.ID.Serial > j.state.ID.Serial:
+		return -1
+	case i.state.ID.Serial < j.state.ID.Serial:
+		return 1
+	}
+	return 0
+}
@@ -2,6 +2,7 @@ package workspace
 
 import (
 	"github.com/charmbracelet/bubbles/key"
+	"github.com/leg100/pug/internal/tui/keys"
 )
 
 type resourcesKeyMap struct {
@@ -13,6 +14,8 @@ type resourcesKeyMap struct {
 	Untaint     key.Binding
 	Move        key.Binding
 	Reload      key.Binding
+	Snapshots   key.Binding
+	History     key.Binding
 	Enter       key.Binding
 }
 
@@ -49,8 +52,49 @@ var resourcesKeys = resourcesKeyMap{
 		key.WithKeys("ctrl+r"),
 		key.WithHelp("ctrl+r", "reload"),
 	),
+	Snapshots: key.NewBinding(
+		key.WithKeys("S"),
+		key.WithHelp("S", "snapshots"),
+	),
+	History: key.NewBinding(
+		key.WithKeys("H"),
+		key.WithHelp("H", "history"),
+	),
 	Enter: key.NewBinding(
 		key.WithKeys("enter"),
 		key.WithHelp("enter", "view resource"),
 	),
 }
+
+type snapshotsKeyMap struct {
+	Restore key.Binding
+	Enter   key.Binding
+}
+
+var snapshotsKeys = snapshotsKeyMap{
+	Restore: key.NewBinding(
+		key.WithKeys("R"),
+		key.WithHelp("R", "restore"),
+	),
+	Enter: key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "view diff"),
+	),
+}
+
+type historyKeyMap struct {
+	Enter key.Binding
+}
+
+var historyKeys = historyKeyMap{
+	Enter: key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "view diff (select two to compare)"),
+	),
+}
+
+func init() {
+	keys.Register("resources", &resourcesKeys, "resources")
+	keys.Register("snapshots", &snapshotsKeys, "snapshots")
+	keys.Register("history", &historyKeys, "history")
+}
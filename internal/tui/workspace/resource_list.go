@@ -126,6 +126,10 @@ func (m *resourceList) Update(msg tea.Msg) tea.Cmd {
 			if row, ok := m.CurrentRow(); ok {
 				return tui.NavigateTo(tui.ResourceKind, tui.WithParent(row.ID))
 			}
+		case key.Matches(msg, resourcesKeys.Snapshots):
+			return tui.NavigateTo(tui.SnapshotListKind, tui.WithParent(m.workspace.ID))
+		case key.Matches(msg, resourcesKeys.History):
+			return tui.NavigateTo(tui.StateHistoryKind, tui.WithParent(m.workspace.ID))
 		case key.Matches(msg, resourcesKeys.Reload):
 			if m.reloading {
 				return tui.ReportError(errors.New("reloading in progress"))
@@ -248,6 +252,8 @@ func (m resourceList) HelpBindings() []key.Binding {
 		resourcesKeys.Taint,
 		resourcesKeys.Untaint,
 		resourcesKeys.Reload,
+		resourcesKeys.Snapshots,
+		resourcesKeys.History,
 	}
 	bindings = append(bindings, m.common.HelpBindings()...)
 	return bindings
@@ -0,0 +1,129 @@
+package workspace
+
+import (
+	"cmp"
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/leg100/pug/internal/resource"
+	"github.com/leg100/pug/internal/state"
+	"github.com/leg100/pug/internal/tui"
+	"github.com/leg100/pug/internal/tui/table"
+	"github.com/leg100/pug/internal/workspace"
+)
+
+var searchMatchColumn = table.Column{
+	Key:        "match",
+	Title:      "MATCH",
+	FlexFactor: 2,
+}
+
+type SearchMaker struct {
+	States     *state.Service
+	Workspaces *workspace.Service
+}
+
+func (mm *SearchMaker) Make(id resource.ID, width, height int) (tui.ChildModel, error) {
+	query, ok := id.(state.SearchQuery)
+	if !ok {
+		return nil, fmt.Errorf("invalid search query: %v", id)
+	}
+	columns := []table.Column{
+		table.ModuleColumn,
+		table.WorkspaceColumn,
+		resourceColumn,
+		searchMatchColumn,
+	}
+	renderer := func(result state.SearchResult) table.RenderedRow {
+		row := table.RenderedRow{
+			resourceColumn.Key: string(result.Resource.Address),
+		}
+		if ws, err := mm.Workspaces.Get(result.Resource.WorkspaceID); err == nil {
+			row[table.ModuleColumn.Key] = ws.ModulePath
+			row[table.WorkspaceColumn.Key] = ws.Name
+		}
+		if result.Path != "" {
+			row[searchMatchColumn.Key] = fmt.Sprintf("%s = %s", result.Path, result.Value)
+		}
+		return row
+	}
+	tbl := table.New(
+		columns,
+		renderer,
+		width,
+		height,
+		table.WithSortFunc(byResultAddress),
+		table.WithPreview[state.SearchResult](tui.ResourceKind),
+	)
+	return &searchResults{
+		Model:  tbl,
+		states: mm.States,
+		query:  query,
+	}, nil
+}
+
+// searchResults lists the resources across all cached states that match a
+// search query.
+type searchResults struct {
+	table.Model[state.SearchResult]
+
+	states *state.Service
+	query  state.SearchQuery
+}
+
+func (m *searchResults) Init() tea.Cmd {
+	return m.search()
+}
+
+func (m *searchResults) Update(msg tea.Msg) tea.Cmd {
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, resourcesKeys.Enter):
+			if row, ok := m.CurrentRow(); ok {
+				return tui.NavigateTo(tui.ResourceKind, tui.WithParent(row.Resource.ID))
+			}
+		}
+	case resource.Event[*state.State]:
+		// A state has been added or replaced, so re-run the search.
+		return m.search()
+	}
+
+	// Handle keyboard and mouse events in the table widget
+	m.Model, cmd = m.Model.Update(msg)
+	return cmd
+}
+
+// search populates the table with the results of the search.
+func (m *searchResults) search() tea.Cmd {
+	results, err := m.states.Search(m.query)
+	if err != nil {
+		return tui.ReportError(fmt.Errorf("searching state: %w", err))
+	}
+	m.SetItems(results...)
+	return nil
+}
+
+func (m *searchResults) BorderText() map[tui.BorderPosition]string {
+	return map[tui.BorderPosition]string{
+		tui.TopLeftBorder: fmt.Sprintf(
+			"%s %s",
+			tui.Bold.Render("search"),
+			m.query,
+		),
+		tui.TopMiddleBorder: m.Metadata(),
+	}
+}
+
+func (m *searchResults) HelpBindings() []key.Binding {
+	return []key.Binding{
+		resourcesKeys.Enter,
+	}
+}
+
+func byResultAddress(i, j state.SearchResult) int {
+	return cmp.Compare(i.Resource.Address, j.Resource.Address)
+}
@@ -0,0 +1,129 @@
+package workspace
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/leg100/pug/internal/resource"
+	"github.com/leg100/pug/internal/state"
+	"github.com/leg100/pug/internal/tui"
+	"github.com/leg100/pug/internal/workspace"
+)
+
+type SnapshotMaker struct {
+	States     *state.Service
+	Workspaces *workspace.Service
+	Helpers    *tui.Helpers
+}
+
+func (mm *SnapshotMaker) Make(id resource.ID, width, height int) (tui.ChildModel, error) {
+	snapshot, err := mm.States.GetSnapshot(id)
+	if err != nil {
+		return nil, err
+	}
+	ws, err := mm.Workspaces.Get(snapshot.WorkspaceID)
+	if err != nil {
+		return nil, err
+	}
+	m := &snapshotModel{
+		Helpers:   mm.Helpers,
+		states:    mm.States,
+		snapshot:  snapshot,
+		workspace: ws,
+		viewport: tui.NewViewport(tui.ViewportOptions{
+			Width:  width,
+			Height: height,
+		}),
+	}
+	if err := m.setContent(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// snapshotModel shows the changes that restoring a snapshot would make to the
+// current state.
+type snapshotModel struct {
+	*tui.Helpers
+
+	states    *state.Service
+	snapshot  *state.Snapshot
+	workspace *workspace.Workspace
+	viewport  tui.Viewport
+}
+
+func (m *snapshotModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m *snapshotModel) Update(msg tea.Msg) tea.Cmd {
+	var (
+		cmd  tea.Cmd
+		cmds []tea.Cmd
+	)
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, snapshotsKeys.Restore):
+			return restorePrompt(m.Helpers, m.states, m.workspace, m.snapshot)
+		}
+	case resource.Event[*state.State]:
+		if msg.Payload.WorkspaceID != m.workspace.ID {
+			return nil
+		}
+		// Current state has changed so re-calculate the diff
+		if err := m.setContent(); errors.Is(err, resource.ErrNotFound) {
+			// Snapshot has since been pruned
+			return nil
+		} else if err != nil {
+			return tui.ReportError(fmt.Errorf("diffing snapshot: %w", err))
+		}
+		return nil
+	case tea.WindowSizeMsg:
+		m.viewport.SetDimensions(msg.Width, msg.Height)
+		return nil
+	}
+
+	// Handle keyboard and mouse events in the viewport
+	m.viewport, cmd = m.viewport.Update(msg)
+	cmds = append(cmds, cmd)
+
+	return tea.Batch(cmds...)
+}
+
+func (m *snapshotModel) setContent() error {
+	snapshotState, err := m.states.GetSnapshotState(m.snapshot.ID)
+	if err != nil {
+		return err
+	}
+	// Current state may not yet have been loaded, in which case the diff is
+	// performed against an empty state.
+	current, _ := m.states.Get(m.workspace.ID)
+	diff := state.NewDiff(current, snapshotState)
+	return m.viewport.SetContent([]byte(renderDiff(diff)))
+}
+
+func (m *snapshotModel) View() string {
+	return m.viewport.View()
+}
+
+func (m *snapshotModel) BorderText() map[tui.BorderPosition]string {
+	return map[tui.BorderPosition]string{
+		tui.TopLeftBorder: fmt.Sprintf(
+			"%s #%d %s",
+			tui.Bold.Render("snapshot"),
+			m.snapshot.Serial,
+			tui.WorkspaceNameWithIcon(m.workspace.Name, true),
+		),
+		tui.TopMiddleBorder: "changes upon restore",
+	}
+}
+
+func (m *snapshotModel) HelpBindings() []key.Binding {
+	return []key.Binding{
+		snapshotsKeys.Restore,
+	}
+}
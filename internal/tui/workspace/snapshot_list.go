@@ -0,0 +1,176 @@
+package workspace
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/leg100/pug/internal/resource"
+	"github.com/leg100/pug/internal/state"
+	"github.com/leg100/pug/internal/tui"
+	"github.com/leg100/pug/internal/tui/table"
+	"github.com/leg100/pug/internal/workspace"
+)
+
+var (
+	snapshotCreatedColumn = table.Column{
+		Key:   "created",
+		Title: "CREATED",
+		Width: len(time.DateTime),
+	}
+	snapshotAgeColumn = table.Column{
+		Key:   "age",
+		Title: "AGE",
+		Width: 7,
+	}
+	snapshotSerialColumn = table.Column{
+		Key:   "serial",
+		Title: "SERIAL",
+		Width: 8,
+	}
+	snapshotResourcesColumn = table.Column{
+		Key:        "resources",
+		Title:      "RESOURCES",
+		FlexFactor: 1,
+	}
+)
+
+type SnapshotListMaker struct {
+	States     *state.Service
+	Workspaces *workspace.Service
+	Helpers    *tui.Helpers
+}
+
+func (mm *SnapshotListMaker) Make(workspaceID resource.ID, width, height int) (tui.ChildModel, error) {
+	ws, err := mm.Workspaces.Get(workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	columns := []table.Column{
+		snapshotCreatedColumn,
+		snapshotAgeColumn,
+		snapshotSerialColumn,
+		snapshotResourcesColumn,
+	}
+	renderer := func(snapshot *state.Snapshot) table.RenderedRow {
+		return table.RenderedRow{
+			snapshotCreatedColumn.Key:   snapshot.Created.Local().Format(time.DateTime),
+			snapshotAgeColumn.Key:       tui.Ago(time.Now(), snapshot.Created),
+			snapshotSerialColumn.Key:    fmt.Sprintf("#%d", snapshot.Serial),
+			snapshotResourcesColumn.Key: fmt.Sprintf("%d", snapshot.Resources),
+		}
+	}
+	tbl := table.New(
+		columns,
+		renderer,
+		width,
+		height,
+		table.WithSortFunc(byNewestSnapshot),
+		table.WithSelectable[*state.Snapshot](false),
+		table.WithPreview[*state.Snapshot](tui.SnapshotKind),
+	)
+	return &snapshotList{
+		Model:     tbl,
+		Helpers:   mm.Helpers,
+		states:    mm.States,
+		workspace: ws,
+	}, nil
+}
+
+type snapshotList struct {
+	table.Model[*state.Snapshot]
+	*tui.Helpers
+
+	states    *state.Service
+	workspace *workspace.Workspace
+}
+
+func (m *snapshotList) Init() tea.Cmd {
+	return func() tea.Msg {
+		snapshots, err := m.states.ListSnapshots(m.workspace.ID)
+		if err != nil {
+			return tui.ErrorMsg(fmt.Errorf("listing snapshots: %w", err))
+		}
+		return table.BulkInsertMsg[*state.Snapshot](snapshots)
+	}
+}
+
+func (m *snapshotList) Update(msg tea.Msg) tea.Cmd {
+	var (
+		cmd  tea.Cmd
+		cmds []tea.Cmd
+	)
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, snapshotsKeys.Enter):
+			if row, ok := m.CurrentRow(); ok {
+				return tui.NavigateTo(tui.SnapshotKind, tui.WithParent(row.ID))
+			}
+		case key.Matches(msg, snapshotsKeys.Restore):
+			if row, ok := m.CurrentRow(); ok {
+				return restorePrompt(m.Helpers, m.states, m.workspace, row)
+			}
+		}
+	case resource.Event[*state.Snapshot]:
+		if msg.Payload.WorkspaceID != m.workspace.ID {
+			return nil
+		}
+	}
+
+	// Handle keyboard and mouse events in the table widget
+	m.Model, cmd = m.Model.Update(msg)
+	cmds = append(cmds, cmd)
+
+	return tea.Batch(cmds...)
+}
+
+func (m *snapshotList) BorderText() map[tui.BorderPosition]string {
+	return map[tui.BorderPosition]string{
+		tui.TopLeftBorder: fmt.Sprintf(
+			"%s %s %s",
+			tui.Bold.Render("snapshots"),
+			tui.ModulePathWithIcon(m.workspace.ModulePath, true),
+			tui.WorkspaceNameWithIcon(m.workspace.Name, true),
+		),
+		tui.TopMiddleBorder: m.Metadata(),
+	}
+}
+
+func (m *snapshotList) HelpBindings() []key.Binding {
+	return []key.Binding{
+		snapshotsKeys.Enter,
+		snapshotsKeys.Restore,
+	}
+}
+
+// restorePrompt prompts the user to confirm restoring a snapshot. Restoring
+// overwrites the workspace's state, so rather than a simple yes/no the user must
+// type the name of the workspace to confirm.
+func restorePrompt(helpers *tui.Helpers, states *state.Service, ws *workspace.Workspace, snapshot *state.Snapshot) tea.Cmd {
+	return tui.CmdHandler(tui.PromptMsg{
+		Prompt: fmt.Sprintf(
+			"Restore snapshot #%d, overwriting state? Enter workspace name to confirm: ",
+			snapshot.Serial,
+		),
+		Placeholder: ws.Name,
+		Action: func(v string) tea.Cmd {
+			if v != ws.Name {
+				return tui.ReportError(fmt.Errorf("workspace name does not match %s: restore canceled", ws.Name))
+			}
+			spec, err := states.RestoreSnapshot(snapshot.ID)
+			if err != nil {
+				return tui.ReportError(fmt.Errorf("restoring snapshot: %w", err))
+			}
+			return helpers.CreateTasksWithSpecs(spec)
+		},
+		Key:    key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "confirm")),
+		Cancel: key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "cancel")),
+	})
+}
+
+func byNewestSnapshot(i, j *state.Snapshot) int {
+	return j.Created.Compare(i.Created)
+}
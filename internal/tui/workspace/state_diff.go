@@ -0,0 +1,97 @@
+package workspace
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/leg100/pug/internal/resource"
+	"github.com/leg100/pug/internal/state"
+	"github.com/leg100/pug/internal/tui"
+	"github.com/leg100/pug/internal/workspace"
+)
+
+type StateDiffMaker struct {
+	States     *state.Service
+	Workspaces *workspace.Service
+}
+
+func (mm *StateDiffMaker) Make(id resource.ID, width, height int) (tui.ChildModel, error) {
+	diffID, ok := id.(state.DiffID)
+	if !ok {
+		return nil, fmt.Errorf("invalid state diff ID: %v", id)
+	}
+	ws, err := mm.Workspaces.Get(diffID.WorkspaceID)
+	if err != nil {
+		return nil, err
+	}
+	before, err := mm.States.GetFromHistory(diffID.WorkspaceID, diffID.Before)
+	if err != nil {
+		return nil, err
+	}
+	after, err := mm.States.GetFromHistory(diffID.WorkspaceID, diffID.After)
+	if err != nil {
+		return nil, err
+	}
+	diff := state.NewDiff(before, after)
+	m := &stateDiffModel{
+		before:    before.Serial,
+		after:     after.Serial,
+		workspace: ws,
+		viewport: tui.NewViewport(tui.ViewportOptions{
+			Width:  width,
+			Height: height,
+		}),
+	}
+	if err := m.viewport.SetContent([]byte(renderDiff(diff))); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// stateDiffModel shows the changes between two serials of a workspace's
+// state.
+type stateDiffModel struct {
+	// before and after are the serials of the states, the former being -1 for
+	// an empty state.
+	before, after int64
+	workspace     *workspace.Workspace
+	viewport      tui.Viewport
+}
+
+func (m *stateDiffModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m *stateDiffModel) Update(msg tea.Msg) tea.Cmd {
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.viewport.SetDimensions(msg.Width, msg.Height)
+		return nil
+	}
+
+	// Handle keyboard and mouse events in the viewport
+	m.viewport, cmd = m.viewport.Update(msg)
+	return cmd
+}
+
+func (m *stateDiffModel) View() string {
+	return m.viewport.View()
+}
+
+func (m *stateDiffModel) BorderText() map[tui.BorderPosition]string {
+	before := fmt.Sprintf("#%d", m.before)
+	if m.before < 0 {
+		before = "empty"
+	}
+	return map[tui.BorderPosition]string{
+		tui.TopLeftBorder: fmt.Sprintf(
+			"%s %s → #%d %s",
+			tui.Bold.Render("diff"),
+			before,
+			m.after,
+			tui.WorkspaceNameWithIcon(m.workspace.Name, true),
+		),
+	}
+}
@@ -0,0 +1,267 @@
+package web
+
+import (
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const esc = 0x1b
+
+// basicColors are the CSS colors of the 16 basic terminal colors, the first
+// eight of which are the normal colors, and the remainder their bright
+// counterparts.
+var basicColors = [16]string{
+	"#000000", "#cd3131", "#0dbc79", "#e5e510", "#2472c8", "#bc3fbc", "#11a8cd", "#e5e5e5",
+	"#666666", "#f14c4c", "#23d18b", "#f5f543", "#3b8eea", "#d670d6", "#29b8db", "#ffffff",
+}
+
+// ansiConverter converts terminal output into HTML, rendering the colors and
+// text attributes set by ANSI escape sequences, and discarding any other
+// escape sequences. Output is converted in chunks, and the converter retains
+// the current style, along with any escape sequence or character cut short at
+// the end of a chunk, from one chunk to the next.
+type ansiConverter struct {
+	style   sgrStyle
+	pending []byte
+}
+
+// sgrStyle is the style set by SGR (Select Graphic Rendition) escape
+// sequences.
+type sgrStyle struct {
+	fg, bg    string
+	bold      bool
+	faint     bool
+	italic    bool
+	underline bool
+}
+
+// css renders the style as an inline CSS declaration.
+func (s sgrStyle) css() string {
+	var decls []string
+	if s.fg != "" {
+		decls = append(decls, "color:"+s.fg)
+	}
+	if s.bg != "" {
+		decls = append(decls, "background-color:"+s.bg)
+	}
+	if s.bold {
+		decls = append(decls, "font-weight:bold")
+	}
+	if s.faint {
+		decls = append(decls, "opacity:0.7")
+	}
+	if s.italic {
+		decls = append(decls, "font-style:italic")
+	}
+	if s.underline {
+		decls = append(decls, "text-decoration:underline")
+	}
+	return strings.Join(decls, ";")
+}
+
+// convert converts a chunk of output into HTML.
+func (c *ansiConverter) convert(chunk []byte) string {
+	var (
+		b    = append(c.pending, chunk...)
+		out  strings.Builder
+		text []byte
+	)
+	c.pending = nil
+
+	flush := func() {
+		if len(text) == 0 {
+			return
+		}
+		escaped := html.EscapeString(string(text))
+		if css := c.style.css(); css != "" {
+			fmt.Fprintf(&out, `<span style="%s">%s</span>`, css, escaped)
+		} else {
+			out.WriteString(escaped)
+		}
+		text = text[:0]
+	}
+
+	i := 0
+loop:
+	for i < len(b) {
+		if b[i] != esc {
+			text = append(text, b[i])
+			i++
+			continue
+		}
+		if i+1 == len(b) {
+			break loop
+		}
+		switch b[i+1] {
+		case '[':
+			// Control sequence: parameter and intermediate bytes followed by
+			// a final byte.
+			j := i + 2
+			for j < len(b) && b[j] >= 0x20 && b[j] <= 0x3f {
+				j++
+			}
+			if j == len(b) {
+				break loop
+			}
+			if b[j] == 'm' {
+				flush()
+				c.style.apply(string(b[i+2 : j]))
+			}
+			i = j + 1
+		case ']':
+			// Operating system command.
+			n := oscLen(b[i+2:])
+			if n < 0 {
+				break loop
+			}
+			i += 2 + n
+		default:
+			// Other escape sequence: intermediate bytes followed by a final
+			// byte.
+			j := i + 1
+			for j < len(b) && b[j] >= 0x20 && b[j] <= 0x2f {
+				j++
+			}
+			if j == len(b) {
+				break loop
+			}
+			i = j + 1
+		}
+	}
+	if i < len(b) {
+		// Retain incomplete escape sequence.
+		c.pending = append(c.pending, b[i:]...)
+	} else if n := incompleteRune(text); n > 0 {
+		// Retain incomplete UTF-8 character.
+		c.pending = append(c.pending, text[len(text)-n:]...)
+		text = text[:len(text)-n]
+	}
+	flush()
+	return out.String()
+}
+
+// oscLen returns the length of an operating system command up to and including
+// its terminator, either BEL or ST, or -1 if it is unterminated.
+func oscLen(b []byte) int {
+	for i := range b {
+		if b[i] == 0x07 {
+			return i + 1
+		}
+		if b[i] == esc && i+1 < len(b) && b[i+1] == '\\' {
+			return i + 2
+		}
+	}
+	return -1
+}
+
+// incompleteRune returns the number of bytes at the end of b belonging to an
+// incomplete UTF-8 encoded character.
+func incompleteRune(b []byte) int {
+	for n := 1; n <= min(len(b), utf8.UTFMax-1); n++ {
+		if utf8.RuneStart(b[len(b)-n]) {
+			if utf8.FullRune(b[len(b)-n:]) {
+				return 0
+			}
+			return n
+		}
+	}
+	return 0
+}
+
+// apply applies the parameters of an SGR escape sequence to the style.
+func (s *sgrStyle) apply(params string) {
+	codes := strings.Split(params, ";")
+	for i := 0; i < len(codes); i++ {
+		code, _ := strconv.Atoi(codes[i])
+		switch {
+		case code == 0:
+			*s = sgrStyle{}
+		case code == 1:
+			s.bold = true
+		case code == 2:
+			s.faint = true
+		case code == 3:
+			s.italic = true
+		case code == 4:
+			s.underline = true
+		case code == 22:
+			s.bold, s.faint = false, false
+		case code == 23:
+			s.italic = false
+		case code == 24:
+			s.underline = false
+		case code >= 30 && code <= 37:
+			s.fg = basicColors[code-30]
+		case code == 38:
+			var color string
+			color, i = extendedColor(codes, i)
+			s.fg = color
+		case code == 39:
+			s.fg = ""
+		case code >= 40 && code <= 47:
+			s.bg = basicColors[code-40]
+		case code == 48:
+			var color string
+			color, i = extendedColor(codes, i)
+			s.bg = color
+		case code == 49:
+			s.bg = ""
+		case code >= 90 && code <= 97:
+			s.fg = basicColors[code-90+8]
+		case code >= 100 && code <= 107:
+			s.bg = basicColors[code-100+8]
+		}
+	}
+}
+
+// extendedColor parses an extended color, either one of 256 colors, or an RGB
+// color, from the codes following the code at index i, returning the CSS
+// color and the index of the last code consumed.
+func extendedColor(codes []string, i int) (string, int) {
+	if i+1 >= len(codes) {
+		return "", i
+	}
+	switch codes[i+1] {
+	case "5":
+		if i+2 >= len(codes) {
+			return "", i + 1
+		}
+		n, _ := strconv.Atoi(codes[i+2])
+		return color256(n), i + 2
+	case "2":
+		if i+4 >= len(codes) {
+			return "", len(codes) - 1
+		}
+		r, _ := strconv.Atoi(codes[i+2])
+		g, _ := strconv.Atoi(codes[i+3])
+		b, _ := strconv.Atoi(codes[i+4])
+		return fmt.Sprintf("#%02x%02x%02x", r&0xff, g&0xff, b&0xff), i + 4
+	}
+	return "", i + 1
+}
+
+// color256 returns the CSS color of one of the 256 terminal colors: the 16
+// basic colors, followed by a 6x6x6 color cube, followed by 24 shades of grey.
+func color256(n int) string {
+	switch {
+	case n < 0 || n > 255:
+		return ""
+	case n < 16:
+		return basicColors[n]
+	case n < 232:
+		n -= 16
+		level := func(v int) int {
+			if v == 0 {
+				return 0
+			}
+			return 55 + v*40
+		}
+		return fmt.Sprintf("#%02x%02x%02x", level(n/36), level(n/6%6), level(n%6))
+	default:
+		v := 8 + (n-232)*10
+		return fmt.Sprintf("#%02x%02x%02x", v, v, v)
+	}
+}
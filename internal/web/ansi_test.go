@@ -0,0 +1,68 @@
+package web
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestANSIConverter(t *testing.T) {
+	tests := []struct {
+		name   string
+		chunks []string
+		want   string
+	}{
+		{
+			name:   "plain text",
+			chunks: []string{"hello <world> & co"},
+			want:   "hello &lt;world&gt; &amp; co",
+		},
+		{
+			name:   "basic color",
+			chunks: []string{"\x1b[32m+ create\x1b[0m done"},
+			want:   `<span style="color:#0dbc79">+ create</span> done`,
+		},
+		{
+			name:   "bold and bright background",
+			chunks: []string{"\x1b[1;101mError\x1b[22m!\x1b[m"},
+			want:   `<span style="background-color:#f14c4c;font-weight:bold">Error</span><span style="background-color:#f14c4c">!</span>`,
+		},
+		{
+			name:   "256 colors",
+			chunks: []string{"\x1b[38;5;196mred\x1b[38;5;244mgrey"},
+			want:   `<span style="color:#ff0000">red</span><span style="color:#808080">grey</span>`,
+		},
+		{
+			name:   "rgb color",
+			chunks: []string{"\x1b[48;2;1;2;3mrgb"},
+			want:   `<span style="background-color:#010203">rgb</span>`,
+		},
+		{
+			name:   "discard other sequences",
+			chunks: []string{"\x1b[2K\x1b]0;title\x07a\x1b]8;;http://x\x1b\\b\x1b(Bc"},
+			want:   "abc",
+		},
+		{
+			name:   "sequence split across chunks",
+			chunks: []string{"a\x1b[3", "1mb", "\x1b", "[0mc"},
+			want:   `a<span style="color:#cd3131">b</span>c`,
+		},
+		{
+			name:   "character split across chunks",
+			chunks: []string{"\xe2\x88", "\x92"},
+			want:   "−",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				c   ansiConverter
+				got string
+			)
+			for _, chunk := range tt.chunks {
+				got += c.convert([]byte(chunk))
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
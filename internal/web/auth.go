@@ -0,0 +1,57 @@
+package web
+
+import (
+	"crypto/subtle"
+	"net"
+	"net/http"
+	"slices"
+)
+
+// tokenCookie is the name of the cookie in which the token is stored once the
+// browser has provided it as a query parameter.
+const tokenCookie = "pug-token"
+
+// protect rejects requests unless they are addressed to a loopback host on the
+// given port, which guards against DNS rebinding, and unless they provide the
+// token, either as a query parameter or a cookie. A token provided as a query
+// parameter is stored in a cookie, and the browser is redirected to the same
+// URL without the token.
+func protect(token, port string, next http.Handler) http.Handler {
+	hosts := []string{
+		net.JoinHostPort("localhost", port),
+		net.JoinHostPort("127.0.0.1", port),
+		net.JoinHostPort("::1", port),
+	}
+	valid := func(got string) bool {
+		return subtle.ConstantTimeCompare([]byte(got), []byte(token)) == 1
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !slices.Contains(hosts, r.Host) {
+			http.Error(w, "invalid host", http.StatusForbidden)
+			return
+		}
+		if q := r.URL.Query(); q.Has("token") {
+			if !valid(q.Get("token")) {
+				http.Error(w, "invalid token", http.StatusUnauthorized)
+				return
+			}
+			http.SetCookie(w, &http.Cookie{
+				Name:     tokenCookie,
+				Value:    token,
+				Path:     "/",
+				HttpOnly: true,
+				SameSite: http.SameSiteStrictMode,
+			})
+			q.Del("token")
+			u := *r.URL
+			u.RawQuery = q.Encode()
+			http.Redirect(w, r, u.RequestURI(), http.StatusFound)
+			return
+		}
+		if cookie, err := r.Cookie(tokenCookie); err != nil || !valid(cookie.Value) {
+			http.Error(w, "invalid or missing token: open the dashboard with ?token=<token>", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
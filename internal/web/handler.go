@@ -0,0 +1,360 @@
+package web
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/leg100/pug/internal/app"
+	"github.com/leg100/pug/internal/resource"
+	"github.com/leg100/pug/internal/task"
+	"github.com/leg100/pug/internal/workspace"
+)
+
+//go:embed templates static
+var files embed.FS
+
+// refreshInterval is how often listings are refreshed in the browser.
+const refreshInterval = 5 * time.Second
+
+var funcs = template.FuncMap{
+	"ago": func(t time.Time) string {
+		return time.Since(t).Round(time.Second).String() + " ago"
+	},
+	"seconds": func(d time.Duration) int {
+		return int(d.Seconds())
+	},
+}
+
+// handler serves the read-only pages of the dashboard. Only GET requests are
+// routed; the dashboard cannot create or otherwise alter resources.
+type handler struct {
+	*app.App
+
+	pages map[string]*template.Template
+}
+
+// page is the data with which a page is rendered.
+type page struct {
+	Title string
+	// Refresh is the interval at which the page is refreshed. Zero disables
+	// refreshing.
+	Refresh time.Duration
+	Data    any
+}
+
+func newHandler(app *app.App) http.Handler {
+	h := &handler{
+		App:   app,
+		pages: make(map[string]*template.Template),
+	}
+	for _, name := range []string{"tasks", "task", "groups", "group", "workspaces"} {
+		h.pages[name] = template.Must(template.New("").Funcs(funcs).ParseFS(files,
+			"templates/layout.html",
+			"templates/"+name+".html",
+		))
+	}
+	static, err := fs.Sub(files, "static")
+	if err != nil {
+		panic(err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /static/", http.StripPrefix("/static/", http.FileServerFS(static)))
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/tasks", http.StatusFound)
+	})
+	mux.HandleFunc("GET /tasks", h.listTasks)
+	mux.HandleFunc("GET /tasks/{id}", h.getTask)
+	mux.HandleFunc("GET /tasks/{id}/output", h.streamOutput)
+	mux.HandleFunc("GET /groups", h.listGroups)
+	mux.HandleFunc("GET /groups/{id}", h.getGroup)
+	mux.HandleFunc("GET /workspaces", h.listWorkspaces)
+	return mux
+}
+
+type taskRow struct {
+	ID        uint
+	GroupID   *uint
+	Module    string
+	Workspace string
+	Command   string
+	Status    task.Status
+	Final     bool
+	Summary   string
+	Error     string
+	Created   time.Time
+}
+
+type groupRow struct {
+	ID       uint
+	Command  string
+	Tasks    int
+	Finished int
+	Errored  int
+	Created  time.Time
+}
+
+type workspaceRow struct {
+	Module    string
+	Name      string
+	Current   bool
+	Resources string
+	Cost      string
+}
+
+func (h *handler) listTasks(w http.ResponseWriter, r *http.Request) {
+	h.render(w, "tasks", page{
+		Title:   "Tasks",
+		Refresh: refreshInterval,
+		Data:    h.taskRows(h.Tasks.List(task.ListOptions{})),
+	})
+}
+
+func (h *handler) getTask(w http.ResponseWriter, r *http.Request) {
+	t, ok := h.task(w, r)
+	if !ok {
+		return
+	}
+	data := struct {
+		Task   taskRow
+		Args   string
+		Path   string
+		Output template.HTML
+	}{
+		Task: h.taskRow(t),
+		Args: strings.Join(t.Args, " "),
+		Path: t.Path,
+	}
+	if data.Task.Final {
+		// The output is complete, so render it in full rather than
+		// streaming it.
+		var c ansiConverter
+		b, _ := io.ReadAll(t.NewReader(true))
+		data.Output = template.HTML(c.convert(b))
+	}
+	h.render(w, "task", page{
+		Title: fmt.Sprintf("Task #%d", t.ID.Serial),
+		Data:  data,
+	})
+}
+
+// streamOutput streams a task's output as server-sent events, each of which
+// is a chunk of output converted to HTML. A done event is sent once the task
+// has finished.
+func (h *handler) streamOutput(w http.ResponseWriter, r *http.Request) {
+	t, ok := h.task(w, r)
+	if !ok {
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	var (
+		c      ansiConverter
+		output = t.NewStreamer()
+	)
+	for {
+		select {
+		case chunk, ok := <-output:
+			if !ok {
+				fmt.Fprint(w, "event: done\ndata:\n\n")
+				flusher.Flush()
+				return
+			}
+			var buf bytes.Buffer
+			buf.WriteString("event: output\n")
+			// Each line of an event's data is prefixed with data, and joined
+			// together again with newlines by the browser.
+			for _, line := range strings.Split(c.convert(chunk), "\n") {
+				fmt.Fprintf(&buf, "data: %s\n", line)
+			}
+			buf.WriteString("\n")
+			if _, err := w.Write(buf.Bytes()); err != nil {
+				go drain(output)
+				return
+			}
+			flusher.Flush()
+		case <-r.Context().Done():
+			go drain(output)
+			return
+		}
+	}
+}
+
+func (h *handler) listGroups(w http.ResponseWriter, r *http.Request) {
+	groups := h.Tasks.ListGroups()
+	slices.SortFunc(groups, func(a, b *task.Group) int {
+		return b.Created.Compare(a.Created)
+	})
+	rows := make([]groupRow, len(groups))
+	for i, g := range groups {
+		rows[i] = groupRow{
+			ID:      g.ID.Serial,
+			Command: g.Command,
+			Tasks:   len(g.Tasks),
+			Created: g.Created,
+		}
+		for _, t := range g.Tasks {
+			state := t.CurrentState()
+			if state.IsFinal() {
+				rows[i].Finished++
+			}
+			if state == task.Errored {
+				rows[i].Errored++
+			}
+		}
+	}
+	h.render(w, "groups", page{
+		Title:   "Task Groups",
+		Refresh: refreshInterval,
+		Data:    rows,
+	})
+}
+
+func (h *handler) getGroup(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r, resource.TaskGroup)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	g, err := h.Tasks.GetGroup(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	h.render(w, "group", page{
+		Title:   fmt.Sprintf("Task Group #%d: %s", g.ID.Serial, g.Command),
+		Refresh: refreshInterval,
+		Data:    h.taskRows(g.Tasks),
+	})
+}
+
+func (h *handler) listWorkspaces(w http.ResponseWriter, r *http.Request) {
+	workspaces := h.Workspaces.List(workspace.ListOptions{})
+	slices.SortFunc(workspaces, func(a, b *workspace.Workspace) int {
+		if c := strings.Compare(a.ModulePath, b.ModulePath); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	rows := make([]workspaceRow, len(workspaces))
+	for i, ws := range workspaces {
+		rows[i] = workspaceRow{
+			Module:    ws.ModulePath,
+			Name:      ws.Name,
+			Resources: "-",
+			Cost:      "-",
+		}
+		if mod, err := h.Modules.Get(ws.ModuleID); err == nil {
+			rows[i].Current = mod.CurrentWorkspaceID == ws.ID
+		}
+		if state, err := h.States.Get(ws.ID); err == nil {
+			rows[i].Resources = strconv.Itoa(len(state.Resources))
+		}
+		if ws.Cost != nil {
+			rows[i].Cost = fmt.Sprintf("$%.2f", *ws.Cost)
+		}
+	}
+	h.render(w, "workspaces", page{
+		Title:   "Workspaces",
+		Refresh: refreshInterval,
+		Data:    rows,
+	})
+}
+
+// task retrieves the task identified in the request path, writing an error
+// if it cannot be retrieved.
+func (h *handler) task(w http.ResponseWriter, r *http.Request) (*task.Task, bool) {
+	id, err := parseID(r, resource.Task)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+	t, err := h.Tasks.Get(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return nil, false
+	}
+	return t, true
+}
+
+func (h *handler) taskRows(tasks []*task.Task) []taskRow {
+	rows := make([]taskRow, len(tasks))
+	for i, t := range tasks {
+		rows[i] = h.taskRow(t)
+	}
+	return rows
+}
+
+func (h *handler) taskRow(t *task.Task) taskRow {
+	state, summary, err := t.Result()
+	row := taskRow{
+		ID:      t.ID.Serial,
+		Command: t.String(),
+		Status:  state,
+		Final:   state.IsFinal(),
+		Created: t.Created,
+	}
+	if id, ok := t.TaskGroupID.(resource.MonotonicID); ok {
+		row.GroupID = &id.Serial
+	}
+	if t.ModuleID != nil {
+		if mod, err := h.Modules.Get(t.ModuleID); err == nil {
+			row.Module = mod.Path
+		}
+	}
+	if t.WorkspaceID != nil {
+		if ws, err := h.Workspaces.Get(t.WorkspaceID); err == nil {
+			row.Workspace = ws.Name
+		}
+	}
+	if summary != nil {
+		row.Summary = summary.String()
+	}
+	if err != nil {
+		row.Error = err.Error()
+	}
+	return row
+}
+
+func (h *handler) render(w http.ResponseWriter, name string, p page) {
+	// Render to a buffer first, to avoid writing a partial page.
+	var buf bytes.Buffer
+	if err := h.pages[name].ExecuteTemplate(&buf, "layout", p); err != nil {
+		h.Logger.Error("rendering web page", "page", name, "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = buf.WriteTo(w)
+}
+
+// drain discards what remains of a task's output stream, which otherwise
+// blocks until its output is received.
+func drain(output <-chan []byte) {
+	for range output {
+	}
+}
+
+// parseID parses the serial number of the resource ID in the request path.
+func parseID(r *http.Request, kind resource.Kind) (resource.ID, error) {
+	serial, err := strconv.ParseUint(r.PathValue("id"), 10, 0)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s ID: %s", kind, r.PathValue("id"))
+	}
+	return resource.MonotonicID{Serial: uint(serial), Kind: kind}, nil
+}
@@ -0,0 +1,177 @@
+package web
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/leg100/pug/internal/app"
+	"github.com/leg100/pug/internal/logging"
+	"github.com/leg100/pug/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler(t *testing.T) {
+	program, workdir := testutils.Fixture(t)
+
+	app, err := app.New(app.Config{
+		Program:  program,
+		MaxTasks: 2,
+		Workdir:  workdir,
+		DataDir:  t.TempDir(),
+		Logging:  logging.Options{Level: "error"},
+	})
+	require.NoError(t, err)
+	t.Cleanup(app.Cleanup)
+
+	_, _, err = app.Modules.Reload()
+	require.NoError(t, err)
+	mod, err := app.Modules.GetByPath("a")
+	require.NoError(t, err)
+
+	// Load workspaces.
+	spec, err := app.Workspaces.Reload(mod.ID)
+	require.NoError(t, err)
+	reload, err := app.Tasks.Create(spec)
+	require.NoError(t, err)
+	require.NoError(t, reload.Wait())
+
+	spec, err = app.Modules.Validate(mod.ID)
+	require.NoError(t, err)
+	task, err := app.Tasks.Create(spec)
+	require.NoError(t, err)
+
+	srv := httptest.NewUnstartedServer(nil)
+	_, port, err := net.SplitHostPort(srv.Listener.Addr().String())
+	require.NoError(t, err)
+	srv.Config.Handler = protect("secret", port, newHandler(app))
+	srv.Start()
+	t.Cleanup(srv.Close)
+
+	// Authenticate the client, which stores the token in a cookie.
+	jar, err := cookiejar.New(nil)
+	require.NoError(t, err)
+	client := &http.Client{Jar: jar}
+	resp, err := client.Get(srv.URL + "/tasks?token=secret")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	// The token is removed from the URL.
+	assert.Equal(t, "/tasks", resp.Request.URL.RequestURI())
+
+	get := func(t *testing.T, path string) (int, string) {
+		resp, err := client.Get(srv.URL + path)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, string(body)
+	}
+
+	t.Run("stream output", func(t *testing.T) {
+		status, body := get(t, fmt.Sprintf("/tasks/%d/output", task.ID.Serial))
+		assert.Equal(t, http.StatusOK, status)
+		assert.Contains(t, body, "event: output\ndata: Success! module a is valid\n")
+		assert.Contains(t, body, `data: <span style="color:#e5e510">Warning:</span> &lt;deprecated&gt; syntax`)
+		assert.Contains(t, body, "event: done\n")
+	})
+
+	require.NoError(t, task.Wait())
+
+	t.Run("list tasks", func(t *testing.T) {
+		status, body := get(t, "/tasks")
+		assert.Equal(t, http.StatusOK, status)
+		assert.Contains(t, body, fmt.Sprintf(`<a href="/tasks/%d">`, task.ID.Serial))
+		assert.Contains(t, body, `<span class="status status-exited">exited</span>`)
+	})
+
+	t.Run("get finished task", func(t *testing.T) {
+		status, body := get(t, fmt.Sprintf("/tasks/%d", task.ID.Serial))
+		assert.Equal(t, http.StatusOK, status)
+		assert.Contains(t, body, `<pre id="output">Success! module a is valid
+<span style="color:#e5e510">Warning:</span> &lt;deprecated&gt; syntax`)
+		// Output of a finished task is not streamed.
+		assert.NotContains(t, body, "EventSource")
+	})
+
+	t.Run("get missing task", func(t *testing.T) {
+		status, _ := get(t, "/tasks/999999")
+		assert.Equal(t, http.StatusNotFound, status)
+	})
+
+	t.Run("list workspaces", func(t *testing.T) {
+		status, body := get(t, "/workspaces")
+		assert.Equal(t, http.StatusOK, status)
+		assert.Regexp(t, `<td>a</td>\s+<td>default <span class="current">current</span></td>`, body)
+	})
+
+	t.Run("list groups", func(t *testing.T) {
+		status, body := get(t, "/groups")
+		assert.Equal(t, http.StatusOK, status)
+		assert.Contains(t, body, "No task groups")
+	})
+
+	t.Run("mutations not allowed", func(t *testing.T) {
+		resp, err := client.Post(srv.URL+"/tasks", "application/json", nil)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+	})
+}
+
+func TestProtect(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	h := protect("secret", "7301", ok)
+
+	serve := func(target, host string, cookie *http.Cookie) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", target, nil)
+		r.Host = host
+		if cookie != nil {
+			r.AddCookie(cookie)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+	valid := &http.Cookie{Name: tokenCookie, Value: "secret"}
+
+	tests := []struct {
+		name   string
+		target string
+		host   string
+		cookie *http.Cookie
+		want   int
+	}{
+		{"valid cookie", "/tasks", "localhost:7301", valid, http.StatusOK},
+		{"ipv4 host", "/tasks", "127.0.0.1:7301", valid, http.StatusOK},
+		{"ipv6 host", "/tasks", "[::1]:7301", valid, http.StatusOK},
+		{"valid query param", "/tasks?token=secret", "localhost:7301", nil, http.StatusFound},
+		{"invalid query param", "/tasks?token=wrong", "localhost:7301", nil, http.StatusUnauthorized},
+		{"invalid cookie", "/tasks", "localhost:7301", &http.Cookie{Name: tokenCookie, Value: "wrong"}, http.StatusUnauthorized},
+		{"missing token", "/tasks", "localhost:7301", nil, http.StatusUnauthorized},
+		{"foreign host", "/tasks", "evil.example.com:7301", valid, http.StatusForbidden},
+		{"wrong port", "/tasks", "localhost:80", valid, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(tt.target, tt.host, tt.cookie)
+			assert.Equal(t, tt.want, w.Code)
+		})
+	}
+
+	t.Run("query param sets cookie", func(t *testing.T) {
+		w := serve("/tasks?token=secret&foo=bar", "localhost:7301", nil)
+		assert.Equal(t, "/tasks?foo=bar", w.Header().Get("Location"))
+		cookies := w.Result().Cookies()
+		if assert.Len(t, cookies, 1) {
+			assert.Equal(t, tokenCookie, cookies[0].Name)
+			assert.Equal(t, "secret", cookies[0].Value)
+			assert.True(t, cookies[0].HttpOnly)
+		}
+	})
+}
@@ -0,0 +1,66 @@
+// Package web provides an optional read-only web dashboard of the running
+// session, for watching tasks from a browser.
+package web
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/leg100/pug/internal"
+	"github.com/leg100/pug/internal/app"
+	"github.com/leg100/pug/internal/logging"
+)
+
+// Options for starting the web dashboard.
+type Options struct {
+	App *app.App
+	// Listen is the loopback address and port on which to listen.
+	Listen string
+	// Token authenticates requests.
+	Token  string
+	Logger logging.Interface
+}
+
+// Start starts the web dashboard in the background. The returned function
+// shuts down the dashboard.
+func Start(opts Options) (func(), error) {
+	if opts.Token == "" {
+		return nil, errors.New("web dashboard requires a token")
+	}
+	if err := internal.CheckLoopback(opts.Listen); err != nil {
+		return nil, fmt.Errorf("listening on web address: %w", err)
+	}
+	ln, err := net.Listen("tcp", opts.Listen)
+	if err != nil {
+		return nil, fmt.Errorf("listening on web address: %w", err)
+	}
+	_, port, err := net.SplitHostPort(ln.Addr().String())
+	if err != nil {
+		ln.Close()
+		return nil, fmt.Errorf("listening on web address: %w", err)
+	}
+	srv := &http.Server{
+		Handler:           protect(opts.Token, port, newHandler(opts.App)),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			opts.Logger.Error("serving web dashboard", "error", err)
+		}
+	}()
+	opts.Logger.Info("started web dashboard", "address", ln.Addr())
+
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		// Output streams only finish once their task finishes, so close any
+		// remaining connections once the timeout expires.
+		if err := srv.Shutdown(ctx); err != nil {
+			_ = srv.Close()
+		}
+	}, nil
+}
@@ -77,11 +77,9 @@ func (r *reloader) Reload(moduleID resource.ID) (task.Spec, error) {
 // workspace for the module.
 func (r *reloader) resetWorkspaces(mod *module.Module, discovered []string, current string) (added []string, removed []string, err error) {
 	// Gather existing workspaces for the module.
-	var existing []*Workspace
-	for _, ws := range r.table.List() {
-		if ws.ModuleID == mod.ID {
-			existing = append(existing, ws)
-		}
+	existing, err := r.table.ListBy(moduleIndex, mod.ID)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	// Add discovered workspaces that don't exist in pug
@@ -1,6 +1,7 @@
 package workspace
 
 import (
+	"fmt"
 	"strings"
 	"testing"
 
@@ -91,3 +92,32 @@ func (f *fakeWorkspaceTable) Delete(id resource.ID) {
 func (f *fakeWorkspaceTable) List() []*Workspace {
 	return f.existing
 }
+
+func (f *fakeWorkspaceTable) ListBy(index string, key any) (rows []*Workspace, err error) {
+	for _, ws := range f.existing {
+		switch index {
+		case moduleIndex:
+			if ws.ModuleID == key {
+				rows = append(rows, ws)
+			}
+		case nameIndex:
+			if (workspaceName{modulePath: ws.ModulePath, name: ws.Name}) == key {
+				rows = append(rows, ws)
+			}
+		default:
+			return nil, fmt.Errorf("no such table index: %s", index)
+		}
+	}
+	return rows, nil
+}
+
+func (f *fakeWorkspaceTable) GetBy(index string, key any) (*Workspace, error) {
+	rows, err := f.ListBy(index, key)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, resource.ErrNotFound
+	}
+	return rows[0], nil
+}
@@ -31,6 +31,8 @@ type ServiceOptions struct {
 	Logger  logging.Interface
 	DataDir string
 	Workdir internal.Workdir
+	// Events configures the brokers that publish the service's events.
+	Events []pubsub.Option
 }
 
 type workspaceTable interface {
@@ -38,7 +40,21 @@ type workspaceTable interface {
 	Update(id resource.ID, updater func(existing *Workspace) error) (*Workspace, error)
 	Delete(id resource.ID)
 	Get(id resource.ID) (*Workspace, error)
+	GetBy(index string, key any) (*Workspace, error)
 	List() []*Workspace
+	ListBy(index string, key any) ([]*Workspace, error)
+}
+
+const (
+	// moduleIndex indexes workspaces by the ID of their module.
+	moduleIndex = "module"
+	// nameIndex indexes workspaces by their module path and name.
+	nameIndex = "name"
+)
+
+type workspaceName struct {
+	modulePath string
+	name       string
 }
 
 type modules interface {
@@ -50,8 +66,16 @@ type modules interface {
 }
 
 func NewService(opts ServiceOptions) *Service {
-	broker := pubsub.NewBroker[*Workspace](opts.Logger)
-	table := resource.NewTable(broker)
+	broker := pubsub.NewBroker[*Workspace](opts.Logger, opts.Events...)
+	table := resource.NewTable(broker,
+		resource.WithIndex(moduleIndex, func(ws *Workspace) []any {
+			return []any{ws.ModuleID}
+		}),
+		resource.WithIndex(nameIndex, func(ws *Workspace) []any {
+			return []any{workspaceName{modulePath: ws.ModulePath, name: ws.Name}}
+		}),
+		resource.WithOrder(byModulePathAndName),
+	)
 
 	opts.Logger.AddArgsUpdater(&logging.ReferenceUpdater[*Workspace]{
 		Getter: table,
@@ -147,12 +171,7 @@ func (s *Service) Get(workspaceID resource.ID) (*Workspace, error) {
 }
 
 func (s *Service) GetByName(modulePath, name string) (*Workspace, error) {
-	for _, ws := range s.table.List() {
-		if ws.ModulePath == modulePath && ws.Name == name {
-			return ws, nil
-		}
-	}
-	return nil, resource.ErrNotFound
+	return s.table.GetBy(nameIndex, workspaceName{modulePath: modulePath, name: name})
 }
 
 type ListOptions struct {
@@ -161,14 +180,14 @@ type ListOptions struct {
 }
 
 func (s *Service) List(opts ListOptions) []*Workspace {
-	var existing []*Workspace
-	for _, ws := range s.table.List() {
-		if opts.ModuleID != nil && opts.ModuleID != ws.ModuleID {
-			continue
+	if opts.ModuleID != nil {
+		workspaces, err := s.table.ListBy(moduleIndex, opts.ModuleID)
+		if err != nil {
+			s.logger.Error("listing workspaces", "error", err)
 		}
-		existing = append(existing, ws)
+		return workspaces
 	}
-	return existing
+	return s.table.List()
 }
 
 // SelectWorkspace runs the `terraform workspace select <workspace_name>`
@@ -1,6 +1,8 @@
 package workspace
 
 import (
+	"cmp"
+
 	"github.com/leg100/pug/internal/module"
 	"github.com/leg100/pug/internal/resource"
 )
@@ -37,3 +39,12 @@ func Sort(getter moduleGetter) func(*Workspace, *Workspace) int {
 		}
 	}
 }
+
+// byModulePathAndName orders workspaces first by their module path and then by
+// their name.
+func byModulePathAndName(i, j *Workspace) int {
+	if n := cmp.Compare(i.ModulePath, j.ModulePath); n != 0 {
+		return n
+	}
+	return cmp.Compare(i.Name, j.Name)
+}
@@ -0,0 +1,99 @@
+package workspace
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/leg100/pug/internal"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// Variables returns the values of the variables set in the variables files
+// that terraform uses for the workspace. Files are read in the same order of
+// precedence as terraform, with later files overriding earlier files:
+//
+// 1. terraform.tfvars
+// 2. terraform.tfvars.json
+// 3. *.auto.tfvars and *.auto.tfvars.json, in lexical order
+// 4. the workspace's variables file, <workspace>.tfvars
+func (ws *Workspace) Variables(workdir internal.Workdir) (map[string]any, error) {
+	dir := workdir.Join(ws.ModulePath)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var auto []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if strings.HasSuffix(entry.Name(), ".auto.tfvars") || strings.HasSuffix(entry.Name(), ".auto.tfvars.json") {
+			auto = append(auto, entry.Name())
+		}
+	}
+	slices.Sort(auto)
+
+	files := []string{"terraform.tfvars", "terraform.tfvars.json"}
+	files = append(files, auto...)
+	if fname, ok := ws.VarsFile(workdir); ok {
+		files = append(files, fname)
+	}
+
+	var (
+		parser    = hclparse.NewParser()
+		variables = make(map[string]any)
+	)
+	for _, fname := range files {
+		path := filepath.Join(dir, fname)
+		if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err := parseVariablesFile(parser, path, variables); err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", fname, err)
+		}
+	}
+	return variables, nil
+}
+
+func parseVariablesFile(parser *hclparse.Parser, path string, variables map[string]any) error {
+	var (
+		file  *hcl.File
+		diags hcl.Diagnostics
+	)
+	if strings.HasSuffix(path, ".json") {
+		file, diags = parser.ParseJSONFile(path)
+	} else {
+		file, diags = parser.ParseHCLFile(path)
+	}
+	if diags.HasErrors() {
+		return diags
+	}
+	attrs, diags := file.Body.JustAttributes()
+	if diags.HasErrors() {
+		return diags
+	}
+	for name, attr := range attrs {
+		value, diags := attr.Expr.Value(nil)
+		if diags.HasErrors() {
+			return diags
+		}
+		// Convert cty value to a go value via json.
+		marshaled, err := ctyjson.Marshal(value, value.Type())
+		if err != nil {
+			return err
+		}
+		var v any
+		if err := json.Unmarshal(marshaled, &v); err != nil {
+			return err
+		}
+		variables[name] = v
+	}
+	return nil
+}
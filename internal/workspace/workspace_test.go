@@ -34,3 +34,35 @@ func TestWorkspace_VarsFile(t *testing.T) {
 	require.True(t, ok)
 	assert.Equal(t, "dev.tfvars", got)
 }
+
+func TestWorkspace_Variables(t *testing.T) {
+	workdir := internal.NewTestWorkdir(t)
+	mod := module.New(module.Options{Path: "a/b/c"})
+	ws, err := New(mod, "dev")
+	require.NoError(t, err)
+
+	files := map[string]string{
+		"terraform.tfvars":      "region = \"eu-west-1\"\ninstances = 1\n",
+		"b.auto.tfvars.json":    `{"tags": {"env": "json"}}`,
+		"a.auto.tfvars":         "tags = { env = \"auto\" }\nsizes = [\"small\"]\n",
+		"dev.tfvars":            "instances = 3\n",
+		"unrelated.tfvars":      "instances = 99\n",
+		"terraform.tfvars.json": `{"region": "us-east-1"}`,
+	}
+	require.NoError(t, os.MkdirAll(workdir.Join(mod.Path), 0o755))
+	for fname, content := range files {
+		err := os.WriteFile(workdir.Join(mod.Path, fname), []byte(content), 0o644)
+		require.NoError(t, err)
+	}
+
+	got, err := ws.Variables(workdir)
+	require.NoError(t, err)
+
+	want := map[string]any{
+		"region":    "us-east-1",
+		"instances": float64(3),
+		"tags":      map[string]any{"env": "json"},
+		"sizes":     []any{"small"},
+	}
+	assert.Equal(t, want, got)
+}
@@ -1,17 +1,22 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
 	"github.com/leg100/pug/internal/app"
+	"github.com/leg100/pug/internal/headless"
 	"github.com/leg100/pug/internal/tui/top"
 	"github.com/leg100/pug/internal/version"
 )
 
 func main() {
 	if err := run(); err != nil {
-		fmt.Println(err.Error())
+		fmt.Fprintln(os.Stderr, err.Error())
+		if errors.Is(err, headless.ErrPlanChanges) {
+			os.Exit(2)
+		}
 		os.Exit(1)
 	}
 }
@@ -26,6 +31,10 @@ func run() error {
 		fmt.Fprintln(os.Stdout, "pug", version.Version)
 		return nil
 	}
+	if cfg.Run != nil {
+		// Run command headlessly and block til it finishes.
+		return headless.Run(cfg, os.Stdout)
+	}
 	// Start TUI and block til user exits.
 	return top.Start(cfg)
 }